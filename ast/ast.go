@@ -10,6 +10,15 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Line() int   // 1-based source line of the node's token
+	Column() int // 1-based source column of the node's token
+}
+
+// Pattern - What a let statement or a function parameter binds: an Identifier, or an ArrayPattern or a
+// HashPattern that destructures the value
+type Pattern interface {
+	Node
+	patternNode()
 }
 
 // Statement node interface
@@ -27,6 +36,7 @@ type Expression interface {
 // Program Node - Implements the Node Interface. It is the AST root node
 type Program struct {
 	Statements []Statement
+	Comments   []token.Token // in source order
 }
 
 func (program *Program) TokenLiteral() string {
@@ -35,6 +45,18 @@ func (program *Program) TokenLiteral() string {
 	}
 	return ""
 }
+func (program *Program) Line() int {
+	if len(program.Statements) > 0 {
+		return program.Statements[0].Line()
+	}
+	return 0
+}
+func (program *Program) Column() int {
+	if len(program.Statements) > 0 {
+		return program.Statements[0].Column()
+	}
+	return 0
+}
 func (program *Program) String() string {
 	var out bytes.Buffer
 
@@ -55,6 +77,8 @@ func (expressionStatement *ExpressionStatement) statementNode() {}
 func (expressionStatement *ExpressionStatement) TokenLiteral() string {
 	return expressionStatement.Token.Literal
 }
+func (expressionStatement *ExpressionStatement) Line() int   { return expressionStatement.Token.Line }
+func (expressionStatement *ExpressionStatement) Column() int { return expressionStatement.Token.Column }
 func (expressionStatement *ExpressionStatement) String() string {
 
 	// TODO: Remove nil check once expressions are implemented in parser
@@ -66,12 +90,15 @@ func (expressionStatement *ExpressionStatement) String() string {
 
 // BlockStatementStruct - implements Statement Interface
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // "{" token
 	Statements []Statement
+	EndToken   token.Token // "}" token
 }
 
 func (blockStatement *BlockStatement) statementNode()       {}
 func (blockStatement *BlockStatement) TokenLiteral() string { return blockStatement.Token.Literal }
+func (blockStatement *BlockStatement) Line() int            { return blockStatement.Token.Line }
+func (blockStatement *BlockStatement) Column() int          { return blockStatement.Token.Column }
 func (blockStatement *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -90,6 +117,8 @@ type PrefixExpression struct {
 
 func (prefixExpression *PrefixExpression) expressionNode()      {}
 func (prefixExpression *PrefixExpression) TokenLiteral() string { return prefixExpression.Token.Literal }
+func (prefixExpression *PrefixExpression) Line() int            { return prefixExpression.Token.Line }
+func (prefixExpression *PrefixExpression) Column() int          { return prefixExpression.Token.Column }
 func (prefixExpression *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -111,6 +140,8 @@ type InfixExpression struct {
 
 func (infixExpression *InfixExpression) expressionNode()      {}
 func (infixExpression *InfixExpression) TokenLiteral() string { return infixExpression.Token.Literal }
+func (infixExpression *InfixExpression) Line() int            { return infixExpression.Token.Line }
+func (infixExpression *InfixExpression) Column() int          { return infixExpression.Token.Column }
 func (infixExpression *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -133,6 +164,8 @@ type IfExpression struct {
 
 func (ifExpression *IfExpression) expressionNode()      {}
 func (ifExpression *IfExpression) TokenLiteral() string { return ifExpression.Token.Literal }
+func (ifExpression *IfExpression) Line() int            { return ifExpression.Token.Line }
+func (ifExpression *IfExpression) Column() int          { return ifExpression.Token.Column }
 func (ifExpression *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -150,18 +183,33 @@ func (ifExpression *IfExpression) String() string {
 
 // LetStatement struct - implements Statement Interface
 type LetStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token    token.Token     // "let" token
+	Name     *Identifier     // nil when the let destructures the value with a Pattern
+	Pattern  Pattern         // nil when the let binds a single Name
+	Type     *TypeAnnotation // the type after the name or pattern, nil when there is none
+	Value    Expression
+	Exported bool // declared with "export let", making the binding visible to importers of the module
 }
 
 func (letStatement *LetStatement) statementNode()       {}
 func (letStatement *LetStatement) TokenLiteral() string { return letStatement.Token.Literal }
+func (letStatement *LetStatement) Line() int            { return letStatement.Token.Line }
+func (letStatement *LetStatement) Column() int          { return letStatement.Token.Column }
 func (letStatement *LetStatement) String() string {
 	var out bytes.Buffer
 
+	if letStatement.Exported {
+		out.WriteString("export ")
+	}
 	out.WriteString(letStatement.TokenLiteral() + " ")
-	out.WriteString(letStatement.Name.String())
+	if letStatement.Pattern != nil {
+		out.WriteString(letStatement.Pattern.String())
+	} else {
+		out.WriteString(letStatement.Name.String())
+	}
+	if letStatement.Type != nil {
+		out.WriteString(": " + letStatement.Type.String())
+	}
 	out.WriteString(" = ")
 
 	// TODO: Remove nil check once expressions are implemented in parser
@@ -181,6 +229,8 @@ type ReturnStatement struct {
 
 func (returnStatement *ReturnStatement) statementNode()       {}
 func (returnStatement *ReturnStatement) TokenLiteral() string { return returnStatement.Token.Literal }
+func (returnStatement *ReturnStatement) Line() int            { return returnStatement.Token.Line }
+func (returnStatement *ReturnStatement) Column() int          { return returnStatement.Token.Column }
 func (returnStatement *ReturnStatement) String() string {
 	var out bytes.Buffer
 
@@ -195,32 +245,65 @@ func (returnStatement *ReturnStatement) String() string {
 	return out.String()
 }
 
-// FunctionLiteral struct - implements the Expression Interface
+// FunctionLiteral struct - implements the Expression Interface. Literals written with macro instead of fn
+// define macros
 type FunctionLiteral struct {
-	Token      token.Token
-	Parameters []*Identifier
-	Body       *BlockStatement
+	Token          token.Token // "fn" or "macro" token
+	Parameters     []Pattern
+	ParameterTypes []*TypeAnnotation // the type of each parameter, nil for those without an annotation
+	ReturnType     *TypeAnnotation   // the type after "->", nil when there is none
+	Body           *BlockStatement
 }
 
 func (funcLiteral *FunctionLiteral) expressionNode()      {}
 func (funcLiteral *FunctionLiteral) TokenLiteral() string { return funcLiteral.Token.Literal }
+func (funcLiteral *FunctionLiteral) Line() int            { return funcLiteral.Token.Line }
+func (funcLiteral *FunctionLiteral) Column() int          { return funcLiteral.Token.Column }
+func (funcLiteral *FunctionLiteral) IsMacro() bool        { return funcLiteral.Token.Type == token.MACRO }
 func (funcLiteral *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, parameter := range funcLiteral.Parameters {
-		params = append(params, parameter.String())
+	for i, parameter := range funcLiteral.Parameters {
+		if parameterType := funcLiteral.ParameterType(i); parameterType != nil {
+			params = append(params, parameter.String()+": "+parameterType.String())
+		} else {
+			params = append(params, parameter.String())
+		}
 	}
 
 	out.WriteString(funcLiteral.TokenLiteral())
 	out.WriteString("( ")
 	out.WriteString(strings.Join(params, ","))
 	out.WriteString(") ")
+	if funcLiteral.ReturnType != nil {
+		out.WriteString("-> " + funcLiteral.ReturnType.String() + " ")
+	}
 	out.WriteString(funcLiteral.Body.String())
 
 	return out.String()
 }
 
+// ParameterType - The annotated type of the i-th parameter, nil when it has none
+func (funcLiteral *FunctionLiteral) ParameterType(i int) *TypeAnnotation {
+	if i < len(funcLiteral.ParameterTypes) {
+		return funcLiteral.ParameterTypes[i]
+	}
+	return nil
+}
+
+// TypeAnnotation - The declared type of a let binding, a parameter or the result of a function, eg. "int".
+// Annotations are checked by the checker package and ignored by the evaluator
+type TypeAnnotation struct {
+	Token token.Token // the type's name
+	Name  string
+}
+
+func (typeAnnotation *TypeAnnotation) TokenLiteral() string { return typeAnnotation.Token.Literal }
+func (typeAnnotation *TypeAnnotation) Line() int            { return typeAnnotation.Token.Line }
+func (typeAnnotation *TypeAnnotation) Column() int          { return typeAnnotation.Token.Column }
+func (typeAnnotation *TypeAnnotation) String() string       { return typeAnnotation.Name }
+
 // CallExpression struct - implements the Expression interface
 type CallExpression struct {
 	Token     token.Token // "(" token
@@ -230,6 +313,8 @@ type CallExpression struct {
 
 func (callFunction *CallExpression) expressionNode()      {}
 func (callFunction *CallExpression) TokenLiteral() string { return callFunction.Token.Literal }
+func (callFunction *CallExpression) Line() int            { return callFunction.Token.Line }
+func (callFunction *CallExpression) Column() int          { return callFunction.Token.Column }
 func (callFunction *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -246,14 +331,17 @@ func (callFunction *CallExpression) String() string {
 	return out.String()
 }
 
-// Identifier struct - implements the Expression interface
+// Identifier struct - implements the Expression and Pattern interfaces
 type Identifier struct {
 	Token token.Token
 	Value string
 }
 
 func (identifier *Identifier) expressionNode()      {}
+func (identifier *Identifier) patternNode()         {}
 func (identifier *Identifier) TokenLiteral() string { return identifier.Token.Literal }
+func (identifier *Identifier) Line() int            { return identifier.Token.Line }
+func (identifier *Identifier) Column() int          { return identifier.Token.Column }
 func (identifier *Identifier) String() string       { return identifier.Value }
 
 // BooleanLiteral struct - implements Expression interface
@@ -264,8 +352,21 @@ type BooleanLiteral struct {
 
 func (booleanLiteral *BooleanLiteral) expressionNode()      {}
 func (booleanLiteral *BooleanLiteral) TokenLiteral() string { return booleanLiteral.Token.Literal }
+func (booleanLiteral *BooleanLiteral) Line() int            { return booleanLiteral.Token.Line }
+func (booleanLiteral *BooleanLiteral) Column() int          { return booleanLiteral.Token.Column }
 func (booleanLiteral *BooleanLiteral) String() string       { return booleanLiteral.Token.Literal }
 
+// NullLiteral struct - implements Expression interface
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (nullLiteral *NullLiteral) expressionNode()      {}
+func (nullLiteral *NullLiteral) TokenLiteral() string { return nullLiteral.Token.Literal }
+func (nullLiteral *NullLiteral) Line() int            { return nullLiteral.Token.Line }
+func (nullLiteral *NullLiteral) Column() int          { return nullLiteral.Token.Column }
+func (nullLiteral *NullLiteral) String() string       { return nullLiteral.Token.Literal }
+
 // IntegerLiteral stuct - implements Expression interface
 type IntegerLiteral struct {
 	Token token.Token
@@ -274,6 +375,8 @@ type IntegerLiteral struct {
 
 func (integerLiteral *IntegerLiteral) expressionNode()      {}
 func (integerLiteral *IntegerLiteral) TokenLiteral() string { return integerLiteral.Token.Literal }
+func (integerLiteral *IntegerLiteral) Line() int            { return integerLiteral.Token.Line }
+func (integerLiteral *IntegerLiteral) Column() int          { return integerLiteral.Token.Column }
 func (integerLiteral *IntegerLiteral) String() string       { return integerLiteral.Token.Literal }
 
 // StringLiteral struct - implements Expression interface
@@ -284,4 +387,297 @@ type StringLiteral struct {
 
 func (stringLiteral *StringLiteral) expressionNode()      {}
 func (stringLiteral *StringLiteral) TokenLiteral() string { return stringLiteral.Token.Literal }
+func (stringLiteral *StringLiteral) Line() int            { return stringLiteral.Token.Line }
+func (stringLiteral *StringLiteral) Column() int          { return stringLiteral.Token.Column }
 func (stringLiteral *StringLiteral) String() string       { return stringLiteral.Token.Literal }
+
+// ImportExpression struct - implements the Expression interface
+type ImportExpression struct {
+	Token token.Token // "import" token
+	Path  *StringLiteral
+}
+
+func (importExpression *ImportExpression) expressionNode()      {}
+func (importExpression *ImportExpression) TokenLiteral() string { return importExpression.Token.Literal }
+func (importExpression *ImportExpression) Line() int            { return importExpression.Token.Line }
+func (importExpression *ImportExpression) Column() int          { return importExpression.Token.Column }
+func (importExpression *ImportExpression) String() string {
+	return importExpression.TokenLiteral() + ` "` + importExpression.Path.Value + `"`
+}
+
+// MemberExpression struct - implements the Expression interface. An Optional member (a?.b) is null when
+// the object is null
+type MemberExpression struct {
+	Token    token.Token // "." or "?." token
+	Object   Expression
+	Property *Identifier
+	Optional bool
+}
+
+func (memberExpression *MemberExpression) expressionNode()      {}
+func (memberExpression *MemberExpression) TokenLiteral() string { return memberExpression.Token.Literal }
+func (memberExpression *MemberExpression) Line() int            { return memberExpression.Token.Line }
+func (memberExpression *MemberExpression) Column() int          { return memberExpression.Token.Column }
+func (memberExpression *MemberExpression) String() string {
+	return memberExpression.Object.String() + memberExpression.Token.Literal + memberExpression.Property.String()
+}
+
+// ArrayLiteral struct - implements the Expression interface
+type ArrayLiteral struct {
+	Token    token.Token // "[" token
+	Elements []Expression
+}
+
+func (arrayLiteral *ArrayLiteral) expressionNode()      {}
+func (arrayLiteral *ArrayLiteral) TokenLiteral() string { return arrayLiteral.Token.Literal }
+func (arrayLiteral *ArrayLiteral) Line() int            { return arrayLiteral.Token.Line }
+func (arrayLiteral *ArrayLiteral) Column() int          { return arrayLiteral.Token.Column }
+func (arrayLiteral *ArrayLiteral) String() string {
+	elements := []string{}
+	for _, element := range arrayLiteral.Elements {
+		elements = append(elements, element.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashLiteral struct - implements the Expression interface. Keys[i] maps to Values[i], in source order
+type HashLiteral struct {
+	Token  token.Token // "{" token
+	Keys   []Expression
+	Values []Expression
+}
+
+func (hashLiteral *HashLiteral) expressionNode()      {}
+func (hashLiteral *HashLiteral) TokenLiteral() string { return hashLiteral.Token.Literal }
+func (hashLiteral *HashLiteral) Line() int            { return hashLiteral.Token.Line }
+func (hashLiteral *HashLiteral) Column() int          { return hashLiteral.Token.Column }
+func (hashLiteral *HashLiteral) String() string {
+	pairs := []string{}
+	for i, key := range hashLiteral.Keys {
+		pairs = append(pairs, key.String()+": "+hashLiteral.Values[i].String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// IndexExpression struct - implements the Expression interface. An Optional index (a?[i]) is null when
+// the indexed value is null, without evaluating the index
+type IndexExpression struct {
+	Token    token.Token // "[" or "?[" token
+	Left     Expression
+	Index    Expression
+	Optional bool
+}
+
+func (indexExpression *IndexExpression) expressionNode()      {}
+func (indexExpression *IndexExpression) TokenLiteral() string { return indexExpression.Token.Literal }
+func (indexExpression *IndexExpression) Line() int            { return indexExpression.Token.Line }
+func (indexExpression *IndexExpression) Column() int          { return indexExpression.Token.Column }
+func (indexExpression *IndexExpression) String() string {
+	return "(" + indexExpression.Left.String() + indexExpression.Token.Literal + indexExpression.Index.String() + "])"
+}
+
+// TryExpression struct - implements the Expression interface. Catch and Finally can each be nil, but
+// not both
+type TryExpression struct {
+	Token     token.Token // "try" token
+	Block     *BlockStatement
+	Parameter *Identifier // what the caught error is bound to. nil if the catch does not name it
+	Catch     *BlockStatement
+	Finally   *BlockStatement
+}
+
+func (tryExpression *TryExpression) expressionNode()      {}
+func (tryExpression *TryExpression) TokenLiteral() string { return tryExpression.Token.Literal }
+func (tryExpression *TryExpression) Line() int            { return tryExpression.Token.Line }
+func (tryExpression *TryExpression) Column() int          { return tryExpression.Token.Column }
+func (tryExpression *TryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(tryExpression.Block.String())
+
+	if tryExpression.Catch != nil {
+		out.WriteString(" catch ")
+		if tryExpression.Parameter != nil {
+			out.WriteString("(" + tryExpression.Parameter.String() + ") ")
+		}
+		out.WriteString(tryExpression.Catch.String())
+	}
+	if tryExpression.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(tryExpression.Finally.String())
+	}
+
+	return out.String()
+}
+
+// ThrowExpression struct - implements the Expression interface
+type ThrowExpression struct {
+	Token token.Token // "throw" (or "raise") token
+	Value Expression
+}
+
+func (throwExpression *ThrowExpression) expressionNode()      {}
+func (throwExpression *ThrowExpression) TokenLiteral() string { return throwExpression.Token.Literal }
+func (throwExpression *ThrowExpression) Line() int            { return throwExpression.Token.Line }
+func (throwExpression *ThrowExpression) Column() int          { return throwExpression.Token.Column }
+func (throwExpression *ThrowExpression) String() string {
+	return throwExpression.TokenLiteral() + " " + throwExpression.Value.String()
+}
+
+// MatchExpression struct - implements the Expression interface
+type MatchExpression struct {
+	Token    token.Token // "match" token
+	Value    Expression
+	Arms     []*MatchArm
+	EndToken token.Token // "}" token
+}
+
+func (matchExpression *MatchExpression) expressionNode()      {}
+func (matchExpression *MatchExpression) TokenLiteral() string { return matchExpression.Token.Literal }
+func (matchExpression *MatchExpression) Line() int            { return matchExpression.Token.Line }
+func (matchExpression *MatchExpression) Column() int          { return matchExpression.Token.Column }
+func (matchExpression *MatchExpression) String() string {
+	arms := []string{}
+	for _, arm := range matchExpression.Arms {
+		arms = append(arms, arm.String())
+	}
+	return "match (" + matchExpression.Value.String() + ") { " + strings.Join(arms, ", ") + " }"
+}
+
+// MatchArm struct - implements the Node interface. The Pattern is a literal, an identifier (which binds the
+// matched value, or ignores it when it is _) or an array or hash literal of patterns
+type MatchArm struct {
+	Token   token.Token // first token of the pattern
+	Pattern Expression
+	Guard   Expression // nil without an if guard
+	Body    Expression
+}
+
+func (matchArm *MatchArm) TokenLiteral() string { return matchArm.Token.Literal }
+func (matchArm *MatchArm) Line() int            { return matchArm.Token.Line }
+func (matchArm *MatchArm) Column() int          { return matchArm.Token.Column }
+func (matchArm *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(matchArm.Pattern.String())
+	if matchArm.Guard != nil {
+		out.WriteString(" if " + matchArm.Guard.String())
+	}
+	out.WriteString(" => " + matchArm.Body.String())
+
+	return out.String()
+}
+
+// ArrayPattern struct - implements the Pattern interface. Binds the elements of an array in order. Rest, if
+// any, is bound to an array of the elements after them
+type ArrayPattern struct {
+	Token    token.Token // "[" token
+	Elements []*PatternElement
+	Rest     *Identifier
+}
+
+func (arrayPattern *ArrayPattern) patternNode()         {}
+func (arrayPattern *ArrayPattern) TokenLiteral() string { return arrayPattern.Token.Literal }
+func (arrayPattern *ArrayPattern) Line() int            { return arrayPattern.Token.Line }
+func (arrayPattern *ArrayPattern) Column() int          { return arrayPattern.Token.Column }
+func (arrayPattern *ArrayPattern) String() string {
+	return "[" + patternElements(arrayPattern.Elements, arrayPattern.Rest) + "]"
+}
+
+// HashPattern struct - implements the Pattern interface. Binds the values of a hash by key. Rest, if any, is
+// bound to a hash of the other keys
+type HashPattern struct {
+	Token    token.Token // "{" token
+	Elements []*PatternElement
+	Rest     *Identifier
+}
+
+func (hashPattern *HashPattern) patternNode()         {}
+func (hashPattern *HashPattern) TokenLiteral() string { return hashPattern.Token.Literal }
+func (hashPattern *HashPattern) Line() int            { return hashPattern.Token.Line }
+func (hashPattern *HashPattern) Column() int          { return hashPattern.Token.Column }
+func (hashPattern *HashPattern) String() string {
+	return "{" + patternElements(hashPattern.Elements, hashPattern.Rest) + "}"
+}
+
+// PatternElement struct - implements the Node interface. One element of an ArrayPattern or a HashPattern:
+// the Key it is found at in a hash (empty in arrays), the Pattern binding it and the Default used when it
+// is missing (nil if it is required)
+type PatternElement struct {
+	Token   token.Token // first token of the element
+	Key     string
+	Pattern Pattern
+	Default Expression
+}
+
+func (patternElement *PatternElement) TokenLiteral() string { return patternElement.Token.Literal }
+func (patternElement *PatternElement) Line() int            { return patternElement.Token.Line }
+func (patternElement *PatternElement) Column() int          { return patternElement.Token.Column }
+func (patternElement *PatternElement) String() string {
+	var out bytes.Buffer
+
+	if !patternElement.Shorthand() {
+		out.WriteString(patternElement.KeyString() + ": ")
+	}
+	out.WriteString(patternElement.Pattern.String())
+	if patternElement.Default != nil {
+		out.WriteString(" = " + patternElement.Default.String())
+	}
+
+	return out.String()
+}
+
+// Shorthand - Whether the element is written without its key: array elements, and hash elements whose key
+// is the name they bind ({name} for {name: name})
+func (patternElement *PatternElement) Shorthand() bool {
+	if patternElement.Key == "" {
+		return true
+	}
+	identifier, ok := patternElement.Pattern.(*Identifier)
+	return ok && identifier.Value == patternElement.Key
+}
+
+// KeyString - The key of a hash pattern element as written: a name, or a quoted string if it is not one
+func (patternElement *PatternElement) KeyString() string {
+	for _, char := range patternElement.Key {
+		if !(char == '_' || 'a' <= char && char <= 'z' || 'A' <= char && char <= 'Z') {
+			return `"` + patternElement.Key + `"`
+		}
+	}
+	return patternElement.Key
+}
+
+func patternElements(elements []*PatternElement, rest *Identifier) string {
+	parts := []string{}
+	for _, element := range elements {
+		parts = append(parts, element.String())
+	}
+	if rest != nil {
+		parts = append(parts, "..."+rest.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// PatternNames - The identifiers pattern binds, in source order
+func PatternNames(pattern Pattern) []*Identifier {
+	var elements []*PatternElement
+	var rest *Identifier
+	switch pattern := pattern.(type) {
+	case *Identifier:
+		return []*Identifier{pattern}
+	case *ArrayPattern:
+		elements, rest = pattern.Elements, pattern.Rest
+	case *HashPattern:
+		elements, rest = pattern.Elements, pattern.Rest
+	}
+
+	names := []*Identifier{}
+	for _, element := range elements {
+		names = append(names, PatternNames(element.Pattern)...)
+	}
+	if rest != nil {
+		names = append(names, rest)
+	}
+	return names
+}
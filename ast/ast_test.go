@@ -28,3 +28,33 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() invalid. Expected: %q, Got:%q", expectedString, program.String())
 	}
 }
+
+func TestWalk(t *testing.T) {
+	x := &Identifier{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"}
+	y := &Identifier{Token: token.Token{Type: token.IDENT, Literal: "y"}, Value: "y"}
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token: token.Token{Type: token.LET, Literal: "let"},
+				Name:  x,
+				Value: &InfixExpression{Token: token.Token{Type: token.PLUS, Literal: "+"}, Left: y, Operator: "+"},
+			},
+		},
+	}
+
+	visited := []string{}
+	Walk(program, func(node Node) bool {
+		visited = append(visited, node.TokenLiteral())
+		return true
+	})
+
+	expected := []string{"let", "let", "x", "+", "y"}
+	if len(visited) != len(expected) {
+		t.Fatalf("Walk visited the wrong nodes. Expected: %v, Got: %v", expected, visited)
+	}
+	for i := range expected {
+		if visited[i] != expected[i] {
+			t.Fatalf("Walk visited the wrong nodes. Expected: %v, Got: %v", expected, visited)
+		}
+	}
+}
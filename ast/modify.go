@@ -0,0 +1,167 @@
+package ast
+
+// Modify - A copy of the tree rooted at node in which each node is replaced by what modifier returns for
+// it. Children are modified before their parents. The nodes of the original tree are never changed, so the
+// same code (eg. the body of a macro) can be modified again
+func Modify(node Node, modifier func(Node) Node) Node {
+	if isNil(node) {
+		return node
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		copied := *node
+		copied.Statements = modifyStatements(node.Statements, modifier)
+		return modifier(&copied)
+	case *BlockStatement:
+		copied := *node
+		copied.Statements = modifyStatements(node.Statements, modifier)
+		return modifier(&copied)
+	case *ExpressionStatement:
+		copied := *node
+		copied.Expression = modifyExpression(node.Expression, modifier)
+		return modifier(&copied)
+	case *LetStatement:
+		copied := *node
+		copied.Pattern = modifyPattern(node.Pattern, modifier)
+		copied.Value = modifyExpression(node.Value, modifier)
+		return modifier(&copied)
+	case *ReturnStatement:
+		copied := *node
+		copied.ReturnValue = modifyExpression(node.ReturnValue, modifier)
+		return modifier(&copied)
+	case *PrefixExpression:
+		copied := *node
+		copied.Right = modifyExpression(node.Right, modifier)
+		return modifier(&copied)
+	case *InfixExpression:
+		copied := *node
+		copied.Left = modifyExpression(node.Left, modifier)
+		copied.Right = modifyExpression(node.Right, modifier)
+		return modifier(&copied)
+	case *IfExpression:
+		copied := *node
+		copied.Condition = modifyExpression(node.Condition, modifier)
+		copied.Consequence = modifyBlock(node.Consequence, modifier)
+		copied.Alternative = modifyBlock(node.Alternative, modifier)
+		return modifier(&copied)
+	case *FunctionLiteral:
+		copied := *node
+		copied.Parameters = make([]Pattern, len(node.Parameters))
+		for i, parameter := range node.Parameters {
+			copied.Parameters[i] = modifyPattern(parameter, modifier)
+		}
+		copied.Body = modifyBlock(node.Body, modifier)
+		return modifier(&copied)
+	case *CallExpression:
+		copied := *node
+		copied.Function = modifyExpression(node.Function, modifier)
+		copied.Arguments = modifyExpressions(node.Arguments, modifier)
+		return modifier(&copied)
+	case *MemberExpression:
+		copied := *node
+		copied.Object = modifyExpression(node.Object, modifier)
+		return modifier(&copied)
+	case *ArrayLiteral:
+		copied := *node
+		copied.Elements = modifyExpressions(node.Elements, modifier)
+		return modifier(&copied)
+	case *HashLiteral:
+		copied := *node
+		copied.Keys = modifyExpressions(node.Keys, modifier)
+		copied.Values = modifyExpressions(node.Values, modifier)
+		return modifier(&copied)
+	case *IndexExpression:
+		copied := *node
+		copied.Left = modifyExpression(node.Left, modifier)
+		copied.Index = modifyExpression(node.Index, modifier)
+		return modifier(&copied)
+	case *TryExpression:
+		copied := *node
+		copied.Block = modifyBlock(node.Block, modifier)
+		copied.Catch = modifyBlock(node.Catch, modifier)
+		copied.Finally = modifyBlock(node.Finally, modifier)
+		return modifier(&copied)
+	case *ThrowExpression:
+		copied := *node
+		copied.Value = modifyExpression(node.Value, modifier)
+		return modifier(&copied)
+	case *MatchExpression:
+		copied := *node
+		copied.Value = modifyExpression(node.Value, modifier)
+		copied.Arms = make([]*MatchArm, len(node.Arms))
+		for i, arm := range node.Arms {
+			copied.Arms[i], _ = Modify(arm, modifier).(*MatchArm)
+		}
+		return modifier(&copied)
+	case *MatchArm:
+		// Patterns are not evaluated, so only the guard and the body are modified
+		copied := *node
+		copied.Guard = modifyExpression(node.Guard, modifier)
+		copied.Body = modifyExpression(node.Body, modifier)
+		return modifier(&copied)
+	case *ArrayPattern:
+		copied := *node
+		copied.Elements = modifyElements(node.Elements, modifier)
+		return modifier(&copied)
+	case *HashPattern:
+		copied := *node
+		copied.Elements = modifyElements(node.Elements, modifier)
+		return modifier(&copied)
+	default:
+		// Identifiers and literals have no children
+		return modifier(node)
+	}
+}
+
+func modifyStatements(statements []Statement, modifier func(Node) Node) []Statement {
+	modified := make([]Statement, len(statements))
+	for i, statement := range statements {
+		modified[i], _ = Modify(statement, modifier).(Statement)
+	}
+	return modified
+}
+
+func modifyExpressions(expressions []Expression, modifier func(Node) Node) []Expression {
+	modified := make([]Expression, len(expressions))
+	for i, expression := range expressions {
+		modified[i] = modifyExpression(expression, modifier)
+	}
+	return modified
+}
+
+func modifyExpression(expression Expression, modifier func(Node) Node) Expression {
+	if isNil(expression) {
+		return expression
+	}
+	modified, _ := Modify(expression, modifier).(Expression)
+	return modified
+}
+
+func modifyBlock(block *BlockStatement, modifier func(Node) Node) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	modified, _ := Modify(block, modifier).(*BlockStatement)
+	return modified
+}
+
+func modifyPattern(pattern Pattern, modifier func(Node) Node) Pattern {
+	if isNil(pattern) {
+		return pattern
+	}
+	modified, _ := Modify(pattern, modifier).(Pattern)
+	return modified
+}
+
+// modifyElements - Copies of the elements of a pattern with their defaults and nested patterns modified
+func modifyElements(elements []*PatternElement, modifier func(Node) Node) []*PatternElement {
+	modified := make([]*PatternElement, len(elements))
+	for i, element := range elements {
+		copied := *element
+		copied.Pattern = modifyPattern(element.Pattern, modifier)
+		copied.Default = modifyExpression(element.Default, modifier)
+		modified[i] = &copied
+	}
+	return modified
+}
@@ -0,0 +1,147 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Walk - Calls visit for node and, if visit returns true, walks each of node's children in source order.
+// Missing children (eg. left nil by a parser error) are skipped
+func Walk(node Node, visit func(Node) bool) {
+	if isNil(node) || !visit(node) {
+		return
+	}
+
+	for _, child := range Children(node) {
+		Walk(child, visit)
+	}
+}
+
+// Children - The direct children of node in source order, skipping missing ones
+func Children(node Node) []Node {
+	children := []Node{}
+	add := func(nodes ...Node) {
+		for _, child := range nodes {
+			if !isNil(child) {
+				children = append(children, child)
+			}
+		}
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, statement := range node.Statements {
+			add(statement)
+		}
+	case *BlockStatement:
+		for _, statement := range node.Statements {
+			add(statement)
+		}
+	case *ExpressionStatement:
+		add(node.Expression)
+	case *LetStatement:
+		add(node.Name, node.Pattern, node.Type, node.Value)
+	case *ReturnStatement:
+		add(node.ReturnValue)
+	case *PrefixExpression:
+		add(node.Right)
+	case *InfixExpression:
+		add(node.Left, node.Right)
+	case *IfExpression:
+		add(node.Condition, node.Consequence, node.Alternative)
+	case *FunctionLiteral:
+		for i, parameter := range node.Parameters {
+			add(parameter, node.ParameterType(i))
+		}
+		add(node.ReturnType, node.Body)
+	case *CallExpression:
+		add(node.Function)
+		for _, argument := range node.Arguments {
+			add(argument)
+		}
+	case *ImportExpression:
+		add(node.Path)
+	case *MemberExpression:
+		add(node.Object, node.Property)
+	case *ArrayLiteral:
+		for _, element := range node.Elements {
+			add(element)
+		}
+	case *HashLiteral:
+		for i := range node.Keys {
+			add(node.Keys[i], node.Values[i])
+		}
+	case *IndexExpression:
+		add(node.Left, node.Index)
+	case *TryExpression:
+		add(node.Block, node.Parameter, node.Catch, node.Finally)
+	case *ThrowExpression:
+		add(node.Value)
+	case *MatchExpression:
+		add(node.Value)
+		for _, arm := range node.Arms {
+			add(arm)
+		}
+	case *MatchArm:
+		add(node.Pattern, node.Guard, node.Body)
+	case *ArrayPattern:
+		for _, element := range node.Elements {
+			add(element)
+		}
+		add(node.Rest)
+	case *HashPattern:
+		for _, element := range node.Elements {
+			add(element)
+		}
+		add(node.Rest)
+	case *PatternElement:
+		add(node.Pattern, node.Default)
+	}
+	return children
+}
+
+// Dump - An indented outline of the tree rooted at node, one node per line with its position
+func Dump(node Node) string {
+	var out strings.Builder
+	var dump func(node Node, depth int)
+	dump = func(node Node, depth int) {
+		if isNil(node) {
+			return
+		}
+
+		name := reflect.TypeOf(node).Elem().Name()
+		fmt.Fprintf(&out, "%s%s", strings.Repeat("  ", depth), name)
+		switch node := node.(type) {
+		case *Identifier, *IntegerLiteral, *BooleanLiteral, *NullLiteral:
+			fmt.Fprintf(&out, " %s", node.TokenLiteral())
+		case *StringLiteral:
+			fmt.Fprintf(&out, " %q", node.Value)
+		case *PrefixExpression:
+			fmt.Fprintf(&out, " %s", node.Operator)
+		case *InfixExpression:
+			fmt.Fprintf(&out, " %s", node.Operator)
+		case *LetStatement:
+			if node.Exported {
+				out.WriteString(" export")
+			}
+		}
+		fmt.Fprintf(&out, " (%d:%d)\n", node.Line(), node.Column())
+
+		for _, child := range Children(node) {
+			dump(child, depth+1)
+		}
+	}
+
+	dump(node, 0)
+	return out.String()
+}
+
+// isNil - Whether node is nil or an interface holding a nil pointer
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	value := reflect.ValueOf(node)
+	return value.Kind() == reflect.Ptr && value.IsNil()
+}
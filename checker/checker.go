@@ -0,0 +1,399 @@
+package checker
+
+import (
+	"fmt"
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/format"
+	"monkeylang/resolver"
+	"sort"
+)
+
+// Problem - A type error found before running the program
+type Problem struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// checker - The state of checking one program
+type checker struct {
+	resolution *resolver.Resolution
+	types      map[*resolver.Binding]*Type
+	annotated  map[*resolver.Binding]bool // the bindings of lets with a type annotation
+	problems   []Problem
+
+	blocks      []*ast.BlockStatement                     // the blocks being checked that may not run (to the end)
+	conditional map[*resolver.Binding]*ast.BlockStatement // the bindings of lets in such blocks, to their block
+
+	function *ast.FunctionLiteral   // the function whose body is being checked. nil for the program
+	pending  []*ast.FunctionLiteral // functions whose bodies are checked after the enclosing code
+}
+
+// Check - The type errors of program. Types are inferred from literals, operators and builtins and from
+// the optional annotations of lets (let x: int = 1), parameters and function results (fn(s: string) -> int).
+// The checking is gradual: values of unknown type (eg. unannotated parameters) are assumed to be right,
+// so a program without annotations only gets errors the evaluator would certainly raise
+//
+// Function bodies are checked after the code around them, since they run later and can use names bound
+// after them
+func Check(program *ast.Program) []Problem {
+	checker := &checker{
+		resolution: resolver.Resolve(program, evaluator.BuiltinNames()),
+		types:      make(map[*resolver.Binding]*Type),
+		annotated:  make(map[*resolver.Binding]bool),
+
+		conditional: make(map[*resolver.Binding]*ast.BlockStatement),
+	}
+
+	ast.Walk(program, func(node ast.Node) bool {
+		if annotation, ok := node.(*ast.TypeAnnotation); ok && named[annotation.Name] == nil {
+			checker.report(annotation, "Unknown type: %s", annotation.Name)
+		}
+		return true
+	})
+
+	checker.statements(program.Statements)
+	for len(checker.pending) > 0 {
+		function := checker.pending[0]
+		checker.pending = checker.pending[1:]
+		checker.functionBody(function)
+	}
+
+	sort.SliceStable(checker.problems, func(i, j int) bool {
+		if checker.problems[i].Line != checker.problems[j].Line {
+			return checker.problems[i].Line < checker.problems[j].Line
+		}
+		return checker.problems[i].Column < checker.problems[j].Column
+	})
+	return checker.problems
+}
+
+func (checker *checker) report(node ast.Node, message string, args ...interface{}) {
+	checker.problems = append(checker.problems, Problem{Line: node.Line(), Column: node.Column(), Message: fmt.Sprintf(message, args...)})
+}
+
+// annotation - The type an annotation names. nil annotations and unknown names are ANY
+func (checker *checker) annotation(annotation *ast.TypeAnnotation) *Type {
+	if annotation == nil || named[annotation.Name] == nil {
+		return ANY
+	}
+	return named[annotation.Name]
+}
+
+// statements - Checks statements and returns the type of the last one
+func (checker *checker) statements(statements []ast.Statement) *Type {
+	typ := NULL
+	for _, statement := range statements {
+		typ = checker.statement(statement)
+	}
+	return typ
+}
+
+func (checker *checker) statement(statement ast.Statement) *Type {
+	switch statement := statement.(type) {
+	case *ast.LetStatement:
+		checker.let(statement)
+	case *ast.ReturnStatement:
+		typ := checker.expression(statement.ReturnValue)
+		checker.result(statement.ReturnValue, typ)
+	case *ast.ExpressionStatement:
+		return checker.expression(statement.Expression)
+	case *ast.BlockStatement:
+		return checker.statements(statement.Statements)
+	}
+	return ANY
+}
+
+func (checker *checker) let(let *ast.LetStatement) {
+	valueType := checker.expression(let.Value)
+	declared := checker.annotation(let.Type)
+
+	if let.Pattern != nil {
+		checker.destructure(let.Pattern, valueType)
+		return
+	}
+	if !valueType.assignableTo(declared) {
+		checker.report(let.Name, "Cannot assign %s to %s: expected %s", valueType, let.Name.Value, declared)
+	}
+
+	binding := checker.resolution.Declarations[let.Name]
+	if binding == nil {
+		return
+	}
+	if len(checker.blocks) > 0 {
+		checker.conditional[binding] = checker.blocks[len(checker.blocks)-1]
+	}
+	if let.Type != nil {
+		checker.types[binding] = declared
+		checker.annotated[binding] = true
+	} else {
+		checker.types[binding] = valueType
+	}
+}
+
+// destructure - Checks that a destructured value can be destructured by pattern
+func (checker *checker) destructure(pattern ast.Pattern, valueType *Type) {
+	expected := ARRAY
+	if _, ok := pattern.(*ast.HashPattern); ok {
+		expected = HASH
+	}
+	if !valueType.assignableTo(expected) {
+		checker.report(pattern, "Cannot destructure %s with %s", valueType, pattern)
+	}
+}
+
+// result - Checks that a value of type typ can be the result of the function being checked
+func (checker *checker) result(node ast.Node, typ *Type) {
+	if checker.function == nil || checker.function.ReturnType == nil {
+		return
+	}
+	if declared := checker.annotation(checker.function.ReturnType); !typ.assignableTo(declared) {
+		checker.report(node, "Cannot return %s from a function returning %s", typ, declared)
+	}
+}
+
+// functionBody - Checks the body of function. Its last expression is its result
+func (checker *checker) functionBody(function *ast.FunctionLiteral) {
+	enclosing := checker.function
+	checker.function = function
+	defer func() { checker.function = enclosing }()
+
+	for i, parameter := range function.Parameters {
+		if name, ok := parameter.(*ast.Identifier); ok {
+			if binding := checker.resolution.Declarations[name]; binding != nil {
+				checker.types[binding] = checker.annotation(function.ParameterType(i))
+			}
+		}
+	}
+
+	statements := function.Body.Statements
+	typ := checker.statements(statements)
+	if len(statements) > 0 {
+		if last, ok := statements[len(statements)-1].(*ast.ExpressionStatement); ok {
+			checker.result(last, typ)
+		}
+	}
+}
+
+// identifier - The type of the binding identifier refers to
+func (checker *checker) identifier(identifier *ast.Identifier) *Type {
+	binding := checker.resolution.References[identifier]
+	if binding == nil {
+		return ANY
+	}
+
+	switch binding.Kind {
+	case resolver.Builtin:
+		if result, ok := builtinResults[binding.Name]; ok {
+			return &Type{Name: FUNCTION.Name, Return: result}
+		}
+		return FUNCTION
+	case resolver.CatchParameter:
+		return ERROR
+	}
+
+	typ, ok := checker.types[binding]
+	if !ok {
+		return ANY
+	}
+	// Blocks run in the enclosing Environment, so after a block that may not have run its lets, the name
+	// may still have its previous value, or none
+	if block, ok := checker.conditional[binding]; ok && !checker.checking(block) {
+		return ANY
+	}
+	// Functions run after the code around them, which may have bound the name again by then
+	if binding.Scope.Function != checker.function && isRebound(binding) && !checker.annotated[binding] {
+		return ANY
+	}
+	return typ
+}
+
+// checking - Whether block is one of the blocks being checked
+func (checker *checker) checking(block *ast.BlockStatement) bool {
+	for _, checked := range checker.blocks {
+		if checked == block {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionalStatements - Checks the statements of block, which may not run or may stop before its end,
+// and returns the type of the last one
+func (checker *checker) conditionalStatements(block *ast.BlockStatement) *Type {
+	checker.blocks = append(checker.blocks, block)
+	defer func() { checker.blocks = checker.blocks[:len(checker.blocks)-1] }()
+	return checker.statements(block.Statements)
+}
+
+// isRebound - Whether binding's name is bound more than once in its scope
+func isRebound(binding *resolver.Binding) bool {
+	for _, other := range binding.Scope.Bindings {
+		if other != binding && other.Name == binding.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// expression - Checks expression and returns its type
+func (checker *checker) expression(expression ast.Expression) *Type {
+	switch expression := expression.(type) {
+	case *ast.IntegerLiteral:
+		return INT
+	case *ast.StringLiteral:
+		return STRING
+	case *ast.BooleanLiteral:
+		return BOOL
+	case *ast.NullLiteral:
+		return NULL
+	case *ast.Identifier:
+		return checker.identifier(expression)
+	case *ast.ArrayLiteral:
+		for _, element := range expression.Elements {
+			checker.expression(element)
+		}
+		return ARRAY
+	case *ast.HashLiteral:
+		for i, key := range expression.Keys {
+			checker.expression(key)
+			checker.expression(expression.Values[i])
+		}
+		return HASH
+	case *ast.PrefixExpression:
+		return checker.prefix(expression)
+	case *ast.InfixExpression:
+		return checker.infix(expression)
+	case *ast.IfExpression:
+		checker.expression(expression.Condition)
+		consequence := checker.conditionalStatements(expression.Consequence)
+		alternative := NULL
+		if expression.Alternative != nil {
+			alternative = checker.conditionalStatements(expression.Alternative)
+		}
+		if consequence == alternative {
+			return consequence
+		}
+		return ANY
+	case *ast.FunctionLiteral:
+		if expression.IsMacro() {
+			return ANY
+		}
+		checker.pending = append(checker.pending, expression)
+		return functionType(expression, checker.annotation)
+	case *ast.CallExpression:
+		return checker.call(expression)
+	case *ast.MemberExpression:
+		checker.expression(expression.Object)
+	case *ast.IndexExpression:
+		checker.expression(expression.Left)
+		checker.expression(expression.Index)
+	case *ast.TryExpression:
+		checker.conditionalStatements(expression.Block)
+		if expression.Catch != nil {
+			checker.conditionalStatements(expression.Catch)
+		}
+		if expression.Finally != nil {
+			checker.conditionalStatements(expression.Finally)
+		}
+	case *ast.ThrowExpression:
+		checker.expression(expression.Value)
+	case *ast.MatchExpression:
+		checker.expression(expression.Value)
+		for _, arm := range expression.Arms {
+			checker.expression(arm.Guard)
+			checker.expression(arm.Body)
+		}
+	}
+	return ANY
+}
+
+func (checker *checker) prefix(prefix *ast.PrefixExpression) *Type {
+	right := checker.expression(prefix.Right)
+	switch prefix.Operator {
+	case "!":
+		return BOOL
+	case "-":
+		if right.isKnown() && right != INT {
+			checker.report(prefix, "Unknown operator: -%s", right)
+			return ANY
+		}
+		return right
+	}
+	return ANY
+}
+
+// infix - The type of an infix expression. Like the evaluator, only integers have arithmetic and ordering,
+// integers and booleans can be compared for equality and anything can be compared with null
+func (checker *checker) infix(infix *ast.InfixExpression) *Type {
+	left := checker.expression(infix.Left)
+	right := checker.expression(infix.Right)
+
+	switch infix.Operator {
+	case "??":
+		if left == right {
+			return left
+		}
+		return ANY
+	case "==", "!=":
+		if left == NULL || right == NULL {
+			return BOOL
+		}
+	}
+	if !left.isKnown() || !right.isKnown() {
+		return checker.operatorResult(infix.Operator, ANY)
+	}
+
+	switch {
+	case left.Name != right.Name:
+		checker.report(infix, "Mismatch types: %s %s %s", left, infix.Operator, right)
+		return ANY
+	case left == INT:
+		return checker.operatorResult(infix.Operator, INT)
+	case left == BOOL && (infix.Operator == "==" || infix.Operator == "!="):
+		return BOOL
+	default:
+		checker.report(infix, "Unknown operator: %s %s %s", left, infix.Operator, right)
+		return ANY
+	}
+}
+
+// operatorResult - The type of the result of operator on operands of type operand
+func (checker *checker) operatorResult(operator string, operand *Type) *Type {
+	switch operator {
+	case "<", ">", "==", "!=":
+		return BOOL
+	default:
+		return operand
+	}
+}
+
+func (checker *checker) call(call *ast.CallExpression) *Type {
+	// Quoted code is not evaluated where it appears
+	if name, ok := call.Function.(*ast.Identifier); ok && name.Value == "quote" {
+		return ANY
+	}
+
+	function := checker.expression(call.Function)
+	arguments := []*Type{}
+	for _, argument := range call.Arguments {
+		arguments = append(arguments, checker.expression(argument))
+	}
+
+	if !function.assignableTo(FUNCTION) {
+		checker.report(call, "Cannot call %s: %s is not a function", format.Node(call.Function), function)
+		return ANY
+	}
+	if function.Parameters != nil && len(function.Parameters) == len(arguments) {
+		for i, argument := range arguments {
+			if !argument.assignableTo(function.Parameters[i]) {
+				checker.report(call.Arguments[i], "Cannot pass %s as argument %d to %s: expected %s", argument, i+1, format.Node(call.Function), function.Parameters[i])
+			}
+		}
+	}
+	if function.Return == nil {
+		return ANY
+	}
+	return function.Return
+}
@@ -0,0 +1,65 @@
+package checker
+
+import (
+	"fmt"
+	"monkeylang/lexer"
+	"monkeylang/parser"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // "<line>:<column> <message>" for each problem
+	}{
+		{"let x = 1 + 2 * 3; x - 1", []string{}},
+		{`1 + "a"`, []string{"1:3 Mismatch types: int + string"}},
+		{`let s = "a"; let n = 5; n * s`, []string{"1:27 Mismatch types: int * string"}},
+		{`"a" + "b"`, []string{"1:5 Unknown operator: string + string"}},
+		{`[true == false, "a" == null, 1 < 2, -true]`, []string{"1:37 Unknown operator: -bool"}},
+		{"let f = fn(a, b) { a + b }; f(1, true)", []string{}},
+		{"let x = 1; let x = \"a\"; x + 1", []string{"1:27 Mismatch types: string + int"}},
+		{"let x = 1; let f = fn() { x + true }; let x = \"a\";", []string{}},
+		{"let c = true; let x = 1; if (c) { let x = \"a\"; x }; x + 1", []string{}},
+		{"let c = true; let x = 1; if (c) { let x = \"a\"; x + 1 }", []string{"1:50 Mismatch types: string + int"}},
+		{"let c = true; if (c) { 1 } else { let y = \"a\"; if (c) { y - 1 } }; y - 1", []string{"1:59 Mismatch types: string - int"}},
+		{"let x = 1; try { let x = true; throw \"no\" } catch { 0 }; x + 1", []string{}},
+		{"len(\"abc\") + 1; is_error(1) + 1", []string{"1:29 Mismatch types: bool + int"}},
+		{"let e = error(\"x\"); let n: int = e;", []string{"1:25 Cannot assign error to n: expected int"}},
+		{"try { 1 } catch (e) { e + 1 }", []string{"1:25 Mismatch types: error + int"}},
+		{"let x = 5; x()", []string{"1:13 Cannot call x: int is not a function"}},
+		{"let n = ref(0); n + 1; let c: ref = n; get(c) + 1", []string{"1:19 Mismatch types: ref + int"}},
+
+		{"let x: int = 5; let y: string = x;", []string{"1:21 Cannot assign int to y: expected string"}},
+		{"let x: any = 5; let y: string = x;", []string{}},
+		{"let [a, b] = 1;", []string{"1:5 Cannot destructure int with [a, b]"}},
+		{"let f = fn(s: string) { s + 1 };", []string{"1:27 Mismatch types: string + int"}},
+		{"let f = fn(n: int) -> int { n }; f(\"a\") + f(2)", []string{"1:36 Cannot pass string as argument 1 to f: expected int"}},
+		{"let f = fn(n: int) -> string { n };", []string{"1:32 Cannot return int from a function returning string"}},
+		{"let f = fn(n) -> string { if (n) { return 1 }; \"a\" };", []string{"1:43 Cannot return int from a function returning string"}},
+		{"let f = fn() -> int { 1 }; let s: string = f();", []string{"1:32 Cannot assign int to s: expected string"}},
+		{"let apply = fn(f: fn, x) { f(x) }; apply(1, 2)", []string{"1:42 Cannot pass int as argument 1 to apply: expected fn"}},
+		{"let g = fn() { h(1) }; let h = fn(s: string) { s };", []string{"1:18 Cannot pass int as argument 1 to h: expected string"}},
+		{"let x: number = 1;", []string{"1:8 Unknown type: number"}},
+	}
+
+	for _, test := range tests {
+		parser := parser.New(lexer.New(test.input))
+		program := parser.ParseProgram()
+		if len(parser.Errors()) != 0 {
+			t.Fatalf("Parser errors for %q: %v", test.input, parser.Errors())
+		}
+
+		problems := Check(program)
+		if len(problems) != len(test.expected) {
+			t.Errorf("Wrong number of problems in %q. Expected: %d. Got: %d %v", test.input, len(test.expected), len(problems), problems)
+			continue
+		}
+		for i, problem := range problems {
+			got := fmt.Sprintf("%d:%d %s", problem.Line, problem.Column, problem.Message)
+			if got != test.expected[i] {
+				t.Errorf("Problem %d in %q is incorrect. Expected: %s. Got: %s", i, test.input, test.expected[i], got)
+			}
+		}
+	}
+}
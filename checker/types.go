@@ -0,0 +1,92 @@
+package checker
+
+import (
+	"monkeylang/ast"
+	"strings"
+)
+
+// Type - A static type: one of the named types below, or a function type
+type Type struct {
+	Name       string
+	Parameters []*Type // the parameter types of a function. nil when they are not known
+	Return     *Type   // the result type of a function. nil when it is not known
+}
+
+// The named types. ANY is the type of everything the checker knows nothing about, eg. unannotated
+// parameters, and can be used as any other type
+var (
+	ANY      = &Type{Name: "any"}
+	INT      = &Type{Name: "int"}
+	STRING   = &Type{Name: "string"}
+	BOOL     = &Type{Name: "bool"}
+	NULL     = &Type{Name: "null"}
+	ARRAY    = &Type{Name: "array"}
+	HASH     = &Type{Name: "hash"}
+	FUNCTION = &Type{Name: "fn"}
+	ERROR    = &Type{Name: "error"}
+	REF      = &Type{Name: "ref"}
+)
+
+// named - The types annotations can name
+var named = map[string]*Type{}
+
+func init() {
+	for _, namedType := range []*Type{ANY, INT, STRING, BOOL, NULL, ARRAY, HASH, FUNCTION, ERROR, REF} {
+		named[namedType.Name] = namedType
+	}
+}
+
+// builtinResults - The result types of the builtin functions
+var builtinResults = map[string]*Type{
+	"len":          INT,
+	"json_encode":  STRING,
+	"json_decode":  ANY,
+	"error":        ERROR,
+	"is_error":     BOOL,
+	"assert":       NULL,
+	"assert_eq":    NULL,
+	"assert_error": ERROR,
+	"ref":          REF,
+	"get":          ANY,
+	"set":          ANY,
+	"update":       ANY,
+	"puts":         NULL,
+	"print":        NULL,
+	"println":      NULL,
+	"eprint":       NULL,
+}
+
+func (typ *Type) String() string {
+	if typ.Name != FUNCTION.Name || (typ.Parameters == nil && typ.Return == nil) {
+		return typ.Name
+	}
+
+	parameters := []string{}
+	for _, parameter := range typ.Parameters {
+		parameters = append(parameters, parameter.String())
+	}
+	result := ANY
+	if typ.Return != nil {
+		result = typ.Return
+	}
+	return "fn(" + strings.Join(parameters, ", ") + ") -> " + result.String()
+}
+
+// isKnown - Whether typ is anything but ANY
+func (typ *Type) isKnown() bool {
+	return typ != ANY
+}
+
+// assignableTo - Whether a value of type typ can be used where target is expected
+func (typ *Type) assignableTo(target *Type) bool {
+	return !typ.isKnown() || !target.isKnown() || typ.Name == target.Name
+}
+
+// functionType - The type of a function literal, from its annotations
+func functionType(function *ast.FunctionLiteral, annotated func(*ast.TypeAnnotation) *Type) *Type {
+	typ := &Type{Name: FUNCTION.Name, Parameters: []*Type{}, Return: annotated(function.ReturnType)}
+	for i := range function.Parameters {
+		typ.Parameters = append(typ.Parameters, annotated(function.ParameterType(i)))
+	}
+	return typ
+}
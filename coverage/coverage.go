@@ -0,0 +1,161 @@
+package coverage
+
+import (
+	"monkeylang/ast"
+	"monkeylang/object"
+	"sort"
+)
+
+// Coverage - Counts how many times each statement and each branch of each if expression of the programs
+// added to it runs, as an object.BranchObserver. It must be set as the Observer of the root Environment
+// before the programs are evaluated, and the programs must not be optimized, since the optimizer replaces
+// statements and removes branches
+//
+// A file can be added more than once (eg. when it is imported by several test files): its statements are
+// identified by their position, so the counts of every copy are added up
+type Coverage struct {
+	object.BaseObserver
+
+	files      map[string]*File
+	statements map[ast.Statement]*Statement
+	branches   map[*ast.IfExpression]*Branch
+}
+
+// File - The statements and if expressions of a file, in the order of their positions
+type File struct {
+	Path       string
+	Statements []*Statement
+	Branches   []*Branch
+}
+
+// Statement - How many times the statement at Line and Column ran
+type Statement struct {
+	Line   int
+	Column int
+	Count  int
+}
+
+// Branch - How many times each branch of the if expression at Line and Column ran. The alternative of
+// an if without an else is running nothing
+type Branch struct {
+	Line        int
+	Column      int
+	Consequence int
+	Alternative int
+}
+
+// New - Creates an empty coverage
+func New() *Coverage {
+	return &Coverage{
+		files:      make(map[string]*File),
+		statements: make(map[ast.Statement]*Statement),
+		branches:   make(map[*ast.IfExpression]*Branch),
+	}
+}
+
+type position struct{ line, column int }
+
+// Add - Measures the coverage of program, the code of the file at path
+func (coverage *Coverage) Add(path string, program *ast.Program) {
+	file, ok := coverage.files[path]
+	if !ok {
+		file = &File{Path: path}
+		coverage.files[path] = file
+	}
+	statements := map[position]*Statement{}
+	for _, statement := range file.Statements {
+		statements[position{statement.Line, statement.Column}] = statement
+	}
+	branches := map[position]*Branch{}
+	for _, branch := range file.Branches {
+		branches[position{branch.Line, branch.Column}] = branch
+	}
+
+	add := func(node ast.Statement) {
+		at := position{node.Line(), node.Column()}
+		if statements[at] == nil {
+			statements[at] = &Statement{Line: at.line, Column: at.column}
+			file.Statements = append(file.Statements, statements[at])
+		}
+		coverage.statements[node] = statements[at]
+	}
+	ast.Walk(program, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			for _, statement := range node.Statements {
+				add(statement)
+			}
+		case *ast.BlockStatement:
+			for _, statement := range node.Statements {
+				add(statement)
+			}
+		case *ast.IfExpression:
+			at := position{node.Line(), node.Column()}
+			if branches[at] == nil {
+				branches[at] = &Branch{Line: at.line, Column: at.column}
+				file.Branches = append(file.Branches, branches[at])
+			}
+			coverage.branches[node] = branches[at]
+		}
+		return true
+	})
+
+	sort.Slice(file.Statements, func(i, j int) bool {
+		return before(file.Statements[i].Line, file.Statements[i].Column, file.Statements[j].Line, file.Statements[j].Column)
+	})
+	sort.Slice(file.Branches, func(i, j int) bool {
+		return before(file.Branches[i].Line, file.Branches[i].Column, file.Branches[j].Line, file.Branches[j].Column)
+	})
+}
+
+func before(line int, column int, otherLine int, otherColumn int) bool {
+	return line < otherLine || (line == otherLine && column < otherColumn)
+}
+
+// Files - The files added to the coverage, sorted by path
+func (coverage *Coverage) Files() []*File {
+	files := make([]*File, 0, len(coverage.files))
+	for _, file := range coverage.files {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
+
+// Statement - Counts statement if it belongs to an added program. Implements object.Observer
+func (coverage *Coverage) Statement(env *object.Environment, statement ast.Statement) {
+	if counted, ok := coverage.statements[statement]; ok {
+		counted.Count++
+	}
+}
+
+// Branch - Counts the branch of ifExpression that runs. Implements object.BranchObserver
+func (coverage *Coverage) Branch(env *object.Environment, ifExpression *ast.IfExpression, consequence bool) {
+	branch, ok := coverage.branches[ifExpression]
+	if !ok {
+		return
+	}
+	if consequence {
+		branch.Consequence++
+	} else {
+		branch.Alternative++
+	}
+}
+
+// Covered - The number of statements of file that ran and the number of branches that ran
+func (file *File) Covered() (statements int, branches int) {
+	for _, statement := range file.Statements {
+		if statement.Count > 0 {
+			statements++
+		}
+	}
+	for _, branch := range file.Branches {
+		if branch.Consequence > 0 {
+			branches++
+		}
+		if branch.Alternative > 0 {
+			branches++
+		}
+	}
+	return statements, branches
+}
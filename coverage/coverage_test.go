@@ -0,0 +1,148 @@
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const source = `let sign = fn(n) {
+  if (n < 0) { return -1; }
+  if (n == 0) { 0 } else { 1 }
+};
+let unused = fn() { puts("never") };
+sign(5); sign(-2);
+`
+
+func parse(t *testing.T, input string) *ast.Program {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		t.Fatalf("Parser errors for %q: %v", input, parser.Errors())
+	}
+	return program
+}
+
+// measure - The coverage of source, saved in a new temporary directory, run as two copies: the whole
+// program, then its definitions followed by sign(0)
+func measure(t *testing.T) (*Coverage, string) {
+	path := filepath.Join(t.TempDir(), "sign.mk")
+	if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatalf("Could not write %s: %s", path, err)
+	}
+
+	coverage := New()
+	for _, input := range []string{source, "sign(0)"} {
+		program := parse(t, source)
+		coverage.Add(path, program)
+		if input != source {
+			program.Statements = append(program.Statements[:2], parse(t, input).Statements...)
+		}
+
+		env := object.NewEnvironment()
+		env.SetObserver(coverage)
+		if result, ok := evaluator.Eval(env, program).(*object.Error); ok {
+			t.Fatalf("Evaluation failed: %s", result.Message)
+		}
+	}
+	return coverage, path
+}
+
+func TestCoverage(t *testing.T) {
+	coverage, path := measure(t)
+	files := coverage.Files()
+	if len(files) != 1 || files[0].Path != path {
+		t.Fatalf("Wrong files. Expected: [%s]. Got: %v", path, files)
+	}
+
+	expectedStatements := "1:1=2 2:3=3 2:16=1 3:3=2 3:17=1 3:28=1 5:1=2 5:21=0 6:1=1 6:10=1"
+	statements := []string{}
+	for _, statement := range files[0].Statements {
+		statements = append(statements, fmt.Sprintf("%d:%d=%d", statement.Line, statement.Column, statement.Count))
+	}
+	if got := strings.Join(statements, " "); got != expectedStatements {
+		t.Errorf("Statements are incorrect. Expected: %s. Got: %s", expectedStatements, got)
+	}
+
+	expectedBranches := "2:3=1/2 3:3=1/1"
+	branches := []string{}
+	for _, branch := range files[0].Branches {
+		branches = append(branches, fmt.Sprintf("%d:%d=%d/%d", branch.Line, branch.Column, branch.Consequence, branch.Alternative))
+	}
+	if got := strings.Join(branches, " "); got != expectedBranches {
+		t.Errorf("Branches are incorrect. Expected: %s. Got: %s", expectedBranches, got)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	coverage, path := measure(t)
+	var out bytes.Buffer
+	coverage.Summary(&out)
+
+	expected := "file" + strings.Repeat(" ", len(path)-2) + "statements    branches\n" +
+		path + "  90.0% (9/10)  100.0% (4/4)\n" +
+		"total" + strings.Repeat(" ", len(path)-3) + "90.0% (9/10)  100.0% (4/4)\n"
+	if out.String() != expected {
+		t.Errorf("Summary is incorrect. Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+}
+
+func TestWriteLCOV(t *testing.T) {
+	coverage, path := measure(t)
+	var out bytes.Buffer
+	if err := coverage.WriteLCOV(&out); err != nil {
+		t.Fatalf("WriteLCOV failed: %s", err)
+	}
+
+	expected := "TN:\nSF:" + path + `
+BRDA:2,0,0,1
+BRDA:2,0,1,2
+BRDA:3,1,0,1
+BRDA:3,1,1,1
+BRF:4
+BRH:4
+DA:1,2
+DA:2,3
+DA:3,2
+DA:5,2
+DA:6,1
+LF:5
+LH:5
+end_of_record
+`
+	if out.String() != expected {
+		t.Errorf("LCOV is incorrect. Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	coverage, _ := measure(t)
+	var out bytes.Buffer
+	if err := coverage.WriteHTML(&out); err != nil {
+		t.Fatalf("WriteHTML failed: %s", err)
+	}
+
+	expected := []string{
+		`<td>90.0% (9/10)</td><td>100.0% (4/4)</td>`,
+		`<span class="covered" title="if at column 3: consequence ran 1 times, alternative ran 2 times"><span class="number">2</span><span class="count">3</span>  if (n &lt; 0) { return -1; }</span>`,
+		`<span class=""><span class="number">4</span><span class="count"></span>};</span>`,
+		`<span class="partial"><span class="number">5</span><span class="count">2</span>let unused = fn() { puts(&#34;never&#34;) };</span>`,
+		`<span class="covered"><span class="number">6</span><span class="count">1</span>sign(5); sign(-2);</span>`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("HTML report is missing %s. Got:\n%s", line, out.String())
+		}
+	}
+	if strings.Contains(out.String(), `<span class="number">7</span>`) {
+		t.Errorf("HTML report has a line after the end of the file")
+	}
+}
@@ -0,0 +1,177 @@
+package coverage
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/tabwriter"
+)
+
+// ratio - covered out of total as a percentage followed by the counts, or "n/a" when total is 0
+func ratio(covered int, total int) string {
+	if total == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.1f%% (%d/%d)", 100*float64(covered)/float64(total), covered, total)
+}
+
+// Summary - Writes the statement and branch coverage of each file, and of all of them, to out
+func (coverage *Coverage) Summary(out io.Writer) {
+	writer := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "file\tstatements\tbranches")
+
+	var statements, coveredStatements, branches, coveredBranches int
+	for _, file := range coverage.Files() {
+		fileStatements, fileBranches := file.Covered()
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", file.Path, ratio(fileStatements, len(file.Statements)), ratio(fileBranches, 2*len(file.Branches)))
+		statements, coveredStatements = statements+len(file.Statements), coveredStatements+fileStatements
+		branches, coveredBranches = branches+2*len(file.Branches), coveredBranches+fileBranches
+	}
+	fmt.Fprintf(writer, "total\t%s\t%s\n", ratio(coveredStatements, statements), ratio(coveredBranches, branches))
+	writer.Flush()
+}
+
+// lines - The lines of file that have statements, in order, with the number of times the line ran: the
+// most times any of its statements ran
+func (file *File) lines() ([]int, map[int]int) {
+	lines := []int{}
+	counts := map[int]int{}
+	for _, statement := range file.Statements {
+		count, ok := counts[statement.Line]
+		if !ok {
+			lines = append(lines, statement.Line)
+		}
+		if !ok || statement.Count > count {
+			counts[statement.Line] = statement.Count
+		}
+	}
+	return lines, counts
+}
+
+// WriteLCOV - Writes the coverage to out in the LCOV tracefile format read by tools like genhtml. The
+// two branches of the nth if expression of a file are the branches 0 (the consequence) and 1 (the
+// alternative) of its block n
+func (coverage *Coverage) WriteLCOV(out io.Writer) error {
+	var builder strings.Builder
+	for _, file := range coverage.Files() {
+		fmt.Fprintf(&builder, "TN:\nSF:%s\n", file.Path)
+
+		_, coveredBranches := file.Covered()
+		for block, branch := range file.Branches {
+			for i, count := range []int{branch.Consequence, branch.Alternative} {
+				taken := fmt.Sprint(count)
+				if branch.Consequence+branch.Alternative == 0 {
+					taken = "-"
+				}
+				fmt.Fprintf(&builder, "BRDA:%d,%d,%d,%s\n", branch.Line, block, i, taken)
+			}
+		}
+		fmt.Fprintf(&builder, "BRF:%d\nBRH:%d\n", 2*len(file.Branches), coveredBranches)
+
+		lines, counts := file.lines()
+		hit := 0
+		for _, line := range lines {
+			fmt.Fprintf(&builder, "DA:%d,%d\n", line, counts[line])
+			if counts[line] > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(&builder, "LF:%d\nLH:%d\nend_of_record\n", len(lines), hit)
+	}
+
+	_, err := io.WriteString(out, builder.String())
+	return err
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage</title>
+<style>
+body { font-family: sans-serif; }
+table.summary td, table.summary th { padding: 2px 12px; text-align: left; }
+pre { line-height: 1.3; }
+.number, .count { color: #888; display: inline-block; text-align: right; width: 4em; margin-right: 1em; }
+.covered { background: #dfd; }
+.partial { background: #ffd; }
+.uncovered { background: #fdd; }
+</style>
+</head>
+<body>
+<h1>Coverage</h1>
+`
+
+// WriteHTML - Writes an HTML report to out: the summary of every file followed by its source, whose
+// lines are marked as covered, partially covered (some statements or branches did not run) or not
+// covered, with the number of times they ran. Sources are read from the paths of the files
+func (coverage *Coverage) WriteHTML(out io.Writer) error {
+	var builder strings.Builder
+	builder.WriteString(htmlHeader)
+
+	files := coverage.Files()
+	builder.WriteString("<table class=\"summary\">\n<tr><th>File</th><th>Statements</th><th>Branches</th></tr>\n")
+	for i, file := range files {
+		statements, branches := file.Covered()
+		fmt.Fprintf(&builder, "<tr><td><a href=\"#file-%d\">%s</a></td><td>%s</td><td>%s</td></tr>\n", i, html.EscapeString(file.Path), ratio(statements, len(file.Statements)), ratio(branches, 2*len(file.Branches)))
+	}
+	builder.WriteString("</table>\n")
+
+	for i, file := range files {
+		fmt.Fprintf(&builder, "<h2 id=\"file-%d\">%s</h2>\n", i, html.EscapeString(file.Path))
+		source, err := ioutil.ReadFile(file.Path)
+		if err != nil {
+			fmt.Fprintf(&builder, "<p>%s</p>\n", html.EscapeString(err.Error()))
+			continue
+		}
+		file.writeSource(&builder, strings.Split(strings.TrimSuffix(string(source), "\n"), "\n"))
+	}
+
+	builder.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(out, builder.String())
+	return err
+}
+
+// writeSource - Writes the annotated lines of the source of file
+func (file *File) writeSource(builder *strings.Builder, source []string) {
+	_, counts := file.lines()
+	partial := map[int]bool{}
+	titles := map[int][]string{}
+	for _, statement := range file.Statements {
+		if statement.Count == 0 && counts[statement.Line] > 0 {
+			partial[statement.Line] = true
+		}
+	}
+	for _, branch := range file.Branches {
+		if branch.Consequence == 0 || branch.Alternative == 0 {
+			partial[branch.Line] = true
+		}
+		titles[branch.Line] = append(titles[branch.Line], fmt.Sprintf("if at column %d: consequence ran %d times, alternative ran %d times", branch.Column, branch.Consequence, branch.Alternative))
+	}
+
+	builder.WriteString("<pre>\n")
+	for i, text := range source {
+		line := i + 1
+		class, count := "", ""
+		if ran, ok := counts[line]; ok {
+			count = fmt.Sprint(ran)
+			switch {
+			case ran == 0:
+				class = "uncovered"
+			case partial[line]:
+				class = "partial"
+			default:
+				class = "covered"
+			}
+		}
+
+		title := ""
+		if len(titles[line]) > 0 {
+			title = fmt.Sprintf(" title=\"%s\"", html.EscapeString(strings.Join(titles[line], "\n")))
+		}
+		fmt.Fprintf(builder, "<span class=\"%s\"%s><span class=\"number\">%d</span><span class=\"count\">%s</span>%s</span>\n", class, title, line, count, html.EscapeString(text))
+	}
+	builder.WriteString("</pre>\n")
+}
@@ -0,0 +1,416 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"monkeylang/ast"
+	"monkeylang/debugger"
+	"monkeylang/lexer"
+	"monkeylang/module"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"monkeylang/protocol"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MonkeyLang has no concurrency, so the program always runs on a single thread
+const threadID = 1
+
+// Server - A Debug Adapter Protocol server for MonkeyLang. It launches one program and lets the
+// client set breakpoints, step and inspect the call stack and environments while it is paused
+type Server struct {
+	// Loader, when set, loads the modules the launched program imports. Without one the program cannot
+	// import modules
+	Loader *module.Loader
+
+	reader *bufio.Reader
+
+	writeMutex sync.Mutex
+	writer     io.Writer
+	seq        int
+
+	session *debugger.Session
+	path    string
+	program *ast.Program
+
+	resume chan debugger.StepMode
+	done   chan struct{}
+
+	// Guarded by mutex. frames and handles describe the program while it is paused
+	mutex    sync.Mutex
+	started  bool
+	stopping bool
+	entry    bool // the next pause is the stop on entry
+	paused   bool
+	frames   []debugger.Frame
+	handles  []*object.Environment // variablesReference n refers to handles[n-1]
+}
+
+// NewServer - Creates a server that reads requests from in and writes responses and events to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	server := &Server{
+		reader: bufio.NewReader(in),
+		writer: out,
+		resume: make(chan debugger.StepMode),
+		done:   make(chan struct{}),
+	}
+	server.session = debugger.NewSession(server.pause)
+	return server
+}
+
+// Serve - Handles requests until the client disconnects or the input is closed
+func (server *Server) Serve() error {
+	for {
+		body, err := protocol.ReadMessage(server.reader)
+		if err == io.EOF {
+			server.stop()
+			return nil
+		}
+		if err != nil {
+			server.stop()
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			server.sendEvent("output", outputEventBody{Category: "stderr", Output: fmt.Sprintf("invalid message: %s\n", err)})
+			continue
+		}
+
+		if !server.handle(&req) {
+			return nil
+		}
+	}
+}
+
+// handle - Dispatches a request and returns false once the session should end
+func (server *Server) handle(req *request) bool {
+	switch req.Command {
+	case "initialize":
+		server.respond(req, capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsEvaluateForHovers:        true,
+			SupportsTerminateRequest:         true,
+		})
+		server.sendEvent("initialized", nil)
+	case "launch":
+		server.launch(req)
+	case "setBreakpoints":
+		server.setBreakpoints(req)
+	case "setExceptionBreakpoints":
+		server.respond(req, nil)
+	case "configurationDone":
+		server.respond(req, nil)
+		server.start()
+	case "threads":
+		server.respond(req, map[string]interface{}{"threads": []thread{{ID: threadID, Name: "main"}}})
+	case "stackTrace":
+		server.stackTrace(req)
+	case "scopes":
+		server.scopes(req)
+	case "variables":
+		server.variables(req)
+	case "evaluate":
+		server.evaluate(req)
+	case "continue":
+		server.step(req, debugger.Continue, map[string]interface{}{"allThreadsContinued": true})
+	case "next":
+		server.step(req, debugger.StepOver, nil)
+	case "stepIn":
+		server.step(req, debugger.StepInto, nil)
+	case "stepOut":
+		server.step(req, debugger.StepOut, nil)
+	case "disconnect", "terminate":
+		server.stop()
+		server.respond(req, nil)
+		return req.Command != "disconnect"
+	default:
+		server.respondError(req, fmt.Sprintf("Unsupported request %q", req.Command))
+	}
+	return true
+}
+
+func (server *Server) launch(req *request) {
+	var args launchArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil || args.Program == "" {
+		server.respondError(req, "launch requires a \"program\" to debug")
+		return
+	}
+
+	source, err := ioutil.ReadFile(args.Program)
+	if err != nil {
+		server.respondError(req, err.Error())
+		return
+	}
+
+	parser := parser.New(lexer.New(string(source)))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		server.respondError(req, "Parser errors: "+strings.Join(parser.Errors(), "; "))
+		return
+	}
+
+	server.path, server.program = args.Program, program
+	server.entry = args.StopOnEntry
+	if !args.StopOnEntry {
+		server.session.SetMode(debugger.Continue)
+	}
+	server.respond(req, nil)
+}
+
+func (server *Server) setBreakpoints(req *request) {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		server.respondError(req, err.Error())
+		return
+	}
+
+	// Only the launched program can be debugged, so every breakpoint applies to it
+	server.session.ClearBreakpoints()
+	breakpoints := []breakpoint{}
+	for _, requested := range args.Breakpoints {
+		server.session.SetBreakpoint(requested.Line)
+		breakpoints = append(breakpoints, breakpoint{Verified: true, Line: requested.Line, Source: &args.Source})
+	}
+	server.respond(req, map[string]interface{}{"breakpoints": breakpoints})
+}
+
+// start - Runs the launched program on its own goroutine so requests can be served while it is paused
+func (server *Server) start() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if server.started || server.program == nil {
+		return
+	}
+	server.started = true
+
+	go func() {
+		defer close(server.done)
+
+		// What the program prints is sent to the client, since stdout carries the protocol
+		env := object.NewEnvironment()
+		env.SetOutput(&outputWriter{server: server, category: "stdout"})
+		env.SetErrorOutput(&outputWriter{server: server, category: "stderr"})
+		if server.Loader != nil {
+			server.Loader.Attach(env, filepath.Dir(server.path))
+		}
+
+		result, stopped := server.session.Eval(env, server.program)
+		exitCode := 0
+		switch {
+		case stopped:
+		case result != nil && result.Type() == object.ERROR_OBJ:
+			exitCode = 1
+			server.sendEvent("output", outputEventBody{Category: "stderr", Output: result.Inspect() + "\n"})
+		case result != nil:
+			server.sendEvent("output", outputEventBody{Category: "console", Output: result.Inspect() + "\n"})
+		}
+
+		server.sendEvent("exited", exitedEventBody{ExitCode: exitCode})
+		server.sendEvent("terminated", nil)
+	}()
+}
+
+// outputWriter - Sends what is written to it to the client as output events of category
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (writer *outputWriter) Write(p []byte) (int, error) {
+	writer.server.sendEvent("output", outputEventBody{Category: writer.category, Output: string(p)})
+	return len(p), nil
+}
+
+// pause - Runs on the program's goroutine. Reports the stop to the client and waits to be resumed
+func (server *Server) pause(env *object.Environment, line int) debugger.StepMode {
+	server.mutex.Lock()
+	if server.stopping {
+		server.mutex.Unlock()
+		return debugger.Stop
+	}
+
+	reason := "step"
+	if server.entry {
+		reason, server.entry = "entry", false
+	} else if server.session.HasBreakpoint(line) {
+		reason = "breakpoint"
+	}
+	server.paused = true
+	server.frames = server.session.Frames()
+	server.handles = nil
+	server.mutex.Unlock()
+
+	server.sendEvent("stopped", stoppedEventBody{Reason: reason, ThreadID: threadID, AllThreadsStopped: true})
+	return <-server.resume
+}
+
+// step - Resumes a paused program with mode
+func (server *Server) step(req *request, mode debugger.StepMode, body interface{}) {
+	server.mutex.Lock()
+	paused := server.paused
+	server.paused = false
+	server.mutex.Unlock()
+
+	if !paused {
+		server.respondError(req, "The program is not paused")
+		return
+	}
+	server.respond(req, body)
+	server.resume <- mode
+}
+
+// stop - Abandons the program, if it is running, and waits for it to finish
+func (server *Server) stop() {
+	server.mutex.Lock()
+	started, paused := server.started, server.paused
+	server.paused, server.stopping = false, true
+	server.mutex.Unlock()
+
+	if !started {
+		return
+	}
+	// A program that never pauses again is abandoned at its next statement
+	server.session.Stop()
+	if paused {
+		server.resume <- debugger.Stop
+	}
+	<-server.done
+}
+
+func (server *Server) stackTrace(req *request) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	frames := []stackFrame{}
+	for i, frame := range server.frames {
+		frames = append(frames, stackFrame{
+			ID:     i,
+			Name:   frame.Name,
+			Source: &source{Name: filepath.Base(server.path), Path: server.path},
+			Line:   frame.Line,
+			Column: 1,
+		})
+	}
+	server.respond(req, map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)})
+}
+
+func (server *Server) scopes(req *request) {
+	var args scopesArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		server.respondError(req, err.Error())
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if args.FrameID < 0 || args.FrameID >= len(server.frames) {
+		server.respondError(req, fmt.Sprintf("Unknown frame %d", args.FrameID))
+		return
+	}
+
+	scopes := []scope{}
+	for env := server.frames[args.FrameID].Env; env != nil; env = env.Outer {
+		name := "Closure"
+		switch {
+		case env.Outer == nil:
+			name = "Globals"
+		case len(scopes) == 0:
+			name = "Locals"
+		}
+
+		server.handles = append(server.handles, env)
+		scopes = append(scopes, scope{Name: name, VariablesReference: len(server.handles)})
+	}
+	server.respond(req, map[string]interface{}{"scopes": scopes})
+}
+
+func (server *Server) variables(req *request) {
+	var args variablesArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		server.respondError(req, err.Error())
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if args.VariablesReference < 1 || args.VariablesReference > len(server.handles) {
+		server.respondError(req, fmt.Sprintf("Unknown variablesReference %d", args.VariablesReference))
+		return
+	}
+
+	env := server.handles[args.VariablesReference-1]
+	variables := []variable{}
+	for _, name := range env.Names() {
+		value, _ := env.Get(name)
+		variables = append(variables, variable{Name: name, Value: debugger.Summarize(value), Type: string(value.Type())})
+	}
+	server.respond(req, map[string]interface{}{"variables": variables})
+}
+
+func (server *Server) evaluate(req *request) {
+	var args evaluateArguments
+	if err := json.Unmarshal(req.Arguments, &args); err != nil {
+		server.respondError(req, err.Error())
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if !server.paused {
+		server.respondError(req, "Expressions can only be evaluated while the program is paused")
+		return
+	}
+
+	frameID := 0
+	if args.FrameID != nil {
+		frameID = *args.FrameID
+	}
+	if frameID < 0 || frameID >= len(server.frames) {
+		server.respondError(req, fmt.Sprintf("Unknown frame %d", frameID))
+		return
+	}
+
+	result, err := server.session.Evaluate(server.frames[frameID].Env, args.Expression)
+	if err != nil {
+		server.respondError(req, err.Error())
+		return
+	}
+	server.respond(req, map[string]interface{}{"result": debugger.Summarize(result), "variablesReference": 0})
+}
+
+func (server *Server) respond(req *request, body interface{}) {
+	server.send(&response{Type: "response", RequestSeq: req.Seq, Success: true, Command: req.Command, Body: body})
+}
+
+func (server *Server) respondError(req *request, message string) {
+	server.send(&response{Type: "response", RequestSeq: req.Seq, Success: false, Command: req.Command, Message: message})
+}
+
+func (server *Server) sendEvent(name string, body interface{}) {
+	server.send(&event{Type: "event", Event: name, Body: body})
+}
+
+// send - Numbers and writes a message. Events are sent from the program's goroutine as well, so
+// writes are serialized
+func (server *Server) send(message interface{}) {
+	server.writeMutex.Lock()
+	defer server.writeMutex.Unlock()
+
+	server.seq++
+	switch message := message.(type) {
+	case *response:
+		message.Seq = server.seq
+	case *event:
+		message.Seq = server.seq
+	}
+	protocol.WriteMessage(server.writer, message)
+}
@@ -0,0 +1,318 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"monkeylang/module"
+	"monkeylang/protocol"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const script = `let add = fn(x, y) {
+	let sum = x + y;
+	sum
+};
+let a = 1;
+let b = add(a, 2);
+b * 10`
+
+// client - Drives a Server over pipes the way an editor would
+type client struct {
+	t        *testing.T
+	writer   io.Writer
+	messages chan []byte
+	seq      int
+}
+
+type message struct {
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	Event      string          `json:"event"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+func newClient(t *testing.T) (*client, chan error) {
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		server := NewServer(serverReader, serverWriter)
+		server.Loader = module.NewLoader(nil)
+		done <- server.Serve()
+		serverWriter.Close()
+	}()
+
+	// Pipes are unbuffered, so messages are read as soon as they are written to stop the server from
+	// blocking on events the test is not waiting for yet
+	messages := make(chan []byte, 100)
+	go func() {
+		reader := bufio.NewReader(clientReader)
+		for {
+			body, err := protocol.ReadMessage(reader)
+			if err != nil {
+				close(messages)
+				return
+			}
+			messages <- body
+		}
+	}()
+
+	return &client{t: t, writer: clientWriter, messages: messages}, done
+}
+
+func (client *client) send(command string, arguments interface{}) int {
+	client.seq++
+	req := map[string]interface{}{"seq": client.seq, "type": "request", "command": command, "arguments": arguments}
+	if err := protocol.WriteMessage(client.writer, req); err != nil {
+		client.t.Fatalf("Could not send %s request: %s", command, err)
+	}
+	return client.seq
+}
+
+// expect - Reads messages until the response to a command or an event with the given name arrives
+func (client *client) expect(kind string, name string) message {
+	for {
+		body, ok := <-client.messages
+		if !ok {
+			client.t.Fatalf("Expected %s %q. Got: end of output", kind, name)
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			client.t.Fatalf("Invalid message %s: %s", body, err)
+		}
+		if msg.Type == kind && (msg.Command == name || msg.Event == name) {
+			return msg
+		}
+	}
+}
+
+// request - Sends a request and returns the body of its successful response
+func (client *client) request(command string, arguments interface{}, body interface{}) {
+	client.send(command, arguments)
+	msg := client.expect("response", command)
+	if !msg.Success {
+		client.t.Fatalf("%s request failed: %s", command, msg.Message)
+	}
+	if body != nil {
+		if err := json.Unmarshal(msg.Body, body); err != nil {
+			client.t.Fatalf("Invalid %s response body %s: %s", command, msg.Body, err)
+		}
+	}
+}
+
+func (client *client) expectStopped(reason string) {
+	var body stoppedEventBody
+	json.Unmarshal(client.expect("event", "stopped").Body, &body)
+	if body.Reason != reason {
+		client.t.Fatalf("Stopped for the wrong reason. Expected: %q. Got: %q", reason, body.Reason)
+	}
+}
+
+func writeScript(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "monkey-dap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "script.mk")
+	if err := ioutil.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBreakpointsStackAndVariables(t *testing.T) {
+	path := writeScript(t)
+	client, done := newClient(t)
+
+	client.request("initialize", map[string]interface{}{"adapterID": "monkey"}, nil)
+	client.expect("event", "initialized")
+	client.request("launch", launchArguments{Program: path}, nil)
+
+	var breakpoints struct{ Breakpoints []breakpoint }
+	client.request("setBreakpoints", setBreakpointsArguments{Source: source{Path: path}, Breakpoints: []sourceBreakpoint{{Line: 3}}}, &breakpoints)
+	if len(breakpoints.Breakpoints) != 1 || !breakpoints.Breakpoints[0].Verified {
+		t.Fatalf("Breakpoint was not verified. Got: %+v", breakpoints)
+	}
+
+	client.request("configurationDone", nil, nil)
+	client.expectStopped("breakpoint")
+
+	var stack struct{ StackFrames []stackFrame }
+	client.request("stackTrace", stackTraceArguments{ThreadID: threadID}, &stack)
+	if len(stack.StackFrames) != 2 {
+		t.Fatalf("Wrong number of stack frames. Expected: 2. Got: %+v", stack.StackFrames)
+	}
+	if stack.StackFrames[0].Name != "add" || stack.StackFrames[0].Line != 3 {
+		t.Errorf("Wrong top frame. Expected: add at line 3. Got: %+v", stack.StackFrames[0])
+	}
+	if stack.StackFrames[1].Name != "<script>" || stack.StackFrames[1].Line != 6 {
+		t.Errorf("Wrong bottom frame. Expected: <script> at line 6. Got: %+v", stack.StackFrames[1])
+	}
+
+	var scopes struct{ Scopes []scope }
+	client.request("scopes", scopesArguments{FrameID: 0}, &scopes)
+	if len(scopes.Scopes) != 2 || scopes.Scopes[0].Name != "Locals" || scopes.Scopes[1].Name != "Globals" {
+		t.Fatalf("Wrong scopes. Got: %+v", scopes.Scopes)
+	}
+
+	var variables struct{ Variables []variable }
+	client.request("variables", variablesArguments{VariablesReference: scopes.Scopes[0].VariablesReference}, &variables)
+	expected := []variable{{Name: "sum", Value: "3", Type: "INTEGER"}, {Name: "x", Value: "1", Type: "INTEGER"}, {Name: "y", Value: "2", Type: "INTEGER"}}
+	if len(variables.Variables) != len(expected) {
+		t.Fatalf("Wrong variables. Expected: %+v. Got: %+v", expected, variables.Variables)
+	}
+	for i, variable := range expected {
+		if variables.Variables[i] != variable {
+			t.Errorf("Wrong variable. Expected: %+v. Got: %+v", variable, variables.Variables[i])
+		}
+	}
+
+	var evaluated struct{ Result string }
+	client.request("evaluate", map[string]interface{}{"expression": "sum * a", "frameId": 0}, &evaluated)
+	if evaluated.Result != "3" {
+		t.Errorf("Wrong evaluate result. Expected: 3. Got: %q", evaluated.Result)
+	}
+
+	client.request("continue", map[string]interface{}{"threadId": threadID}, nil)
+	var output outputEventBody
+	json.Unmarshal(client.expect("event", "output").Body, &output)
+	if output.Output != "30\n" {
+		t.Errorf("Wrong program output. Expected: %q. Got: %q", "30\n", output.Output)
+	}
+	client.expect("event", "terminated")
+
+	client.request("disconnect", nil, nil)
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned an error: %s", err)
+	}
+}
+
+func TestStopOnEntryAndStepping(t *testing.T) {
+	path := writeScript(t)
+	client, done := newClient(t)
+
+	client.request("initialize", nil, nil)
+	client.request("launch", launchArguments{Program: path, StopOnEntry: true}, nil)
+	client.request("configurationDone", nil, nil)
+	client.expectStopped("entry")
+
+	lines := []int{}
+	currentLine := func() {
+		var stack struct{ StackFrames []stackFrame }
+		client.request("stackTrace", stackTraceArguments{ThreadID: threadID}, &stack)
+		lines = append(lines, stack.StackFrames[0].Line)
+	}
+
+	currentLine()
+	for _, command := range []string{"next", "next", "stepIn", "stepOut"} {
+		client.request(command, map[string]interface{}{"threadId": threadID}, nil)
+		client.expectStopped("step")
+		currentLine()
+	}
+
+	expected := []int{1, 5, 6, 2, 7}
+	for i, line := range expected {
+		if lines[i] != line {
+			t.Fatalf("Stepped through the wrong lines. Expected: %v. Got: %v", expected, lines)
+		}
+	}
+
+	client.request("disconnect", nil, nil)
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned an error: %s", err)
+	}
+}
+
+func TestImports(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "shapes.mk"), []byte("export let square = fn(x) { x * x };"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "script.mk")
+	if err := ioutil.WriteFile(path, []byte(`let shapes = import "./shapes"; shapes.square(4)`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client, done := newClient(t)
+
+	client.request("initialize", map[string]interface{}{"adapterID": "monkey"}, nil)
+	client.request("launch", launchArguments{Program: path}, nil)
+	client.request("configurationDone", nil, nil)
+
+	var output outputEventBody
+	json.Unmarshal(client.expect("event", "output").Body, &output)
+	if expected := (outputEventBody{Category: "console", Output: "16\n"}); output != expected {
+		t.Errorf("Wrong output event. Expected: %+v. Got: %+v", expected, output)
+	}
+	client.expect("event", "terminated")
+
+	client.request("disconnect", nil, nil)
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned an error: %s", err)
+	}
+}
+
+func TestProgramOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.mk")
+	if err := ioutil.WriteFile(path, []byte(`println("hello"); eprint("oops"); 1`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client, done := newClient(t)
+
+	client.request("initialize", map[string]interface{}{"adapterID": "monkey"}, nil)
+	client.request("launch", launchArguments{Program: path}, nil)
+	client.request("configurationDone", nil, nil)
+
+	expected := []outputEventBody{{Category: "stdout", Output: "hello\n"}, {Category: "stderr", Output: "oops"}, {Category: "console", Output: "1\n"}}
+	for _, expectedOutput := range expected {
+		var output outputEventBody
+		json.Unmarshal(client.expect("event", "output").Body, &output)
+		if output != expectedOutput {
+			t.Errorf("Wrong output event. Expected: %+v. Got: %+v", expectedOutput, output)
+		}
+	}
+	client.expect("event", "terminated")
+
+	client.request("disconnect", nil, nil)
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned an error: %s", err)
+	}
+}
+
+func TestDisconnectStopsRunningProgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loop.mk")
+	if err := ioutil.WriteFile(path, []byte(`let loop = fn() { loop() }; loop()`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client, done := newClient(t)
+
+	client.request("initialize", nil, nil)
+	client.request("launch", launchArguments{Program: path}, nil)
+	client.request("configurationDone", nil, nil)
+
+	// The program never pauses, so only the stop flag can end it
+	client.request("disconnect", nil, nil)
+	if err := <-done; err != nil {
+		t.Fatalf("Serve returned an error: %s", err)
+	}
+}
+
+func TestOversizedMessage(t *testing.T) {
+	client, done := newClient(t)
+
+	go io.WriteString(client.writer, "Content-Length: 1000000000000\r\n\r\n")
+	if err := <-done; err == nil || !strings.Contains(err.Error(), "larger than the maximum") {
+		t.Fatalf("An oversized message should be rejected. Got: %v", err)
+	}
+}
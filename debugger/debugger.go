@@ -0,0 +1,260 @@
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"monkeylang/lexer"
+	"monkeylang/module"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"strconv"
+	"strings"
+)
+
+const PROMPT = "(debug) "
+
+const HELP = `Commands:
+  c, continue         run until the next breakpoint
+  s, step             step to the next statement, entering function calls
+  n, next             step to the next statement, stepping over function calls
+  o, out              run until the current function returns
+  b, break <line>     set a breakpoint on a line
+  d, delete <line>    remove the breakpoint on a line
+  breakpoints         list breakpoints
+  bt, stack           print the call stack
+  env                 print the bindings of the current environment chain
+  p, print <expr>     evaluate an expression in the current environment
+  w, watch <expr>     evaluate an expression every time the program pauses
+  unwatch <n>         remove watch expression number n
+  l, list             show the source around the current line
+  q, quit             stop the program
+  h, help             show this message
+`
+
+// Debugger - An interactive step debugger for MonkeyLang scripts. It reads commands from the user
+// whenever its Session pauses the program
+type Debugger struct {
+	// Loader, when set, loads the modules the script imports, resolving relative imports against Dir.
+	// Without one the script cannot import modules
+	Loader *module.Loader
+	Dir    string
+
+	session *Session
+	source  string
+	lines   []string
+	scanner *bufio.Scanner
+	out     io.Writer
+	watches []string
+}
+
+// New - Creates a debugger for source that reads commands from in and writes to out
+func New(source string, in io.Reader, out io.Writer) *Debugger {
+	debugger := &Debugger{
+		source:  source,
+		lines:   strings.Split(source, "\n"),
+		scanner: bufio.NewScanner(in),
+		out:     out,
+	}
+	debugger.session = NewSession(debugger.pause)
+	return debugger
+}
+
+// SetBreakpoint - Pause before the first statement on line each time it is reached
+func (debugger *Debugger) SetBreakpoint(line int) {
+	debugger.session.SetBreakpoint(line)
+}
+
+// Run - Parses and evaluates the script, pausing before its first statement. It returns the result of
+// the script, an error when it cannot be parsed, or nil when it was stopped
+func (debugger *Debugger) Run() object.Object {
+	parser := parser.New(lexer.New(debugger.source))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		for _, errorMsg := range parser.Errors() {
+			fmt.Fprintf(debugger.out, "Parser error: %s\n", errorMsg)
+		}
+		return &object.Error{Message: "Parser errors: " + strings.Join(parser.Errors(), "; ")}
+	}
+
+	env := object.NewEnvironment()
+	env.SetOutput(debugger.out)
+	if debugger.Loader != nil {
+		debugger.Loader.Attach(env, debugger.Dir)
+	}
+	result, stopped := debugger.session.Eval(env, program)
+	switch {
+	case stopped:
+		io.WriteString(debugger.out, "Program stopped\n")
+	case result != nil:
+		fmt.Fprintf(debugger.out, "Program finished: %s\n", result.Inspect())
+	default:
+		io.WriteString(debugger.out, "Program finished\n")
+	}
+	return result
+}
+
+// pause - Reports where the program stopped and reads commands until one resumes execution
+func (debugger *Debugger) pause(env *object.Environment, line int) StepMode {
+	fmt.Fprintf(debugger.out, "Paused at line %d: %s\n", line, debugger.sourceLine(line))
+	debugger.printWatches(env)
+
+	for {
+		io.WriteString(debugger.out, PROMPT)
+		if !debugger.scanner.Scan() {
+			return Stop
+		}
+
+		command, argument := splitCommand(debugger.scanner.Text())
+		switch command {
+		case "":
+			continue
+		case "c", "continue":
+			return Continue
+		case "s", "step":
+			return StepInto
+		case "n", "next":
+			return StepOver
+		case "o", "out":
+			return StepOut
+		case "b", "break":
+			if breakLine, ok := debugger.parseLine(argument); ok {
+				debugger.session.SetBreakpoint(breakLine)
+				fmt.Fprintf(debugger.out, "Breakpoint set at line %d\n", breakLine)
+			}
+		case "d", "delete":
+			if breakLine, ok := debugger.parseLine(argument); ok {
+				debugger.session.ClearBreakpoint(breakLine)
+				fmt.Fprintf(debugger.out, "Breakpoint removed from line %d\n", breakLine)
+			}
+		case "breakpoints":
+			debugger.printBreakpoints()
+		case "bt", "stack":
+			debugger.printStack()
+		case "env":
+			debugger.printEnvironment(env)
+		case "p", "print":
+			fmt.Fprintf(debugger.out, "%s\n", debugger.evaluate(env, argument))
+		case "w", "watch":
+			debugger.watches = append(debugger.watches, argument)
+			fmt.Fprintf(debugger.out, "Watch %d: %s = %s\n", len(debugger.watches), argument, debugger.evaluate(env, argument))
+		case "unwatch":
+			index, err := strconv.Atoi(argument)
+			if err != nil || index < 1 || index > len(debugger.watches) {
+				fmt.Fprintf(debugger.out, "No watch expression %q\n", argument)
+				continue
+			}
+			debugger.watches = append(debugger.watches[:index-1], debugger.watches[index:]...)
+		case "l", "list":
+			debugger.printSource(line)
+		case "q", "quit":
+			return Stop
+		case "h", "help":
+			io.WriteString(debugger.out, HELP)
+		default:
+			fmt.Fprintf(debugger.out, "Unknown command %q. Type \"help\" for a list of commands\n", command)
+		}
+	}
+}
+
+// evaluate - Evaluates src in env and returns the result, or the parser errors, as text
+func (debugger *Debugger) evaluate(env *object.Environment, src string) string {
+	result, err := debugger.session.Evaluate(env, src)
+	if err != nil {
+		return "Parser error: " + err.Error()
+	}
+	return result.Inspect()
+}
+
+func (debugger *Debugger) parseLine(argument string) (int, bool) {
+	line, err := strconv.Atoi(argument)
+	if err != nil || line < 1 || line > len(debugger.lines) {
+		fmt.Fprintf(debugger.out, "Invalid line %q\n", argument)
+		return 0, false
+	}
+	return line, true
+}
+
+func (debugger *Debugger) sourceLine(line int) string {
+	if line < 1 || line > len(debugger.lines) {
+		return ""
+	}
+	return strings.TrimSpace(debugger.lines[line-1])
+}
+
+func (debugger *Debugger) printWatches(env *object.Environment) {
+	for i, watch := range debugger.watches {
+		fmt.Fprintf(debugger.out, "Watch %d: %s = %s\n", i+1, watch, debugger.evaluate(env, watch))
+	}
+}
+
+func (debugger *Debugger) printBreakpoints() {
+	breakpoints := debugger.session.Breakpoints()
+	if len(breakpoints) == 0 {
+		io.WriteString(debugger.out, "No breakpoints\n")
+		return
+	}
+	for _, line := range breakpoints {
+		fmt.Fprintf(debugger.out, "Line %d: %s\n", line, debugger.sourceLine(line))
+	}
+}
+
+func (debugger *Debugger) printStack() {
+	for i, frame := range debugger.session.Frames() {
+		fmt.Fprintf(debugger.out, "#%d %s at line %d\n", i, frame.Name, frame.Line)
+	}
+}
+
+func (debugger *Debugger) printEnvironment(env *object.Environment) {
+	for depth := 0; env != nil; depth++ {
+		if depth == 0 {
+			io.WriteString(debugger.out, "Scope 0 (current):\n")
+		} else {
+			fmt.Fprintf(debugger.out, "Scope %d:\n", depth)
+		}
+
+		for _, name := range env.Names() {
+			value, _ := env.Get(name)
+			fmt.Fprintf(debugger.out, "  %s = %s\n", name, Summarize(value))
+		}
+		env = env.Outer
+	}
+}
+
+func (debugger *Debugger) printSource(line int) {
+	for i := line - 3; i <= line+3; i++ {
+		if i < 1 || i > len(debugger.lines) {
+			continue
+		}
+
+		marker := "  "
+		if i == line {
+			marker = "=>"
+		} else if debugger.session.HasBreakpoint(i) {
+			marker = "* "
+		}
+		fmt.Fprintf(debugger.out, "%s %4d  %s\n", marker, i, debugger.lines[i-1])
+	}
+}
+
+// Summarize - A single line description of obj. Functions are shown by their parameters only
+func Summarize(obj object.Object) string {
+	function, ok := obj.(*object.Function)
+	if !ok {
+		return obj.Inspect()
+	}
+
+	parameters := []string{}
+	for _, parameter := range function.Parameters {
+		parameters = append(parameters, parameter.String())
+	}
+	return "fn(" + strings.Join(parameters, ", ") + ")"
+}
+
+func splitCommand(input string) (string, string) {
+	input = strings.TrimSpace(input)
+	if index := strings.IndexAny(input, " \t"); index >= 0 {
+		return input[:index], strings.TrimSpace(input[index+1:])
+	}
+	return input, ""
+}
@@ -0,0 +1,127 @@
+package debugger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"monkeylang/module"
+	"monkeylang/object"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const script = `let add = fn(x, y) {
+	let sum = x + y;
+	sum
+};
+let a = 1;
+let b = add(a, 2);
+b * 10`
+
+func runDebugger(commands ...string) string {
+	var out bytes.Buffer
+	debugger := New(script, strings.NewReader(strings.Join(commands, "\n")+"\n"), &out)
+	debugger.Run()
+	return out.String()
+}
+
+func expectOutput(t *testing.T, output string, expected ...string) {
+	for _, line := range expected {
+		if !strings.Contains(output, line) {
+			t.Errorf("Debugger output is missing %q. Got:\n%s", line, output)
+		}
+	}
+}
+
+func TestContinueToBreakpoint(t *testing.T) {
+	output := runDebugger("b 2", "c", "p x + y", "bt", "c")
+
+	expectOutput(t, output,
+		"Paused at line 1: let add = fn(x, y) {",
+		"Breakpoint set at line 2",
+		"Paused at line 2: let sum = x + y;",
+		"(debug) 3\n",
+		"#0 add at line 2\n#1 <script> at line 6\n",
+		"Program finished: 30",
+	)
+}
+
+func TestStepping(t *testing.T) {
+	output := runDebugger("n", "n", "s", "s", "o", "q")
+
+	expectOutput(t, output,
+		"Paused at line 5: let a = 1;",
+		"Paused at line 6: let b = add(a, 2);",
+		"Paused at line 2: let sum = x + y;",
+		"Paused at line 3: sum",
+		"Paused at line 7: b * 10",
+		"Program stopped",
+	)
+}
+
+func TestStepOverSkipsFunctionBody(t *testing.T) {
+	output := runDebugger("b 6", "c", "n", "c")
+
+	if strings.Contains(output, "Paused at line 2") {
+		t.Errorf("Stepping over a call paused inside the function. Got:\n%s", output)
+	}
+	expectOutput(t, output, "Paused at line 7: b * 10", "Program finished: 30")
+}
+
+func TestEnvironmentAndWatches(t *testing.T) {
+	output := runDebugger("b 3", "c", "w sum * 2", "env", "q")
+
+	expectOutput(t, output,
+		"Watch 1: sum * 2 = 6",
+		"Scope 0 (current):\n  sum = 3\n  x = 1\n  y = 2\n",
+		"Scope 1:\n  a = 1\n  add = fn(x, y)\n",
+	)
+}
+
+func TestImports(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "shapes.mk"), []byte("export let square = fn(x) { x * x };"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	debugger := New(`let shapes = import "./shapes"; shapes.square(4)`, strings.NewReader("c\n"), &out)
+	debugger.Loader = module.NewLoader(nil)
+	debugger.Dir = dir
+	debugger.Run()
+
+	expectOutput(t, out.String(), "Program finished: 16")
+}
+
+func TestBreakpointInRepeatedCalls(t *testing.T) {
+	// The definitions on line 1 pause once, then every call of the function does
+	tests := []struct {
+		source string
+		pauses int
+	}{
+		{"let twice = fn(x) { x * 2 };\ntwice(twice(1))", 3},
+		{"let f = fn(x) { x };\n[f(1), f(2), f(3)]", 4},
+		{"let count = fn(n) { if (n == 0) { 0 } else { count(n - 1) } };\ncount(2)", 4},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		debugger := New(test.source, strings.NewReader(strings.Repeat("c\n", test.pauses+1)), &out)
+		debugger.SetBreakpoint(1)
+		debugger.session.SetMode(Continue)
+		debugger.Run()
+
+		if pauses := strings.Count(out.String(), "Paused at line 1"); pauses != test.pauses {
+			t.Errorf("Pauses at the breakpoint of %q are incorrect. Expected: %d. Got: %d", test.source, test.pauses, pauses)
+		}
+	}
+}
+
+func TestRunReturnsErrors(t *testing.T) {
+	for _, source := range []string{"1 + true", "let = 1"} {
+		debugger := New(source, strings.NewReader("c\n"), ioutil.Discard)
+		if result, ok := debugger.Run().(*object.Error); !ok {
+			t.Errorf("Run of %q should return an error. Got: %v", source, result)
+		}
+	}
+}
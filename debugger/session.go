@@ -0,0 +1,236 @@
+package debugger
+
+import (
+	"errors"
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StepMode - How execution continues after a pause
+type StepMode int
+
+const (
+	Continue StepMode = iota // run until the next breakpoint
+	StepInto                 // pause at the next statement
+	StepOver                 // pause at the next statement in the current or a calling function
+	StepOut                  // pause at the next statement in a calling function
+	Stop                     // abandon the program
+)
+
+// errStop is panicked from inside the evaluator to abandon the program and is recovered by Eval
+var errStop = errors.New("debugger: stop")
+
+// PauseHandler - Called when execution pauses before the statement on line. It blocks for as long as the
+// program should stay paused and returns how execution continues
+type PauseHandler func(env *object.Environment, line int) StepMode
+
+// Frame - One entry of the call stack
+type Frame struct {
+	Name string
+	Line int // line of the statement currently running in this frame
+	Env  *object.Environment
+}
+
+// Session - The debugging state of one running program: breakpoints, the call stack and the current
+// step. It implements object.Observer and calls its PauseHandler whenever the program should pause
+type Session struct {
+	mutex       sync.Mutex
+	breakpoints map[int]bool
+
+	frames  []*Frame
+	onPause PauseHandler
+
+	mode      StepMode
+	stepDepth int // depth of the call stack when the current step started
+	lastLine  int // line of the last statement seen
+	lastDepth int // depth of the call stack at the last statement seen
+
+	evaluating bool // true while an expression is evaluated on behalf of the user
+	stopping   bool // guarded by mutex. Set by Stop to abandon the program at its next statement
+}
+
+// NewSession - Creates a session that pauses before the first statement and calls onPause on each pause
+func NewSession(onPause PauseHandler) *Session {
+	return &Session{breakpoints: make(map[int]bool), onPause: onPause, mode: StepInto}
+}
+
+// SetMode - Sets how the program starts: StepInto pauses before the first statement, Continue runs
+// to the first breakpoint
+func (session *Session) SetMode(mode StepMode) {
+	session.mode = mode
+}
+
+// SetBreakpoint - Pause before the first statement on line each time it is reached
+func (session *Session) SetBreakpoint(line int) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	session.breakpoints[line] = true
+}
+
+// ClearBreakpoint - Removes the breakpoint on line, if any
+func (session *Session) ClearBreakpoint(line int) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	delete(session.breakpoints, line)
+}
+
+// ClearBreakpoints - Removes every breakpoint
+func (session *Session) ClearBreakpoints() {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	session.breakpoints = make(map[int]bool)
+}
+
+// Breakpoints - The sorted lines that have a breakpoint
+func (session *Session) Breakpoints() []int {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	lines := make([]int, 0, len(session.breakpoints))
+	for line := range session.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// HasBreakpoint - Whether line has a breakpoint
+func (session *Session) HasBreakpoint(line int) bool {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	return session.breakpoints[line]
+}
+
+// Stop - Abandons the program at its next statement, even if it would not pause there. Unlike returning
+// Stop from a pause it can be called from any goroutine, eg. while the program runs without breakpoints
+func (session *Session) Stop() {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	session.stopping = true
+}
+
+// Frames - The call stack, innermost frame first. Only meaningful while the program is paused
+func (session *Session) Frames() []Frame {
+	frames := make([]Frame, 0, len(session.frames))
+	for i := len(session.frames) - 1; i >= 0; i-- {
+		frames = append(frames, *session.frames[i])
+	}
+	return frames
+}
+
+// Eval - Evaluates program in env under the debugger. stopped is true if a pause returned Stop
+func (session *Session) Eval(env *object.Environment, program *ast.Program) (result object.Object, stopped bool) {
+	env.SetObserver(session)
+	session.frames = []*Frame{{Name: "<script>", Env: env}}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if recovered != errStop {
+				panic(recovered)
+			}
+			result, stopped = nil, true
+		}
+	}()
+
+	if err := evaluator.ExpandMacros(env, program); err != nil {
+		return err, false
+	}
+	return evaluator.Eval(env, program), false
+}
+
+// Evaluate - Evaluates src in env without pausing, eg. for print and watch expressions
+func (session *Session) Evaluate(env *object.Environment, src string) (object.Object, error) {
+	parser := parser.New(lexer.New(src))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		return nil, errors.New(strings.Join(parser.Errors(), "; "))
+	}
+
+	session.evaluating = true
+	defer func() { session.evaluating = false }()
+
+	if err := evaluator.ExpandMacros(env, program); err != nil {
+		return err, nil
+	}
+	result := evaluator.Eval(env, program)
+	if result == nil {
+		result = evaluator.NULL
+	}
+	return result, nil
+}
+
+// Statement - Decides whether to pause before statement runs. Implements object.Observer
+func (session *Session) Statement(env *object.Environment, statement ast.Statement) {
+	if session.evaluating {
+		return
+	}
+	session.mutex.Lock()
+	stopping := session.stopping
+	session.mutex.Unlock()
+	if stopping {
+		panic(errStop)
+	}
+
+	line, depth := statement.Line(), len(session.frames)
+	top := session.frames[depth-1]
+	top.Line, top.Env = line, env
+
+	// Several statements can share a line (eg. "if (x) { y }"), only the first of them pauses
+	newLine := line != session.lastLine || depth != session.lastDepth
+	session.lastLine, session.lastDepth = line, depth
+	if !newLine {
+		return
+	}
+
+	pause := session.HasBreakpoint(line)
+	switch session.mode {
+	case StepInto:
+		pause = true
+	case StepOver:
+		pause = pause || depth <= session.stepDepth
+	case StepOut:
+		pause = pause || depth < session.stepDepth
+	}
+	if !pause {
+		return
+	}
+
+	mode := session.onPause(env, line)
+	if mode == Stop {
+		panic(errStop)
+	}
+	session.mode = mode
+	session.stepDepth = len(session.frames)
+}
+
+// Call - Pushes a frame for the called function. Implements object.Observer
+func (session *Session) Call(env *object.Environment, call *ast.CallExpression, function *object.Function, args []object.Object) {
+	if session.evaluating {
+		return
+	}
+
+	name := "<anonymous>"
+	if call != nil {
+		if identifier, ok := call.Function.(*ast.Identifier); ok {
+			name = identifier.Value
+		}
+	}
+	session.frames = append(session.frames, &Frame{Name: name, Line: function.Body.Line(), Env: env})
+	// Each call starts on a new line, even when the previous call of the same function (eg. a tail call)
+	// left off on the line it starts on
+	session.lastLine = 0
+}
+
+// Return - Pops the frame of the returning function. Implements object.Observer
+func (session *Session) Return(function *object.Function, result object.Object) {
+	if session.evaluating || len(session.frames) <= 1 {
+		return
+	}
+	session.frames = session.frames[:len(session.frames)-1]
+}
@@ -0,0 +1,143 @@
+package evaluator
+
+import (
+	"fmt"
+	"monkeylang/object"
+	"strconv"
+	"strings"
+)
+
+// ASSERTION_ERROR - The kind of the errors raised by failed assertions
+const ASSERTION_ERROR = "AssertionError"
+
+// assertions - Builtins raising an error of kind ASSERTION_ERROR when a condition does not hold. They
+// return null (or the caught error for assert_error) otherwise
+var assertions = map[string]object.BuiltinFunction{
+	// assert(condition, message?) - Fails when condition is not truthy
+	"assert": func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 && len(args) != 2 {
+			return newError("Invalid number of arguments to `assert` function. Expected: 1 or 2, Got: %d", len(args))
+		}
+		if IsTruthy(args[0]) {
+			return NULL
+		}
+		return assertionFailed(args[1:], "Expected a truthy value. Got: %s", inspectQuoted(args[0]))
+	},
+	// assert_eq(actual, expected, message?) - Fails when actual is not equal to expected. Arrays and
+	// hashes are equal when their elements are
+	"assert_eq": func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 2 && len(args) != 3 {
+			return newError("Invalid number of arguments to `assert_eq` function. Expected: 2 or 3, Got: %d", len(args))
+		}
+		if equal(args[0], args[1]) {
+			return NULL
+		}
+		return assertionFailed(args[2:], "%s", diff(inspectQuoted(args[1]), inspectQuoted(args[0])))
+	},
+	// assert_error(function, kind?) - Calls function without arguments and fails unless it raises an error
+	// (of kind when given). Returns the caught error
+	"assert_error": func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 && len(args) != 2 {
+			return newError("Invalid number of arguments to `assert_error` function. Expected: 1 or 2, Got: %d", len(args))
+		}
+		kind := ""
+		if len(args) == 2 {
+			str, ok := args[1].(*object.String)
+			if !ok {
+				return newError("Invalid kind to `assert_error` function. Expected: STRING, Got: %s", args[1].Type())
+			}
+			kind = str.Value
+		}
+
+		result := Apply(env, args[0])
+		errorObject, ok := result.(*object.Error)
+		if !ok {
+			return assertionFailed(nil, "Expected an error. Got: %s", inspectQuoted(result))
+		}
+		errorValue := caught(errorObject)
+		if kind != "" && errorValue.Kind != kind {
+			return assertionFailed(nil, "Expected an error of kind %s. Got: %s", kind, errorValue.Inspect())
+		}
+		return errorValue
+	},
+}
+
+func init() {
+	for name, assertion := range assertions {
+		builtins[name] = &object.Builtin{Fn: assertion}
+	}
+}
+
+// assertionFailed - The error raised by a failed assertion. The message given to the assertion, if any,
+// comes before the description of the failure
+func assertionFailed(message []object.Object, description string, args ...interface{}) *object.Error {
+	text := fmt.Sprintf(description, args...)
+	if len(message) == 1 {
+		text = message[0].Inspect() + "\n" + text
+	}
+	return &object.Error{Kind: ASSERTION_ERROR, Message: text}
+}
+
+// equal - Whether two values are equal: scalars by value, arrays, hashes and error values by contents and
+// everything else (eg. functions) by identity
+func equal(left object.Object, right object.Object) bool {
+	if left.Type() != right.Type() {
+		return false
+	}
+
+	switch left := left.(type) {
+	case *object.Integer:
+		return left.Value == right.(*object.Integer).Value
+	case *object.String:
+		return left.Value == right.(*object.String).Value
+	case *object.Boolean:
+		return left.Value == right.(*object.Boolean).Value
+	case *object.Null:
+		return true
+	case *object.ErrorValue:
+		return left.Kind == right.(*object.ErrorValue).Kind && left.Message == right.(*object.ErrorValue).Message
+	case *object.Array:
+		elements := right.(*object.Array).Elements
+		if len(left.Elements) != len(elements) {
+			return false
+		}
+		for i, element := range left.Elements {
+			if !equal(element, elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *object.Hash:
+		hash := right.(*object.Hash)
+		if left.Len() != hash.Len() {
+			return false
+		}
+		for _, pair := range left.Pairs() {
+			value, ok := hash.Get(pair.Key)
+			if !ok || !equal(pair.Value, value) {
+				return false
+			}
+		}
+		return true
+	default:
+		return left == right
+	}
+}
+
+// inspectQuoted - The Inspect of value, quoted when it is a string so that "1" and 1 can be told apart
+func inspectQuoted(value object.Object) string {
+	if str, ok := value.(*object.String); ok {
+		return strconv.Quote(str.Value)
+	}
+	return value.Inspect()
+}
+
+// diff - Describes the difference between the expected and actual Inspect of a value, pointing at the
+// first character where they differ
+func diff(expected string, actual string) string {
+	first := 0
+	for first < len(expected) && first < len(actual) && expected[first] == actual[first] {
+		first++
+	}
+	return "Expected: " + expected + "\n     Got: " + actual + "\n" + strings.Repeat(" ", len("Expected: ")+first) + "^"
+}
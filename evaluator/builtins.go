@@ -1,10 +1,15 @@
 package evaluator
 
-import "monkeylang/object"
+import (
+	"io"
+	"monkeylang/object"
+	"sort"
+	"strings"
+)
 
 var builtins = map[string]*object.Builtin{
 	"len": &object.Builtin{
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("Invalid number of argument to `len` function. Expected: 1, Got: %d", len(args))
 			}
@@ -12,9 +17,171 @@ var builtins = map[string]*object.Builtin{
 			switch arg := args[0].(type) {
 			case *object.String:
 				return &object.Integer{Value: int64(len(arg.Value))}
+			case *object.Array:
+				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(arg.Len())}
 			default:
 				return newError("Invalid argument to `len` function. Got: %s", args[0].Type())
 			}
 		},
 	},
+	// json_encode(value, indent?) - The JSON text of value, indented by indent spaces (or the indent
+	// string) per level when given
+	"json_encode": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("Invalid number of arguments to `json_encode` function. Expected: 1 or 2, Got: %d", len(args))
+			}
+
+			var indent object.Object
+			if len(args) == 2 {
+				indent = args[1]
+			}
+			return EncodeJSON("json_encode", args[0], indent)
+		},
+	},
+	// json_decode(text) - The value of the JSON text
+	"json_decode": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("Invalid number of arguments to `json_decode` function. Expected: 1, Got: %d", len(args))
+			}
+			text, ok := args[0].(*object.String)
+			if !ok {
+				return newError("Invalid argument to `json_decode` function. Expected: STRING, Got: %s", args[0].Type())
+			}
+
+			value, err := object.DecodeJSON(text.Value, NULL)
+			if err != nil {
+				return newError("%s", err)
+			}
+			return value
+		},
+	},
+	// error(message, kind?) - An error value of kind (by default "Error") that can be thrown
+	"error": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("Invalid number of arguments to `error` function. Expected: 1 or 2, Got: %d", len(args))
+			}
+			errorValue := &object.ErrorValue{Kind: "Error"}
+			for i, arg := range args {
+				str, ok := arg.(*object.String)
+				if !ok {
+					return newError("Invalid argument to `error` function. Expected: STRING, Got: %s", arg.Type())
+				}
+				if i == 0 {
+					errorValue.Message = str.Value
+				} else {
+					errorValue.Kind = str.Value
+				}
+			}
+			return errorValue
+		},
+	},
+	// is_error(value) - Whether value is an error value (eg. one that has been caught)
+	"is_error": &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("Invalid number of arguments to `is_error` function. Expected: 1, Got: %d", len(args))
+			}
+			return nativeBoolToBooleanObject(args[0].Type() == object.ERROR_VALUE_OBJ)
+		},
+	},
+}
+
+// output - Builtins writing the Inspect of their arguments to the Output (or ErrorOutput) of the calling
+// Environment. They return null
+var output = map[string]struct {
+	separator string // written between arguments
+	end       string // written after the last argument
+	toError   bool   // whether to write to the ErrorOutput
+}{
+	// puts(args...) - Each argument on its own line
+	"puts": {separator: "\n", end: "\n"},
+	// print(args...) - The arguments separated by spaces
+	"print": {separator: " "},
+	// println(args...) - The arguments separated by spaces, followed by a newline
+	"println": {separator: " ", end: "\n"},
+	// eprint(args...) - Like print, to the error output
+	"eprint": {separator: " ", toError: true},
+}
+
+func init() {
+	for name, format := range output {
+		format := format
+		builtins[name] = &object.Builtin{
+			Fn: func(env *object.Environment, args ...object.Object) object.Object {
+				texts := make([]string, len(args))
+				for i, arg := range args {
+					texts[i] = arg.Inspect()
+				}
+
+				out := env.Output()
+				if format.toError {
+					out = env.ErrorOutput()
+				}
+				io.WriteString(out, strings.Join(texts, format.separator)+format.end)
+				return NULL
+			},
+		}
+	}
+}
+
+// arities - The least and most arguments of the builtins that take a fixed number of them
+var arities = map[string][2]int{
+	"len":          {1, 1},
+	"json_encode":  {1, 2},
+	"json_decode":  {1, 1},
+	"error":        {1, 2},
+	"is_error":     {1, 1},
+	"assert":       {1, 2},
+	"assert_eq":    {2, 3},
+	"assert_error": {1, 2},
+	"ref":          {1, 1},
+	"get":          {1, 1},
+	"set":          {2, 2},
+	"update":       {2, 2},
+}
+
+// EncodeJSON - The JSON text of value as a String, indented by indent spaces (an Integer) or by the indent
+// String per level unless indent is nil, or an error naming function when value or indent is invalid
+func EncodeJSON(function string, value object.Object, indent object.Object) object.Object {
+	text := ""
+	switch indent := indent.(type) {
+	case nil:
+	case *object.Integer:
+		if indent.Value < 0 {
+			return newError("Invalid indent to `%s` function: %d is negative", function, indent.Value)
+		}
+		text = strings.Repeat(" ", int(indent.Value))
+	case *object.String:
+		text = indent.Value
+	default:
+		return newError("Invalid indent to `%s` function. Expected: INTEGER or STRING, Got: %s", function, indent.Type())
+	}
+
+	encoded, err := object.EncodeJSON(value, text)
+	if err != nil {
+		return newError("%s", err)
+	}
+	return &object.String{Value: encoded}
+}
+
+// BuiltinArity - The least and most arguments the builtin function name takes. ok is false for unknown
+// names and for builtins taking any number of arguments
+func BuiltinArity(name string) (min int, max int, ok bool) {
+	arity, ok := arities[name]
+	return arity[0], arity[1], ok
+}
+
+// BuiltinNames - The sorted names of the builtin functions
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
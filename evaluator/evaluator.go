@@ -12,7 +12,47 @@ var (
 	FALSE = &object.Boolean{Value: false}
 )
 
+// Eval - Evaluates node in env. Errors raised by the evaluation of node that come from none of its
+// children are attributed to it (see object.Error.Node)
 func Eval(env *object.Environment, node ast.Node) object.Object {
+	if env != nil && env.Observer() != nil {
+		return evalObserved(env, node, eval)
+	}
+	return raise(nil, node, eval(env, node))
+}
+
+// evalObserved - Evaluates node with evaluate, telling the Observer of env about it if it is a
+// NodeObserver and about the error it raises if it is an ErrorObserver
+func evalObserved(env *object.Environment, node ast.Node, evaluate func(*object.Environment, ast.Node) object.Object) object.Object {
+	observer, ok := env.Observer().(object.NodeObserver)
+	if !ok {
+		return raise(env, node, evaluate(env, node))
+	}
+
+	observer.Enter(env, node)
+	result := raise(env, node, evaluate(env, node))
+	observer.Exit(env, node, result)
+	return result
+}
+
+// raise - Attributes result to node if it is an error that has not been attributed yet, telling the
+// Observer of env (if any) when it is an ErrorObserver
+func raise(env *object.Environment, node ast.Node, result object.Object) object.Object {
+	errorObject, ok := result.(*object.Error)
+	if !ok || errorObject.Node != nil {
+		return result
+	}
+
+	errorObject.Node = node
+	if env != nil {
+		if observer, ok := env.Observer().(object.ErrorObserver); ok {
+			observer.Error(env, node, errorObject)
+		}
+	}
+	return result
+}
+
+func eval(env *object.Environment, node ast.Node) object.Object {
 	switch castedNode := node.(type) {
 	case *ast.Program:
 		return evalProgram(env, castedNode)
@@ -23,6 +63,13 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 		if isError(left) {
 			return left
 		}
+		// The right operand of ?? is only evaluated when it is needed
+		if castedNode.Operator == "??" {
+			if left != NULL {
+				return left
+			}
+			return Eval(env, castedNode.Right)
+		}
 		right := Eval(env, castedNode.Right)
 		if isError(right) {
 			return right
@@ -40,6 +87,8 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 		return &object.String{Value: castedNode.Value}
 	case *ast.BooleanLiteral:
 		return nativeBoolToBooleanObject(castedNode.Value)
+	case *ast.NullLiteral:
+		return NULL
 	case *ast.Identifier:
 		return evalIdentifier(env, castedNode)
 	case *ast.LetStatement:
@@ -47,11 +96,14 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 		if isError(value) {
 			return value
 		}
+		if castedNode.Pattern != nil {
+			return bind(env, castedNode.Pattern, value)
+		}
 		env.Set(castedNode.Name.Value, value)
 	case *ast.BlockStatement:
 		return evalBlockStatement(env, castedNode)
 	case *ast.ReturnStatement:
-		value := Eval(env, castedNode.ReturnValue)
+		value := evalTailPosition(env, castedNode.ReturnValue)
 		if isError(value) {
 			return value
 		}
@@ -59,10 +111,16 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 	case *ast.IfExpression:
 		return evalIfExpression(env, castedNode)
 	case *ast.FunctionLiteral:
+		if castedNode.IsMacro() {
+			return newError("Macros can only be defined by top level let statements")
+		}
 		params := castedNode.Parameters
 		body := castedNode.Body
 		return &object.Function{Parameters: params, Env: env, Body: body}
 	case *ast.CallExpression:
+		if isQuote(castedNode) {
+			return quote(env, castedNode)
+		}
 		function := Eval(env, castedNode.Function)
 		if isError(function) {
 			return function
@@ -71,7 +129,57 @@ func Eval(env *object.Environment, node ast.Node) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		return applyFunction(env, castedNode, function, args)
+	case *ast.ImportExpression:
+		importer := env.Importer()
+		if importer == nil {
+			return newError("Cannot import %q: modules are not available", castedNode.Path.Value)
+		}
+		return importer.Import(env, castedNode.Path.Value)
+	case *ast.MemberExpression:
+		obj := Eval(env, castedNode.Object)
+		if isError(obj) {
+			return obj
+		}
+		if castedNode.Optional && obj == NULL {
+			return NULL
+		}
+		return evalMemberExpression(obj, castedNode.Property.Value)
+	case *ast.ArrayLiteral:
+		elements := evalExpressions(env, castedNode.Elements)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Array{Elements: elements}
+	case *ast.HashLiteral:
+		return evalHashLiteral(env, castedNode)
+	case *ast.TryExpression:
+		return evalTryExpression(env, castedNode)
+	case *ast.MatchExpression:
+		arm, err := matchArm(env, castedNode)
+		if err != nil {
+			return err
+		}
+		return Eval(env, arm.Body)
+	case *ast.ThrowExpression:
+		value := Eval(env, castedNode.Value)
+		if isError(value) {
+			return value
+		}
+		return throw(value, castedNode.Token.Line)
+	case *ast.IndexExpression:
+		left := Eval(env, castedNode.Left)
+		if isError(left) {
+			return left
+		}
+		if castedNode.Optional && left == NULL {
+			return NULL
+		}
+		index := Eval(env, castedNode.Index)
+		if isError(index) {
+			return index
+		}
+		return evalIndexExpression(left, index)
 	}
 	return nil
 }
@@ -80,11 +188,12 @@ func evalProgram(env *object.Environment, program *ast.Program) object.Object {
 	var result object.Object
 
 	for _, statement := range program.Statements {
+		notifyStatement(env, statement)
 		result = Eval(env, statement)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
-			return result.Value
+			return resolveTailCall(result.Value)
 		case *object.Error:
 			return result
 		}
@@ -110,6 +219,7 @@ func evalBlockStatement(env *object.Environment, block *ast.BlockStatement) obje
 	var result object.Object
 
 	for _, statement := range block.Statements {
+		notifyStatement(env, statement)
 		result = Eval(env, statement)
 
 		if result != nil {
@@ -131,6 +241,10 @@ func evalExpressions(env *object.Environment, expressions []ast.Expression) []ob
 		if isError(evaluated) {
 			return []object.Object{evaluated}
 		}
+		if evaluated == nil {
+			// An empty block (eg. of an if) has no value
+			evaluated = NULL
+		}
 		result = append(result, evaluated)
 	}
 
@@ -154,6 +268,11 @@ func evalInfixExpression(env *object.Environment, operator string, left object.O
 		return evalIntegerInfixExpression(env, operator, left, right)
 	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
 		return evalBooleanInfixExpression(env, operator, left, right)
+	// Anything can be compared with null, which only equals itself
+	case (left == NULL || right == NULL) && operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case (left == NULL || right == NULL) && operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
 	case left.Type() != right.Type():
 		return newError("Mismatch types: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -221,7 +340,8 @@ func evalIfExpression(env *object.Environment, ifExpression *ast.IfExpression) o
 		return condition
 	}
 
-	if isTruthy(condition) {
+	notifyBranch(env, ifExpression, IsTruthy(condition))
+	if IsTruthy(condition) {
 		return Eval(env, ifExpression.Consequence)
 	} else if ifExpression.Alternative != nil {
 		return Eval(env, ifExpression.Alternative)
@@ -230,37 +350,546 @@ func evalIfExpression(env *object.Environment, ifExpression *ast.IfExpression) o
 	}
 }
 
-func applyFunction(funcObj object.Object, args []object.Object) object.Object {
+// applyFunction - Calls funcObj with args from env. Calls made in tail position come back as a TailCall
+// and are run by this loop (a trampoline) instead of recursing, so tail recursion uses constant Go stack
+func applyFunction(env *object.Environment, call *ast.CallExpression, funcObj object.Object, args []object.Object) object.Object {
+	for {
+		switch function := funcObj.(type) {
+		case *object.Function:
+			if len(args) != len(function.Parameters) {
+				return newError("Wrong number of arguments. Expected: %d, Got: %d", len(function.Parameters), len(args))
+			}
+			extendedEnv, err := extendFunctionEnv(function, args)
+			if err != nil {
+				return err
+			}
+			observer := extendedEnv.Observer()
+			if observer != nil {
+				observer.Call(extendedEnv, call, function, args)
+			}
+
+			evaluated := unwrapReturnValue(evalTailPosition(extendedEnv, function.Body))
+			if evaluated == nil {
+				// The body is empty or ends with a let, which have no value
+				evaluated = NULL
+			}
+
+			if observer != nil {
+				observer.Return(function, evaluated)
+			}
+
+			tailCall, ok := evaluated.(*object.TailCall)
+			if !ok {
+				if errorObject, isError := evaluated.(*object.Error); isError && call != nil {
+					errorObject.Stack = append(errorObject.Stack, frame(call))
+				}
+				return evaluated
+			}
+			env, call, funcObj, args = tailCall.Env, tailCall.Call, tailCall.Function, tailCall.Arguments
+		case *object.Builtin:
+			var observer object.BuiltinObserver
+			if env != nil {
+				observer, _ = env.Observer().(object.BuiltinObserver)
+			}
+			if observer != nil {
+				observer.CallBuiltin(env, call, function, args)
+			}
+
+			result := function.Fn(env, args...)
+
+			if observer != nil {
+				observer.ReturnBuiltin(function, result)
+			}
+			if errorObject, isError := result.(*object.Error); isError && call != nil {
+				raise(env, call, errorObject)
+				errorObject.Stack = append(errorObject.Stack, frame(call))
+			}
+			return result
+		default:
+			return newError("Not a function %T", function)
+		}
+	}
+}
+
+// Apply - Calls a function or builtin with args from env, for builtins that take a function as an
+// argument. Functions whose body has no value return NULL
+func Apply(env *object.Environment, function object.Object, args ...object.Object) object.Object {
+	return applyFunction(env, nil, function, args)
+}
+
+// evalTailPosition - Evaluates a node whose value is the value of the enclosing function. A call
+// in this position is not applied; it is returned as a TailCall for applyFunction to run
+func evalTailPosition(env *object.Environment, node ast.Node) object.Object {
+	switch node.(type) {
+	// The nodes tailPosition evaluates itself. It evaluates the others with Eval
+	case *ast.BlockStatement, *ast.ExpressionStatement, *ast.IfExpression, *ast.CallExpression, *ast.MatchExpression:
+		if env.Observer() != nil {
+			return evalObserved(env, node, tailPosition)
+		}
+		return raise(nil, node, tailPosition(env, node))
+	default:
+		return Eval(env, node)
+	}
+}
+
+func tailPosition(env *object.Environment, node ast.Node) object.Object {
+	switch castedNode := node.(type) {
+	case *ast.BlockStatement:
+		var result object.Object
 
-	switch function := funcObj.(type) {
-	case *object.Function:
-		extendedEnv := extendFunctionEnv(function, args)
-		evaluated := Eval(extendedEnv, function.Body)
-		return unwrapReturnValue(evaluated)
-	case *object.Builtin:
-		return function.Fn(args...)
+		for i, statement := range castedNode.Statements {
+			notifyStatement(env, statement)
+			if i == len(castedNode.Statements)-1 {
+				return evalTailPosition(env, statement)
+			}
+
+			result = Eval(env, statement)
+			if result != nil {
+				resultType := result.Type()
+				if resultType == object.RETURN_VALUE_OBJ || resultType == object.ERROR_OBJ {
+					return result
+				}
+			}
+		}
+
+		return result
+	case *ast.ExpressionStatement:
+		return evalTailPosition(env, castedNode.Expression)
+	case *ast.IfExpression:
+		condition := Eval(env, castedNode.Condition)
+		if isError(condition) {
+			return condition
+		}
+
+		notifyBranch(env, castedNode, IsTruthy(condition))
+		if IsTruthy(condition) {
+			return evalTailPosition(env, castedNode.Consequence)
+		} else if castedNode.Alternative != nil {
+			return evalTailPosition(env, castedNode.Alternative)
+		} else {
+			return NULL
+		}
+	case *ast.CallExpression:
+		if isQuote(castedNode) {
+			return quote(env, castedNode)
+		}
+		function := Eval(env, castedNode.Function)
+		if isError(function) {
+			return function
+		}
+		args := evalExpressions(env, castedNode.Arguments)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		return &object.TailCall{Env: env, Call: castedNode, Function: function, Arguments: args}
+	case *ast.MatchExpression:
+		arm, err := matchArm(env, castedNode)
+		if err != nil {
+			return err
+		}
+		return evalTailPosition(env, arm.Body)
 	default:
-		return newError("Not a function %T", function)
+		return Eval(env, node)
 	}
 }
 
-func extendFunctionEnv(function *object.Function, args []object.Object) *object.Environment {
+// resolveTailCall - Runs obj to completion if it is a pending TailCall
+func resolveTailCall(obj object.Object) object.Object {
+	if tailCall, ok := obj.(*object.TailCall); ok {
+		return applyFunction(tailCall.Env, tailCall.Call, tailCall.Function, tailCall.Arguments)
+	}
+	return obj
+}
+
+// notifyStatement - Tells the environment's Observer, if any, that statement is about to run
+func notifyStatement(env *object.Environment, statement ast.Statement) {
+	if observer := env.Observer(); observer != nil {
+		observer.Statement(env, statement)
+	}
+}
+
+// notifyBranch - Tells the environment's Observer, if it is a BranchObserver, which branch of ifExpression
+// is about to run
+func notifyBranch(env *object.Environment, ifExpression *ast.IfExpression, consequence bool) {
+	if observer, ok := env.Observer().(object.BranchObserver); ok {
+		observer.Branch(env, ifExpression, consequence)
+	}
+}
+
+func extendFunctionEnv(function *object.Function, args []object.Object) (*object.Environment, object.Object) {
 	env := object.NewEnclosedEnvrionment(function.Env)
 
 	for i, param := range function.Parameters {
-		env.Set(param.Value, args[i])
+		if err := bind(env, param, args[i]); err != nil {
+			return nil, err
+		}
 	}
 
-	return env
+	return env, nil
+}
+
+// bind - Sets the names pattern binds in env: the whole value for an identifier, or the parts of it that an
+// array or hash pattern destructures. Defaults are evaluated in env, after the elements before them are bound
+func bind(env *object.Environment, pattern ast.Pattern, value object.Object) object.Object {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		env.Set(pattern.Value, value)
+	case *ast.ArrayPattern:
+		array, ok := value.(*object.Array)
+		if !ok {
+			return newError("Cannot destructure %s with %s", value.Type(), pattern)
+		}
+		if err := checkElementCount(pattern, len(array.Elements)); err != nil {
+			return err
+		}
+
+		for i, element := range pattern.Elements {
+			var elementValue object.Object
+			if i < len(array.Elements) {
+				elementValue = array.Elements[i]
+			}
+			if err := bindElement(env, pattern, element, elementValue); err != nil {
+				return err
+			}
+		}
+		if pattern.Rest != nil {
+			rest := []object.Object{}
+			if len(array.Elements) > len(pattern.Elements) {
+				rest = append(rest, array.Elements[len(pattern.Elements):]...)
+			}
+			env.Set(pattern.Rest.Value, &object.Array{Elements: rest})
+		}
+	case *ast.HashPattern:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return newError("Cannot destructure %s with %s", value.Type(), pattern)
+		}
+
+		bound := map[string]bool{}
+		for _, element := range pattern.Elements {
+			elementValue, _ := hash.Get(&object.String{Value: element.Key})
+			if err := bindElement(env, pattern, element, elementValue); err != nil {
+				return err
+			}
+			bound[element.Key] = true
+		}
+		if pattern.Rest != nil {
+			rest := object.NewHash()
+			for _, pair := range hash.Pairs() {
+				if key, ok := pair.Key.(*object.String); !ok || !bound[key.Value] {
+					rest.Set(pair.Key, pair.Value)
+				}
+			}
+			env.Set(pattern.Rest.Value, rest)
+		}
+	}
+	return nil
+}
+
+// bindElement - Binds element of pattern to value, or to its default when value is missing (nil)
+func bindElement(env *object.Environment, pattern ast.Pattern, element *ast.PatternElement, value object.Object) object.Object {
+	if value == nil {
+		if element.Default == nil {
+			return newError("Missing key %s to destructure with %s", element.KeyString(), pattern)
+		}
+		value = Eval(env, element.Default)
+		if isError(value) {
+			return value
+		}
+	}
+	return bind(env, element.Pattern, value)
+}
+
+// checkElementCount - An error unless an array of count elements fits pattern: it has every element
+// without a default and, unless there is a rest, no more elements than the pattern
+func checkElementCount(pattern *ast.ArrayPattern, count int) object.Object {
+	required := 0
+	for i, element := range pattern.Elements {
+		if element.Default == nil {
+			required = i + 1
+		}
+	}
+
+	var expected string
+	switch {
+	case pattern.Rest != nil:
+		expected = fmt.Sprintf("at least %d", required)
+	case required == len(pattern.Elements):
+		expected = fmt.Sprintf("%d", required)
+	default:
+		expected = fmt.Sprintf("%d to %d", required, len(pattern.Elements))
+	}
+
+	if count < required || (pattern.Rest == nil && count > len(pattern.Elements)) {
+		return newError("Wrong number of elements to destructure with %s. Expected: %s, Got: %d", pattern, expected, count)
+	}
+	return nil
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
-		return returnValue
+		return returnValue.Value
 	}
 	return obj
 }
 
+// evalMemberExpression - The export name of a module, the value of the key name of a hash (null if it is
+// missing) or the kind, message or stack of an error
+func evalMemberExpression(obj object.Object, name string) object.Object {
+	switch obj := obj.(type) {
+	case *object.ErrorValue:
+		switch name {
+		case "kind":
+			return &object.String{Value: obj.Kind}
+		case "message":
+			return &object.String{Value: obj.Message}
+		case "stack":
+			stack := &object.Array{Elements: []object.Object{}}
+			for _, frame := range obj.Stack {
+				stack.Elements = append(stack.Elements, &object.String{Value: frame})
+			}
+			return stack
+		}
+		return newError("Errors have no %s: expected kind, message or stack", name)
+	case *object.Module:
+		value, ok := obj.Exports[name]
+		if !ok {
+			return newError("Module %s has no export %s", obj.Name, name)
+		}
+		return value
+	case *object.Hash:
+		if value, ok := obj.Get(&object.String{Value: name}); ok {
+			return value
+		}
+		return NULL
+	default:
+		return newError("Cannot access %s of %s", name, obj.Type())
+	}
+}
+
+// matchArm - The first arm whose pattern matches the value of matchExpression and whose guard, if any, is
+// truthy. Like the parameter of a catch, the names bound by the pattern of that arm are set in env. The
+// guard is evaluated with the names its pattern binds, which are not set in env when the guard fails
+func matchArm(env *object.Environment, matchExpression *ast.MatchExpression) (*ast.MatchArm, object.Object) {
+	value := Eval(env, matchExpression.Value)
+	if isError(value) {
+		return nil, value
+	}
+
+	for _, arm := range matchExpression.Arms {
+		bindings := map[string]object.Object{}
+		if !matchPattern(arm.Pattern, value, bindings) {
+			continue
+		}
+
+		if arm.Guard != nil {
+			// The guard sees the bindings, which are only bound in env if the arm is chosen
+			guardEnv := object.NewEnclosedEnvrionment(env)
+			for name, bound := range bindings {
+				guardEnv.Set(name, bound)
+			}
+			guard := Eval(guardEnv, arm.Guard)
+			if isError(guard) {
+				return nil, guard
+			}
+			if !IsTruthy(guard) {
+				continue
+			}
+		}
+		for name, bound := range bindings {
+			env.Set(name, bound)
+		}
+		return arm, nil
+	}
+	return nil, newError("No match arm matches %s", value.Inspect())
+}
+
+// matchPattern - Whether value matches pattern, adding the names the pattern binds to bindings. Arrays
+// match patterns of the same length and hashes match when they have every key of the pattern
+func matchPattern(pattern ast.Expression, value object.Object, bindings map[string]object.Object) bool {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			bindings[pattern.Value] = value
+		}
+		return true
+	case *ast.NullLiteral:
+		return value == NULL
+	case *ast.BooleanLiteral:
+		return value == nativeBoolToBooleanObject(pattern.Value)
+	case *ast.IntegerLiteral:
+		integer, ok := value.(*object.Integer)
+		return ok && integer.Value == pattern.Value
+	case *ast.PrefixExpression:
+		integer, ok := value.(*object.Integer)
+		return ok && integer.Value == -pattern.Right.(*ast.IntegerLiteral).Value
+	case *ast.StringLiteral:
+		str, ok := value.(*object.String)
+		return ok && str.Value == pattern.Value
+	case *ast.ArrayLiteral:
+		array, ok := value.(*object.Array)
+		if !ok || len(array.Elements) != len(pattern.Elements) {
+			return false
+		}
+		for i, element := range pattern.Elements {
+			if !matchPattern(element, array.Elements[i], bindings) {
+				return false
+			}
+		}
+		return true
+	case *ast.HashLiteral:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return false
+		}
+		for i, key := range pattern.Keys {
+			element, ok := hash.Get(Eval(nil, key))
+			if !ok || !matchPattern(pattern.Values[i], element, bindings) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// evalTryExpression - Evaluates the try block, then the catch block if it raised an error and finally the
+// finally block. An error or a return in the finally block replaces the result of the other blocks
+func evalTryExpression(env *object.Environment, tryExpression *ast.TryExpression) object.Object {
+	result := evalGuarded(env, tryExpression.Block)
+
+	if errorObject, ok := result.(*object.Error); ok && tryExpression.Catch != nil {
+		if tryExpression.Parameter != nil {
+			env.Set(tryExpression.Parameter.Value, caught(errorObject))
+		}
+		result = evalGuarded(env, tryExpression.Catch)
+	}
+
+	if tryExpression.Finally != nil {
+		finally := Eval(env, tryExpression.Finally)
+		if finally != nil && (finally.Type() == object.ERROR_OBJ || finally.Type() == object.RETURN_VALUE_OBJ) {
+			return finally
+		}
+	}
+
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+// evalGuarded - Evaluates a block of a try expression. A call returned from the block is made before the
+// try finishes (instead of by the trampoline) so that the try can catch its errors
+func evalGuarded(env *object.Environment, block *ast.BlockStatement) object.Object {
+	result := Eval(env, block)
+	if returnValue, ok := result.(*object.ReturnValue); ok {
+		if _, isTailCall := returnValue.Value.(*object.TailCall); isTailCall {
+			value := resolveTailCall(returnValue.Value)
+			if isError(value) {
+				return value
+			}
+			return &object.ReturnValue{Value: value}
+		}
+	}
+	return result
+}
+
+// throw - Raises value as an error. Error values are raised as they are, other values become the message
+// of an error of kind "Error"
+func throw(value object.Object, line int) *object.Error {
+	errorValue, ok := value.(*object.ErrorValue)
+	if !ok {
+		message := value.Inspect()
+		if str, isString := value.(*object.String); isString {
+			message = str.Value
+		}
+		errorValue = &object.ErrorValue{Kind: "Error", Message: message}
+	}
+
+	stack := append([]string{}, errorValue.Stack...)
+	if len(stack) == 0 {
+		stack = append(stack, fmt.Sprintf("thrown at line %d", line))
+	}
+	return &object.Error{Kind: errorValue.Kind, Message: errorValue.Message, Stack: stack}
+}
+
+// caught - The value a catch block gets for errorObject. Errors raised by the interpreter are of kind
+// "RuntimeError"
+func caught(errorObject *object.Error) *object.ErrorValue {
+	kind := errorObject.Kind
+	if kind == "" {
+		kind = "RuntimeError"
+	}
+	return &object.ErrorValue{Kind: kind, Message: errorObject.Message, Stack: errorObject.Stack}
+}
+
+// frame - Describes call in the stack of an error that went through it. Calls of functions made in tail
+// position are not listed, calls of builtins always are
+func frame(call *ast.CallExpression) string {
+	name := "<anonymous>"
+	switch function := call.Function.(type) {
+	case *ast.Identifier:
+		name = function.Value
+	case *ast.MemberExpression:
+		name = function.String()
+	}
+	return fmt.Sprintf("%s called at line %d", name, call.Token.Line)
+}
+
+func evalHashLiteral(env *object.Environment, hashLiteral *ast.HashLiteral) object.Object {
+	hash := object.NewHash()
+
+	for i, keyNode := range hashLiteral.Keys {
+		key := Eval(env, keyNode)
+		if isError(key) {
+			return key
+		}
+		if _, ok := key.(object.Hashable); !ok {
+			return newError("Unusable as hash key: %s", key.Type())
+		}
+
+		value := Eval(env, hashLiteral.Values[i])
+		if isError(value) {
+			return value
+		}
+		hash.Set(key, value)
+	}
+
+	return hash
+}
+
+// evalIndexExpression - Indexes arrays and strings by integer and hashes by key. Indexes out of range
+// and missing keys give null
+func evalIndexExpression(left object.Object, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		elements := left.(*object.Array).Elements
+		i := index.(*object.Integer).Value
+		if i < 0 || i >= int64(len(elements)) {
+			return NULL
+		}
+		return elements[i]
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		value := left.(*object.String).Value
+		i := index.(*object.Integer).Value
+		if i < 0 || i >= int64(len(value)) {
+			return NULL
+		}
+		return &object.String{Value: value[i : i+1]}
+	case left.Type() == object.HASH_OBJ:
+		if _, ok := index.(object.Hashable); !ok {
+			return newError("Unusable as hash key: %s", index.Type())
+		}
+		if value, ok := left.(*object.Hash).Get(index); ok {
+			return value
+		}
+		return NULL
+	default:
+		return newError("Index operator not supported: %s[%s]", left.Type(), index.Type())
+	}
+}
+
 func evalIdentifier(env *object.Environment, identifier *ast.Identifier) object.Object {
 	if value, ok := env.Get(identifier.Value); ok {
 		return value
@@ -288,7 +917,8 @@ func nativeBoolToBooleanObject(boolean bool) *object.Boolean {
 	return FALSE
 }
 
-func isTruthy(obj object.Object) bool {
+// IsTruthy - Whether obj counts as true in a condition: everything but false and null does
+func IsTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
 		return false
@@ -1,9 +1,14 @@
 package evaluator
 
 import (
+	"bytes"
+	"fmt"
+	"monkeylang/ast"
 	"monkeylang/lexer"
 	"monkeylang/object"
 	"monkeylang/parser"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -262,6 +267,25 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestTailCallOptimization(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedValue int64
+	}{
+		{"let countdown = fn(n) { if (n == 0) { 0 } else { countdown(n - 1) } }; countdown(1000000);", 0},
+		{"let countdown = fn(n) { if (n == 0) { return 0; } return countdown(n - 1); }; countdown(1000000);", 0},
+		{"let sum = fn(n, acc) { if (n == 0) { return acc; } sum(n - 1, acc + n) }; sum(1000000, 0);", 500000500000},
+		{"let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } }; let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } }; if (isEven(100001)) { 1 } else { 0 }", 0},
+		{"let add = fn(x, y) { x + y }; let addTwice = fn(x) { return add(x, add(x, x)); }; addTwice(3);", 9},
+		{"let f = fn(x) { x }; return f(7); 9;", 7},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		testIntegerObject(t, evaluated, test.expectedValue)
+	}
+}
+
 func TestBuiltinLenFunction(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -296,6 +320,499 @@ func TestBuiltinLenFunction(t *testing.T) {
 	}
 }
 
+func TestBuiltinJSONFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json_encode({"name": "monkey", "tags": ["a", "b"], "age": 3, "ok": true, "none": json_decode("null")})`, `{"name":"monkey","tags":["a","b"],"age":3,"ok":true,"none":null}`},
+		{`json_encode([1, [2]], 2)`, "[\n  1,\n  [\n    2\n  ]\n]"},
+		{`json_encode({"a": 1}, "--")`, "{\n--\"a\": 1\n}"},
+		{`json_encode([])`, "[]"},
+		{`json_encode("<a & b>")`, `"<a & b>"`},
+		{`json_decode(json_encode({"b": [1, true], "a": "x"}))`, `{"b": [1, true], "a": "x"}`},
+		{`json_decode("[1, -2, false, null]")`, "[1, -2, false, null]"},
+		{`json_decode("[]")[0]`, "null"},
+		{`json_encode(fn(x) { x })`, "ERROR: Cannot encode FUNCTION_OBJ as JSON"},
+		{`json_encode([1, len])`, "ERROR: Cannot encode BUILTIN as JSON"},
+		{`json_encode({1: 2})`, "ERROR: Cannot encode INTEGER hash key 1 as JSON: keys must be strings"},
+		{`json_encode(1, true)`, "ERROR: Invalid indent to `json_encode` function. Expected: INTEGER or STRING, Got: BOOLEAN"},
+		{`json_encode([1], -1)`, "ERROR: Invalid indent to `json_encode` function: -1 is negative"},
+		{`json_encode()`, "ERROR: Invalid number of arguments to `json_encode` function. Expected: 1 or 2, Got: 0"},
+		{`json_decode(1)`, "ERROR: Invalid argument to `json_decode` function. Expected: STRING, Got: INTEGER"},
+		{`json_decode("[1, 2")`, "ERROR: Invalid JSON at offset 5: unexpected end of JSON input"},
+		{`json_decode("[1 2]")`, "ERROR: Invalid JSON at offset 3: invalid character '2' after array element"},
+		{`json_decode("1.5")`, "ERROR: Unsupported number 1.5 at offset 0: only integers are supported"},
+		{`json_decode("1 2")`, "ERROR: Invalid JSON at offset 2: unexpected data after the value"},
+		{`json_decode("")`, "ERROR: Invalid JSON at offset 0: unexpected end of input"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+
+	// Scripts cannot write quotes inside strings, so JSON objects are decoded from Go
+	decoded := builtins["json_decode"].Fn(nil, &object.String{Value: `{"user": {"id": 7, "roles": ["admin"]}, "active": null}`})
+	if expected := `{"user": {"id": 7, "roles": ["admin"]}, "active": null}`; decoded.Inspect() != expected {
+		t.Errorf("Decoded object is incorrect. Expected: %s. Got: %s", expected, decoded.Inspect())
+	}
+	malformed := builtins["json_decode"].Fn(nil, &object.String{Value: `{"a": 1,, "b": 2}`})
+	if expected := "ERROR: Invalid JSON at offset 8: invalid character ',' looking for beginning of value"; malformed.Inspect() != expected {
+		t.Errorf("Error for malformed JSON is incorrect. Expected: %s. Got: %s", expected, malformed.Inspect())
+	}
+}
+
+func TestBuiltinOutputFunctions(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedOutput string
+		expectedError  string
+	}{
+		{`puts("hello", 1, [true, "x"])`, "hello\n1\n[true, \"x\"]\n", ""},
+		{`puts()`, "\n", ""},
+		{`print("a", 1); print("b")`, "a 1b", ""},
+		{`println("a", {"k": 1}); println()`, "a {\"k\": 1}\n\n", ""},
+		{`eprint("oops", 2)`, "", "oops 2"},
+		{`let f = fn(x) { println(x) }; f(1); let g = fn() { f(2) }; g()`, "1\n2\n", ""},
+		{`puts(fn() {}(), fn() { let x = 1 }(), if (true) {}, [if (true) {}])`, "null\nnull\nnull\n[null]\n", ""},
+	}
+
+	for _, test := range tests {
+		var output, errorOutput bytes.Buffer
+		env := object.NewEnvironment()
+		env.SetOutput(&output)
+		env.SetErrorOutput(&errorOutput)
+
+		evaluated := Eval(env, parser.New(lexer.New(test.input)).ParseProgram())
+		if evaluated != NULL {
+			t.Errorf("Value of %q is incorrect. Expected: null. Got: %s", test.input, evaluated.Inspect())
+		}
+		if output.String() != test.expectedOutput {
+			t.Errorf("Output of %q is incorrect. Expected: %q. Got: %q", test.input, test.expectedOutput, output.String())
+		}
+		if errorOutput.String() != test.expectedError {
+			t.Errorf("Error output of %q is incorrect. Expected: %q. Got: %q", test.input, test.expectedError, errorOutput.String())
+		}
+	}
+}
+
+func TestArrayAndHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2 * 2, 3 + 3]", "[1, 4, 6]"},
+		{`["a", true, fn(x) { x }(1)]`, `["a", true, 1]`},
+		{`let two = "two"; {"one": 10 - 9, two: 1 + 1, 3: 3, true: 4}`, `{"one": 1, "two": 2, 3: 3, true: 4}`},
+		{`{"a": 1, "a": 2}`, `{"a": 2}`},
+		{`{[1]: 2}`, "ERROR: Unusable as hash key: ARRAY"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		if evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[1, 2, 3][0]", 1},
+		{"[1, 2, 3][1 + 1]", 3},
+		{"let a = [1, 2, 3]; a[0] + a[1] + a[2]", 6},
+		{"[1, 2, 3][3]", nil},
+		{"[1, 2, 3][-1]", nil},
+		{`"abc"[1]`, "b"},
+		{`{"a": 5}["a"]`, 5},
+		{`{"a": 5}["b"]`, nil},
+		{`let key = "a"; {"a": 5}[key]`, 5},
+		{`{5: 5}[5]`, 5},
+		{`{true: 5}[true]`, 5},
+		{`let h = {"size": 3}; h.size`, 3},
+		{`{"a": 5}.b`, nil},
+		{`len([1, 2]) + len({"a": 1})`, 3},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		switch expected := test.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if str, ok := evaluated.(*object.String); !ok || str.Value != expected {
+				t.Errorf("Value of %q is incorrect. Expected: %q. Got: %s", test.input, expected, evaluated.Inspect())
+			}
+		default:
+			if evaluated != NULL {
+				t.Errorf("Value of %q is incorrect. Expected: null. Got: %s", test.input, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestIndexErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1[0]", "Index operator not supported: INTEGER[INTEGER]"},
+		{`[1]["a"]`, "Index operator not supported: ARRAY[STRING]"},
+		{`{"a": 1}[fn(x) { x }]`, "Unusable as hash key: FUNCTION_OBJ"},
+		{"1.a", "Cannot access a of INTEGER"},
+		{"fn(x, y) { x }(1)", "Wrong number of arguments. Expected: 2, Got: 1"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		errorObject, ok := evaluated.(*object.Error)
+		if !ok || errorObject.Message != test.expected {
+			t.Errorf("Error of %q is incorrect. Expected: %s. Got: %s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestNullAndOptionalChaining(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"null", "null"},
+		{"[null == null, null != null, 1 == null, null != false, \"a\" == null]", "[true, false, false, true, false]"},
+		{"if (null) { 1 } else { 2 }", "2"},
+		{"null ?? 5", "5"},
+		{"false ?? 5", "false"},
+		{"let a = null; a ?? b", "ERROR: Unknown identifier: b"},
+		{"1 ?? b", "1"},
+		{"null ?? null ?? 3", "3"},
+		{`let h = {"a": {"b": 1}}; [h?.a?.b, h.x?.b, h?.x, h.a?["b"]]`, "[1, null, null, 1]"},
+		{"let a = null; a?[f()]", "null"},
+		{"let a = null; a?.b.c", "ERROR: Cannot access c of NULL"},
+		{`let h = {"n": null}; h.n?.x ?? "default"`, "default"},
+		{"null.a", "ERROR: Cannot access a of NULL"},
+		{"null < 1", "ERROR: Mismatch types: NULL < INTEGER"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		if evaluated == nil || evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %v", test.input, test.expected, evaluated)
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (2) { 1 => \"one\", 2 => \"two\", _ => \"many\" }", "two"},
+		{"match (7) { 1 => \"one\", _ => \"many\" }", "many"},
+		{"match (-3) { -3 => true, _ => false }", "true"},
+		{"match (null) { null => 1, _ => 2 }", "1"},
+		{"match (false) { true => 1, false => 2 }", "2"},
+		{"match (\"a\") { 1 => 1, \"a\" => 2 }", "2"},
+		{"match (5) { n if n < 0 => -1, n if n > 0 => 1, _ => 0 }", "1"},
+		{"match (5) { n => n * 2 }", "10"},
+		{"match ([1, [2, 3]]) { [a] => a, [a, [b, c]] => a + b + c }", "6"},
+		{"match ([1, 2]) { [_, _, _] => 3, [_, _] => 2 }", "2"},
+		{`match ({"x": 1, "y": 2, "z": 3}) { {"x": 0} => 0, {"x": 1, "y": y} => y }`, "2"},
+		{`match ({"x": 1}) { {"y": y} => y, _ => "no y" }`, "no y"},
+		{"match (1) { n => n }; n", "1"},
+		{"let x = 1; match (5) { x if x > 10 => 100, _ => x }", "1"},
+		{"let x = 1; match (5) { x if x > 10 => 100, y => y }; x", "1"},
+		{"let f = fn(v) { let x = 1; match (v) { x if x > 10 => 100, _ => x } }; f(5)", "1"},
+		{"match ([5, 20]) { [a, b] if a > b => a, [a, b] if b > a => b }", "20"},
+		{"match (4) { 1 => 1, 2 => 2 }", "ERROR: No match arm matches 4"},
+		{"match ([1]) { [a] if a + true => a }", "ERROR: Mismatch types: INTEGER + BOOLEAN"},
+		{"let count = fn(n, total) { match (n) { 0 => total, _ => count(n - 1, total + 1) } }; count(100000, 0)", "100000"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		if evaluated == nil || evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %v", test.input, test.expected, evaluated)
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = [1, 2]; a + b", "3"},
+		{"let [first, ...rest] = [1, 2, 3]; [first, rest]", "[1, [2, 3]]"},
+		{"let [a, ...rest] = [1]; rest", "[]"},
+		{"let [a, [b, c]] = [1, [2, 3]]; a + b + c", "6"},
+		{"let [a, b = a + 1] = [1]; [a, b]", "[1, 2]"},
+		{"let [a, b = 5] = [1, null]; b", "null"},
+		{`let {name, age} = {"name": "Ana", "age": 30}; [name, age]`, `["Ana", 30]`},
+		{`let {name: n, "full name": full} = {"name": "a", "full name": "b"}; [n, full]`, `["a", "b"]`},
+		{`let {pos: [x, y], size = 1} = {"pos": [3, 4]}; x * y * size`, "12"},
+		{`let {a, ...others} = {"a": 1, "b": 2, 3: 4}; others`, `{"b": 2, 3: 4}`},
+		{"let divmod = fn(a, b) { [a / b, a - a / b * b] }; let [q, r] = divmod(7, 2); [q, r]", "[3, 1]"},
+		{`let f = fn([a, b], {c}) { a + b + c }; f([1, 2], {"c": 3})`, "6"},
+		{`let f = fn(x, [y, z = x]) { [y, z] }; f(9, [1])`, "[1, 9]"},
+
+		{"let [a, b] = [1];", "ERROR: Wrong number of elements to destructure with [a, b]. Expected: 2, Got: 1"},
+		{"let [a, b] = [1, 2, 3];", "ERROR: Wrong number of elements to destructure with [a, b]. Expected: 2, Got: 3"},
+		{"let [a, b = 1] = [];", "ERROR: Wrong number of elements to destructure with [a, b = 1]. Expected: 1 to 2, Got: 0"},
+		{"let [a, ...b] = [];", "ERROR: Wrong number of elements to destructure with [a, ...b]. Expected: at least 1, Got: 0"},
+		{"let [a] = 1;", "ERROR: Cannot destructure INTEGER with [a]"},
+		{`let {a} = [1];`, "ERROR: Cannot destructure ARRAY with {a}"},
+		{`let {a, "b c": d} = {"a": 1};`, `ERROR: Missing key "b c" to destructure with {a, "b c": d}`},
+		{`let {a: [b]} = {"a": {}};`, "ERROR: Cannot destructure HASH with [b]"},
+		{"let f = fn([a]) { a }; f(1)", "ERROR: Cannot destructure INTEGER with [a]"},
+		{"let [a = b] = [];", "ERROR: Unknown identifier: b"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		if evaluated == nil || evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %v", test.input, test.expected, evaluated)
+		}
+	}
+}
+
+func TestTryCatchAndThrow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { 1 } catch (e) { 2 }", "1"},
+		{"try { 1 + true } catch (e) { e }", "RuntimeError: Mismatch types: INTEGER + BOOLEAN"},
+		{"try { foo } catch { 0 }", "0"},
+		{`try { throw "boom" } catch (e) { [e.kind, e.message] }`, `["Error", "boom"]`},
+		{`try { throw 42 } catch (e) { e.message }`, "42"},
+		{`try { raise error("bad input", "ValueError") } catch (e) { e }`, "ValueError: bad input"},
+		{`let f = fn(x) { throw "in f" }; try { f(1) } catch (e) { e.stack }`, `["thrown at line 1", "f called at line 1"]`},
+		{"let f = fn() {\n throw \"deep\"\n};\nlet g = fn() { let r = f(); r };\ntry { g() } catch (e) { e.stack }", `["thrown at line 2", "f called at line 4", "g called at line 5"]`},
+		{`try { try { throw "inner" } catch (e) { throw e } } catch (e) { e.stack }`, `["thrown at line 1"]`},
+		{`let x = 0; try { throw "a" } catch { x } finally { 1 }`, "0"},
+		{`try { 1 } finally { throw "cleanup" }`, "ERROR: cleanup"},
+		{`throw "uncaught"`, "ERROR: uncaught"},
+		{`let f = fn() { try { return g() } catch (e) { return e.message } }; let g = fn() { throw "tail" }; f()`, "tail"},
+		{`let f = fn() { try { return 1 } finally { 2 } }; f()`, "1"},
+		{`[is_error(error("x")), is_error("x"), is_error(try { 1 + true } catch (e) { e })]`, "[true, false, true]"},
+		{`error("x").line`, "ERROR: Errors have no line: expected kind, message or stack"},
+		{`error(1)`, "ERROR: Invalid argument to `error` function. Expected: STRING, Got: INTEGER"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		if evaluated == nil || evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %v", test.input, test.expected, evaluated)
+		}
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[assert(1 < 2), assert_eq([1, {"a": "b"}], [1, {"a": "b"}]), assert_eq(null, null)]`, "[null, null, null]"},
+		{"assert(1 > 2)", "ERROR: Expected a truthy value. Got: false"},
+		{`assert(null, "x is set")`, "ERROR: x is set\nExpected a truthy value. Got: null"},
+		{"assert_eq([1, 2, 3], [1, 2, 4])", "ERROR: Expected: [1, 2, 4]\n     Got: [1, 2, 3]\n                 ^"},
+		{`assert_eq("1", 1, "parsed")`, "ERROR: parsed\nExpected: 1\n     Got: \"1\"\n          ^"},
+		{`assert_eq({"a": 1}, {"a": 1, "b": 2})`, "ERROR: Expected: {\"a\": 1, \"b\": 2}\n     Got: {\"a\": 1}\n                 ^"},
+		{`try { assert(false) } catch (e) { [e.kind, e.stack] }`, `["AssertionError", ["assert called at line 1"]]`},
+		{`assert_error(fn() { 1 + true })`, "RuntimeError: Mismatch types: INTEGER + BOOLEAN"},
+		{`assert_error(fn() { throw error("bad", "ValueError") }, "ValueError").message`, "bad"},
+		{`assert_error(fn() { throw "bad" }, "ValueError")`, "ERROR: Expected an error of kind ValueError. Got: Error: bad"},
+		{`assert_error(fn() { "fine" })`, "ERROR: Expected an error. Got: \"fine\""},
+		{`assert_error(fn() {})`, "ERROR: Expected an error. Got: null"},
+		{`assert_error(fn() { let x = 1 })`, "ERROR: Expected an error. Got: null"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		if evaluated == nil || evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %v", test.input, test.expected, evaluated)
+		}
+	}
+}
+
+func TestQuoteAndUnquote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"quote(5)", "QUOTE(5)"},
+		{"quote(foobar + 8)", "QUOTE((foobar + 8))"},
+		{"quote(unquote(4 + 4) + 8)", "QUOTE((8 + 8))"},
+		{"let x = 8; quote(unquote(x) * y)", "QUOTE((8 * y))"},
+		{`quote(unquote("a") + unquote(true) ?? unquote(null))`, `QUOTE(((a + true) ?? null))`},
+		{"let q = quote(4 + 4); quote(unquote(q) + unquote(q))", "QUOTE(((4 + 4) + (4 + 4)))"},
+		{"quote(fn(x) { unquote(1 + 1) })", "QUOTE(fn( x) 2)"},
+		{"quote(1, 2)", "ERROR: Invalid number of arguments to `quote`. Expected: 1, Got: 2"},
+		{"quote(unquote([1]))", "ERROR: Cannot unquote ARRAY: only integers, strings, booleans, null and quoted code can be unquoted"},
+		{"quote(unquote(nope))", "ERROR: Unknown identifier: nope"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		if evaluated == nil || evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %v", test.input, test.expected, evaluated)
+		}
+	}
+}
+
+func TestMacroExpansion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let unless = macro(condition, consequence, alternative) { quote(if (!(unquote(condition))) { unquote(consequence) } else { unquote(alternative) }) }; unless(10 > 5, 1, 2)", "2"},
+		{"let twice = macro(x) { quote(unquote(x) + unquote(x)) }; let n = 0; let next = fn() { let n = n + 1; n }; twice(next())", "2"},
+		{"let reverse = macro(a, b) { quote(unquote(b) - unquote(a)) }; reverse(2 + 2, 10 - 5)", "1"},
+		{"let square = macro(x) { quote(unquote(x) * unquote(x)) }; let f = fn(n) { square(n + 1) }; f(2)", "9"},
+		{"let answer = macro() { quote(42) }; [answer(), answer()]", "[42, 42]"},
+		{"let plain = fn(x) { x }; plain(1)", "1"},
+		{"let m = macro(x) { x }; m(1 + 1)", "2"},
+
+		{"let m = macro(x) { 1 }; m(2)", "ERROR: In macro m called at line 1: Macros must return quoted code. Got: INTEGER"},
+		{"let m = macro(x) { x }; m()", "ERROR: In macro m called at line 1: Wrong number of arguments. Expected: 1, Got: 0"},
+		{"let m = macro() { quote(unquote(1 + true)) }; m()", "ERROR: In macro m called at line 1: Mismatch types: INTEGER + BOOLEAN"},
+		{"let f = fn() { macro(x) { x } }; f()", "ERROR: Macros can only be defined by top level let statements"},
+	}
+
+	for _, test := range tests {
+		program := parser.New(lexer.New(test.input)).ParseProgram()
+		env := object.NewEnvironment()
+
+		var evaluated object.Object
+		if err := ExpandMacros(env, program); err != nil {
+			evaluated = err
+		} else {
+			evaluated = Eval(env, program)
+		}
+		if evaluated == nil || evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %v", test.input, test.expected, evaluated)
+		}
+	}
+}
+
+func TestRefs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let n = ref(1); [get(n), set(n, 2), get(n), n]", "[1, 2, 2, ref(2)]"},
+		{"let n = ref(1); update(n, fn(x) { x * 10 }); get(n)", "10"},
+		{"let make = fn() { let n = ref(0); fn() { update(n, fn(x) { x + 1 }) } }; let a = make(); let b = make(); a(); a(); b(); [a(), b()]", "[3, 2]"},
+		{"let n = ref(0); let outer = fn() { let inner = fn() { fn() { set(n, get(n) + 5) } }; inner()() }; outer(); outer(); get(n)", "10"},
+		{"let once = fn(f) { let result = ref(null); fn(x) { let hit = get(result); if (hit != null) { return hit }; set(result, f(x)) } }; let calls = ref(0); let g = once(fn(x) { update(calls, fn(c) { c + 1 }); x * 2 }); [g(2), g(3), get(calls)]", "[4, 4, 1]"},
+		{"let account = fn() { let total = ref(0); {\"add\": fn(n) { update(total, fn(t) { t + n }) }, \"total\": fn() { get(total) }} }; let a = account(); a[\"add\"](5); a[\"add\"](7); a[\"total\"]()", "12"},
+		{"let n = ref(1); let x = n; set(x, 5); get(n)", "5"},
+		{"let r = ref(0); set(r, [r, {\"self\": r}]); r", `ref([ref(...), {"self": ref(...)}])`},
+		{"let a = ref(0); let b = ref(a); set(a, b); [a, b]", "[ref(ref(ref(...))), ref(ref(ref(...)))]"},
+		{"let r = ref(1); [update(r, fn(x) {}), get(r), r]", "[null, null, ref(null)]"},
+		{"let r = ref(0); let a = [r, r]; set(r, 1); [a, ref(a)]", "[[ref(1), ref(1)], ref([ref(1), ref(1)])]"},
+		{"let n = ref(1); update(n, fn(x) { throw \"no\" }); get(n)", "ERROR: no"},
+		{"let n = ref(1); try { update(n, fn(x) { throw \"no\" }) } catch { 0 }; get(n)", "1"},
+		{"get(1)", "ERROR: Invalid argument to `get` function. Expected: REF, Got: INTEGER"},
+		{"update(ref(1), 2)", "ERROR: Invalid argument to `update` function. Expected: FUNCTION_OBJ, Got: INTEGER"},
+		{"set(ref(1))", "ERROR: Invalid number of arguments to `set` function. Expected: 2, Got: 1"},
+	}
+
+	for _, test := range tests {
+		evaluated := runMonkeyLang(test.input)
+		if evaluated == nil || evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %v", test.input, test.expected, evaluated)
+		}
+	}
+}
+
+func TestRefUpdateIsAtomic(t *testing.T) {
+	env := object.NewEnvironment()
+	counter := Eval(env, parser.New(lexer.New("let n = ref(0); fn() { update(n, fn(x) { x + 1 }) }")).ParseProgram())
+
+	var group sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for j := 0; j < 100; j++ {
+				Apply(env, counter)
+			}
+		}()
+	}
+	group.Wait()
+
+	if result := Apply(env, counter); result.Inspect() != "801" {
+		t.Errorf("Counter is incorrect. Expected: 801. Got: %s", result.Inspect())
+	}
+}
+
+func TestRefInspectIsConcurrent(t *testing.T) {
+	ref := runMonkeyLang("ref([ref(1), ref(2)])")
+
+	var group sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for j := 0; j < 100; j++ {
+				if got := ref.Inspect(); got != "ref([ref(1), ref(2)])" {
+					t.Errorf("Inspect is incorrect. Expected: ref([ref(1), ref(2)]). Got: %s", got)
+					return
+				}
+			}
+		}()
+	}
+	group.Wait()
+}
+
+// recorder - An Observer recording the names bound and the errors raised
+type recorder struct {
+	object.BaseObserver
+	events []string
+}
+
+func (recorder *recorder) Bind(env *object.Environment, name string, value object.Object) {
+	if value.Type() == object.FUNCTION_OBJ {
+		recorder.events = append(recorder.events, name+" = fn")
+		return
+	}
+	recorder.events = append(recorder.events, name+" = "+value.Inspect())
+}
+
+func (recorder *recorder) Error(env *object.Environment, node ast.Node, err *object.Error) {
+	recorder.events = append(recorder.events, fmt.Sprintf("%s at %d:%d", err.Message, node.Line(), node.Column()))
+}
+
+func TestObservers(t *testing.T) {
+	input := "let f = fn(x) {\n  x + true\n};\nlet [a, b] = [1, 2];\ntry { f(a) } catch (e) { b }\nf(b)"
+	expected := "f = fn a = 1 b = 2 x = 1 Mismatch types: INTEGER + BOOLEAN at 2:5 e = RuntimeError: Mismatch types: INTEGER + BOOLEAN x = 2 Mismatch types: INTEGER + BOOLEAN at 2:5"
+
+	first, second := &recorder{}, &recorder{}
+	env := object.NewEnvironment()
+	env.SetObserver(object.Observers{first, second})
+	evaluated := Eval(env, parser.New(lexer.New(input)).ParseProgram())
+
+	for _, recorder := range []*recorder{first, second} {
+		if got := strings.Join(recorder.events, " "); got != expected {
+			t.Errorf("Events are incorrect. Expected: %q. Got: %q", expected, got)
+		}
+	}
+	err, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("Result is not an error. Got: %T", evaluated)
+	}
+	if err.Node == nil || err.Node.Line() != 2 || err.Node.Column() != 5 {
+		t.Errorf("Error node is incorrect. Expected: the node at 2:5. Got: %v", err.Node)
+	}
+}
+
 func runMonkeyLang(input string) object.Object {
 	lexer := lexer.New(input)
 	parser := parser.New(lexer)
@@ -0,0 +1,182 @@
+package evaluator
+
+import (
+	"monkeylang/ast"
+	"monkeylang/object"
+	"monkeylang/token"
+	"strconv"
+)
+
+// ExpandMacros - The pass between parsing and evaluation. Removes the top level lets that define macros
+// from program, binding the macros in env, then replaces each call of a macro bound in env with the code
+// the macro returns. Macros are called with their arguments quoted. The first macro that fails stops the
+// expansion and its error is returned
+func ExpandMacros(env *object.Environment, program *ast.Program) *object.Error {
+	defineMacros(env, program)
+	if !callsMacros(env, program) {
+		return nil
+	}
+
+	var expansionError *object.Error
+	expanded := ast.Modify(program, func(node ast.Node) ast.Node {
+		call, ok := node.(*ast.CallExpression)
+		if !ok || expansionError != nil {
+			return node
+		}
+		name, macro := macroOf(env, call)
+		if macro == nil {
+			return node
+		}
+
+		code, err := expandMacro(macro, call)
+		if err != nil {
+			expansionError = newError("In macro %s called at line %d: %s", name, call.Token.Line, err.Message)
+			return node
+		}
+		return code
+	})
+
+	program.Statements = expanded.(*ast.Program).Statements
+	return expansionError
+}
+
+// defineMacros - Binds the macros defined by the top level lets of program in env and removes those lets
+func defineMacros(env *object.Environment, program *ast.Program) {
+	statements := []ast.Statement{}
+	for _, statement := range program.Statements {
+		if let, ok := statement.(*ast.LetStatement); ok && let.Name != nil {
+			if function, ok := let.Value.(*ast.FunctionLiteral); ok && function.IsMacro() {
+				env.Set(let.Name.Value, &object.Macro{Parameters: function.Parameters, Body: function.Body, Env: env})
+				continue
+			}
+		}
+		statements = append(statements, statement)
+	}
+	program.Statements = statements
+}
+
+// callsMacros - Whether program calls any macro bound in env. Programs without macro calls are left as
+// they were parsed
+func callsMacros(env *object.Environment, program *ast.Program) bool {
+	found := false
+	ast.Walk(program, func(node ast.Node) bool {
+		if call, ok := node.(*ast.CallExpression); ok {
+			if _, macro := macroOf(env, call); macro != nil {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// macroOf - The name and the macro call calls, if call calls a macro bound in env
+func macroOf(env *object.Environment, call *ast.CallExpression) (string, *object.Macro) {
+	identifier, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return "", nil
+	}
+	obj, ok := env.Get(identifier.Value)
+	if !ok {
+		return "", nil
+	}
+	macro, _ := obj.(*object.Macro)
+	return identifier.Value, macro
+}
+
+// expandMacro - The code macro returns when called with the quoted arguments of call
+func expandMacro(macro *object.Macro, call *ast.CallExpression) (ast.Node, *object.Error) {
+	if len(call.Arguments) != len(macro.Parameters) {
+		return nil, newError("Wrong number of arguments. Expected: %d, Got: %d", len(macro.Parameters), len(call.Arguments))
+	}
+
+	env := object.NewEnclosedEnvrionment(macro.Env)
+	for i, parameter := range macro.Parameters {
+		if err := bind(env, parameter, &object.Quote{Node: call.Arguments[i]}); err != nil {
+			return nil, err.(*object.Error)
+		}
+	}
+
+	evaluated := resolveTailCall(unwrapReturnValue(Eval(env, macro.Body)))
+	switch evaluated := evaluated.(type) {
+	case *object.Quote:
+		return evaluated.Node, nil
+	case *object.Error:
+		return nil, evaluated
+	case nil:
+		return nil, newError("Macros must return quoted code. Got: nothing")
+	default:
+		return nil, newError("Macros must return quoted code. Got: %s", evaluated.Type())
+	}
+}
+
+// isQuote - Whether call uses quote, which is not a function: its argument is not evaluated
+func isQuote(call *ast.CallExpression) bool {
+	identifier, ok := call.Function.(*ast.Identifier)
+	return ok && identifier.Value == "quote"
+}
+
+// quote - The code of the argument of a quote call, in which each unquote(value) is replaced by the code of
+// the value
+func quote(env *object.Environment, call *ast.CallExpression) object.Object {
+	if len(call.Arguments) != 1 {
+		return newError("Invalid number of arguments to `quote`. Expected: 1, Got: %d", len(call.Arguments))
+	}
+
+	var unquoteError object.Object
+	node := ast.Modify(call.Arguments[0], func(node ast.Node) ast.Node {
+		unquote, ok := node.(*ast.CallExpression)
+		if !ok || unquoteError != nil {
+			return node
+		}
+		if identifier, ok := unquote.Function.(*ast.Identifier); !ok || identifier.Value != "unquote" {
+			return node
+		}
+		if len(unquote.Arguments) != 1 {
+			unquoteError = newError("Invalid number of arguments to `unquote`. Expected: 1, Got: %d", len(unquote.Arguments))
+			return node
+		}
+
+		value := Eval(env, unquote.Arguments[0])
+		if isError(value) {
+			unquoteError = value
+			return node
+		}
+		code := codeOf(value, unquote.Token)
+		if code == nil {
+			unquoteError = newError("Cannot unquote %s: only integers, strings, booleans, null and quoted code can be unquoted", value.Type())
+			return node
+		}
+		return code
+	})
+
+	if unquoteError != nil {
+		return unquoteError
+	}
+	return &object.Quote{Node: node}
+}
+
+// codeOf - A literal for value, positioned at tok, or the code of a Quote. nil for values without literals
+func codeOf(value object.Object, tok token.Token) ast.Node {
+	at := func(tokenType token.TokenType, literal string) token.Token {
+		return token.Token{Type: tokenType, Literal: literal, Line: tok.Line, Column: tok.Column}
+	}
+
+	switch value := value.(type) {
+	case *object.Quote:
+		return value.Node
+	case *object.Integer:
+		return &ast.IntegerLiteral{Token: at(token.INT, strconv.FormatInt(value.Value, 10)), Value: value.Value}
+	case *object.String:
+		return &ast.StringLiteral{Token: at(token.STRING, value.Value), Value: value.Value}
+	case *object.Boolean:
+		if value.Value {
+			return &ast.BooleanLiteral{Token: at(token.TRUE, "true"), Value: true}
+		}
+		return &ast.BooleanLiteral{Token: at(token.FALSE, "false"), Value: false}
+	case *object.Null:
+		return &ast.NullLiteral{Token: at(token.NULL, "null")}
+	default:
+		return nil
+	}
+}
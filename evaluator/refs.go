@@ -0,0 +1,83 @@
+package evaluator
+
+import "monkeylang/object"
+
+// references - Builtins making and changing mutable cells, the state closures share (eg. counters and
+// caches). A let inside a function only binds a new name, so it cannot change the values its closures see
+var references = map[string]object.BuiltinFunction{
+	// ref(value) - A new cell holding value
+	"ref": func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("Invalid number of arguments to `ref` function. Expected: 1, Got: %d", len(args))
+		}
+		return object.NewRef(args[0])
+	},
+	// get(ref) - The value held by ref
+	"get": func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 {
+			return newError("Invalid number of arguments to `get` function. Expected: 1, Got: %d", len(args))
+		}
+		ref, err := refArgument("get", args[0])
+		if err != nil {
+			return err
+		}
+		return ref.Get()
+	},
+	// set(ref, value) - Replaces the value held by ref. Returns value
+	"set": func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return newError("Invalid number of arguments to `set` function. Expected: 2, Got: %d", len(args))
+		}
+		ref, err := refArgument("set", args[0])
+		if err != nil {
+			return err
+		}
+		ref.Set(args[1])
+		return args[1]
+	},
+	// update(ref, function) - Replaces the value held by ref with the result of calling function with it,
+	// and returns the result. When ref is changed by another program while function runs, function is
+	// called again with the new value, so it should not change ref itself. An error raised by function
+	// leaves ref unchanged
+	"update": func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return newError("Invalid number of arguments to `update` function. Expected: 2, Got: %d", len(args))
+		}
+		ref, err := refArgument("update", args[0])
+		if err != nil {
+			return err
+		}
+		if args[1].Type() != object.FUNCTION_OBJ && args[1].Type() != object.BUILTIN_OBJ {
+			return newError("Invalid argument to `update` function. Expected: FUNCTION_OBJ, Got: %s", args[1].Type())
+		}
+
+		for {
+			old := ref.Get()
+			result := Apply(env, args[1], old)
+			if _, ok := result.(*object.Error); ok {
+				return result
+			}
+			if result == nil {
+				result = NULL
+			}
+			if ref.CompareAndSet(old, result) {
+				return result
+			}
+		}
+	},
+}
+
+func init() {
+	for name, reference := range references {
+		builtins[name] = &object.Builtin{Fn: reference}
+	}
+}
+
+// refArgument - The cell passed to the builtin name, or an error when arg is not one
+func refArgument(name string, arg object.Object) (*object.Ref, *object.Error) {
+	ref, ok := arg.(*object.Ref)
+	if !ok {
+		return nil, newError("Invalid argument to `%s` function. Expected: REF, Got: %s", name, arg.Type())
+	}
+	return ref, nil
+}
@@ -0,0 +1,364 @@
+package format
+
+import (
+	"bytes"
+	"monkeylang/ast"
+	"monkeylang/parser"
+	"monkeylang/token"
+	"strings"
+)
+
+const INDENT = "\t"
+
+// printer - Writes nodes as source code, tracking the indentation of the enclosing blocks
+type printer struct {
+	out      bytes.Buffer
+	indent   int
+	comments []token.Token // the comments not written yet
+}
+
+// Source - Formats a parsed program as MonkeyLang source: one statement per line, blocks indented with
+// tabs and single spaces around operators. Blank lines between statements are kept (at most one)
+//
+// Comments are written on their own line before the statement that follows them, or after the statement
+// that ends on their line. Comments inside a statement that spans lines are moved before the next one
+func Source(program *ast.Program) string {
+	printer := &printer{comments: program.Comments}
+	printer.statements(program.Statements)
+	for _, comment := range printer.comments {
+		printer.write("//", comment.Literal, "\n")
+	}
+	return printer.out.String()
+}
+
+// Node - Formats a single statement or expression
+func Node(node ast.Node) string {
+	printer := &printer{}
+	switch node := node.(type) {
+	case *ast.Program:
+		printer.statements(node.Statements)
+	case ast.Statement:
+		printer.statement(node)
+	case ast.Expression:
+		printer.expression(node, parser.LOWEST)
+	}
+	return strings.TrimSuffix(printer.out.String(), "\n")
+}
+
+func (printer *printer) write(strs ...string) {
+	for _, str := range strs {
+		printer.out.WriteString(str)
+	}
+}
+
+func (printer *printer) newline() {
+	printer.write("\n", strings.Repeat(INDENT, printer.indent))
+}
+
+func (printer *printer) statements(statements []ast.Statement) {
+	for i, statement := range statements {
+		if i > 0 {
+			if printer.firstLine(statement)-EndLine(statements[i-1]) > 1 {
+				printer.write("\n")
+			}
+			printer.newline()
+		}
+		printer.commentsBefore(statement.Line(), true)
+		printer.statement(statement)
+		if len(printer.comments) > 0 && printer.comments[0].Line == EndLine(statement) {
+			printer.write(" //", printer.comments[0].Literal)
+			printer.comments = printer.comments[1:]
+		}
+	}
+	if len(statements) > 0 && printer.indent == 0 {
+		printer.write("\n")
+	}
+}
+
+func (printer *printer) statement(statement ast.Statement) {
+	switch statement := statement.(type) {
+	case *ast.LetStatement:
+		if statement.Exported {
+			printer.write("export ")
+		}
+		printer.write("let ")
+		if statement.Pattern != nil {
+			printer.pattern(statement.Pattern)
+		} else {
+			printer.write(statement.Name.Value)
+		}
+		if statement.Type != nil {
+			printer.write(": ", statement.Type.Name)
+		}
+		printer.write(" = ")
+		printer.expression(statement.Value, parser.LOWEST)
+		printer.write(";")
+	case *ast.ReturnStatement:
+		printer.write("return ")
+		printer.expression(statement.ReturnValue, parser.LOWEST)
+		printer.write(";")
+	case *ast.ExpressionStatement:
+		printer.expression(statement.Expression, parser.LOWEST)
+		// Blocks already end in "}" so only other expressions are terminated
+		switch statement.Expression.(type) {
+		case *ast.IfExpression, *ast.TryExpression, *ast.MatchExpression:
+		default:
+			printer.write(";")
+		}
+	case *ast.BlockStatement:
+		printer.block(statement)
+	}
+}
+
+func (printer *printer) block(block *ast.BlockStatement) {
+	if block != nil && len(block.Statements) == 0 && printer.hasCommentBefore(block.EndToken.Line) {
+		printer.write("{")
+		printer.indent++
+		printer.commentsBefore(block.EndToken.Line, false)
+		printer.indent--
+		printer.newline()
+		printer.write("}")
+		return
+	}
+	if block == nil || len(block.Statements) == 0 {
+		printer.write("{}")
+		return
+	}
+
+	printer.write("{")
+	printer.indent++
+	printer.newline()
+	printer.statements(block.Statements)
+	printer.commentsBefore(block.EndToken.Line, false)
+	printer.indent--
+	printer.newline()
+	printer.write("}")
+}
+
+// expression - Writes expression, wrapped in parentheses if it binds more loosely than the surrounding
+// operator (precedence)
+func (printer *printer) expression(expression ast.Expression, precedence int) {
+	switch expression := expression.(type) {
+	case nil:
+	case *ast.Identifier:
+		printer.write(expression.Value)
+	case *ast.IntegerLiteral:
+		printer.write(expression.Token.Literal)
+	case *ast.BooleanLiteral, *ast.NullLiteral:
+		printer.write(expression.TokenLiteral())
+	case *ast.StringLiteral:
+		printer.write(`"`, expression.Value, `"`)
+	case *ast.PrefixExpression:
+		printer.parenthesize(precedence > parser.PREFIX, func() {
+			printer.write(expression.Operator)
+			printer.expression(expression.Right, parser.PREFIX)
+		})
+	case *ast.InfixExpression:
+		operatorPrecedence := parser.Precedence(token.TokenType(expression.Operator))
+		printer.parenthesize(precedence > operatorPrecedence, func() {
+			printer.expression(expression.Left, operatorPrecedence)
+			printer.write(" ", expression.Operator, " ")
+			// Operators are left associative so an equal precedence on the right needs parentheses
+			printer.expression(expression.Right, operatorPrecedence+1)
+		})
+	case *ast.IfExpression:
+		printer.write("if (")
+		printer.expression(expression.Condition, parser.LOWEST)
+		printer.write(") ")
+		printer.block(expression.Consequence)
+		if expression.Alternative != nil {
+			printer.write(" else ")
+			printer.block(expression.Alternative)
+		}
+	case *ast.TryExpression:
+		printer.write("try ")
+		printer.block(expression.Block)
+		if expression.Catch != nil {
+			printer.write(" catch ")
+			if expression.Parameter != nil {
+				printer.write("(", expression.Parameter.Value, ") ")
+			}
+			printer.block(expression.Catch)
+		}
+		if expression.Finally != nil {
+			printer.write(" finally ")
+			printer.block(expression.Finally)
+		}
+	case *ast.MatchExpression:
+		printer.write("match (")
+		printer.expression(expression.Value, parser.LOWEST)
+		printer.write(") {")
+		printer.indent++
+		for _, arm := range expression.Arms {
+			printer.newline()
+			printer.expression(arm.Pattern, parser.LOWEST)
+			if arm.Guard != nil {
+				printer.write(" if ")
+				printer.expression(arm.Guard, parser.LOWEST)
+			}
+			printer.write(" => ")
+			printer.expression(arm.Body, parser.LOWEST)
+			printer.write(",")
+		}
+		printer.indent--
+		printer.newline()
+		printer.write("}")
+	case *ast.ThrowExpression:
+		printer.parenthesize(precedence > parser.LOWEST, func() {
+			printer.write(expression.Token.Literal, " ")
+			printer.expression(expression.Value, parser.LOWEST)
+		})
+	case *ast.FunctionLiteral:
+		printer.write(expression.Token.Literal, "(")
+		for i, parameter := range expression.Parameters {
+			if i > 0 {
+				printer.write(", ")
+			}
+			printer.pattern(parameter)
+			if parameterType := expression.ParameterType(i); parameterType != nil {
+				printer.write(": ", parameterType.Name)
+			}
+		}
+		printer.write(") ")
+		if expression.ReturnType != nil {
+			printer.write("-> ", expression.ReturnType.Name, " ")
+		}
+		printer.block(expression.Body)
+	case *ast.CallExpression:
+		printer.expression(expression.Function, parser.CALL)
+		printer.write("(")
+		printer.list(expression.Arguments)
+		printer.write(")")
+	case *ast.ArrayLiteral:
+		printer.write("[")
+		printer.list(expression.Elements)
+		printer.write("]")
+	case *ast.HashLiteral:
+		printer.write("{")
+		for i, key := range expression.Keys {
+			if i > 0 {
+				printer.write(", ")
+			}
+			printer.expression(key, parser.LOWEST)
+			printer.write(": ")
+			printer.expression(expression.Values[i], parser.LOWEST)
+		}
+		printer.write("}")
+	case *ast.IndexExpression:
+		printer.expression(expression.Left, parser.INDEX)
+		printer.write(expression.Token.Literal)
+		printer.expression(expression.Index, parser.LOWEST)
+		printer.write("]")
+	case *ast.ImportExpression:
+		printer.write(`import "`, expression.Path.Value, `"`)
+	case *ast.MemberExpression:
+		printer.expression(expression.Object, parser.CALL)
+		printer.write(expression.Token.Literal, expression.Property.Value)
+	default:
+		printer.write(expression.String())
+	}
+}
+
+// pattern - Writes a name or a destructuring pattern
+func (printer *printer) pattern(pattern ast.Pattern) {
+	var elements []*ast.PatternElement
+	var rest *ast.Identifier
+	var open, close string
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		printer.write(pattern.Value)
+		return
+	case *ast.ArrayPattern:
+		elements, rest, open, close = pattern.Elements, pattern.Rest, "[", "]"
+	case *ast.HashPattern:
+		elements, rest, open, close = pattern.Elements, pattern.Rest, "{", "}"
+	}
+
+	printer.write(open)
+	for i, element := range elements {
+		if i > 0 {
+			printer.write(", ")
+		}
+		if !element.Shorthand() {
+			printer.write(element.KeyString(), ": ")
+		}
+		printer.pattern(element.Pattern)
+		if element.Default != nil {
+			printer.write(" = ")
+			printer.expression(element.Default, parser.LOWEST)
+		}
+	}
+	if rest != nil {
+		if len(elements) > 0 {
+			printer.write(", ")
+		}
+		printer.write("...", rest.Value)
+	}
+	printer.write(close)
+}
+
+// list - Writes expressions separated by commas
+func (printer *printer) list(expressions []ast.Expression) {
+	for i, expression := range expressions {
+		if i > 0 {
+			printer.write(", ")
+		}
+		printer.expression(expression, parser.LOWEST)
+	}
+}
+
+func (printer *printer) parenthesize(parenthesize bool, write func()) {
+	if parenthesize {
+		printer.write("(")
+	}
+	write()
+	if parenthesize {
+		printer.write(")")
+	}
+}
+
+// firstLine - The line of statement or of the first comment written before it
+func (printer *printer) firstLine(statement ast.Statement) int {
+	if printer.hasCommentBefore(statement.Line()) {
+		return printer.comments[0].Line
+	}
+	return statement.Line()
+}
+
+func (printer *printer) hasCommentBefore(line int) bool {
+	return len(printer.comments) > 0 && printer.comments[0].Line < line
+}
+
+// commentsBefore - Writes the comments before line, each on its own line. They are followed by a newline
+// when before is true (ie. when something is written after them on the same indentation) and preceded by
+// one otherwise
+func (printer *printer) commentsBefore(line int, before bool) {
+	for printer.hasCommentBefore(line) {
+		if !before {
+			printer.newline()
+		}
+		printer.write("//", printer.comments[0].Literal)
+		if before {
+			printer.newline()
+		}
+		printer.comments = printer.comments[1:]
+	}
+}
+
+// EndLine - The last source line node spans
+func EndLine(node ast.Node) int {
+	line := 0
+	ast.Walk(node, func(node ast.Node) bool {
+		if node.Line() > line {
+			line = node.Line()
+		}
+		if block, ok := node.(*ast.BlockStatement); ok && block.EndToken.Line > line {
+			line = block.EndToken.Line
+		}
+		if match, ok := node.(*ast.MatchExpression); ok && match.EndToken.Line > line {
+			line = match.EndToken.Line
+		}
+		return true
+	})
+	return line
+}
@@ -0,0 +1,91 @@
+package format
+
+import (
+	"monkeylang/ast"
+	"monkeylang/lexer"
+	"monkeylang/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		t.Fatalf("Parser errors for %q: %v", input, parser.Errors())
+	}
+	return program
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let   x=5", "let x = 5;\n"},
+		{"1+2*3", "1 + 2 * 3;\n"},
+		{"(1+2)*3", "(1 + 2) * 3;\n"},
+		{"1-(2-3)", "1 - (2 - 3);\n"},
+		{"(1-2)-3", "1 - 2 - 3;\n"},
+		{"-(1+2)", "-(1 + 2);\n"},
+		{"!!true", "!!true;\n"},
+		{`"a b"`, "\"a b\";\n"},
+		{"add(1,2*3)", "add(1, 2 * 3);\n"},
+		{"fn(x){x}(5)", "fn(x) {\n\tx;\n}(5);\n"},
+		{"let f=fn(){}", "let f = fn() {};\n"},
+		{"let f:fn=fn(a:int,b)->string{b}", "let f: fn = fn(a: int, b) -> string {\n\tb;\n};\n"},
+		{"let m=macro(x){quote(unquote(x)*2)}", "let m = macro(x) {\n\tquote(unquote(x) * 2);\n};\n"},
+		{"export  let x=import \"./a\" . b", "export let x = import \"./a\".b;\n"},
+		{"(-m).f(1).g", "(-m).f(1).g;\n"},
+		{`[1,2*3][0]+{"a":[x],1:2}["a"][0]`, "[1, 2 * 3][0] + {\"a\": [x], 1: 2}[\"a\"][0];\n"},
+		{"(-a)[1]", "(-a)[1];\n"},
+		{"let x=a?.b?[0]??null", "let x = a?.b?[0] ?? null;\n"},
+		{"(a??b)+1", "(a ?? b) + 1;\n"},
+		{"let [a,[b,c]=[1,2],...r]=xs", "let [a, [b, c] = [1, 2], ...r] = xs;\n"},
+		{"let f=fn({name:n,age=1+1,\"x y\":z,...o}){n}", "let f = fn({name: n, age = 1 + 1, \"x y\": z, ...o}) {\n\tn;\n};\n"},
+		{
+			"match(x){0=>null,[a,_] if a>1=>a*2,_=>{\"k\":x}}",
+			"match (x) {\n\t0 => null,\n\t[a, _] if a > 1 => a * 2,\n\t_ => {\"k\": x},\n}\n",
+		},
+		{
+			"if(x<y){return x}else{y}",
+			"if (x < y) {\n\treturn x;\n} else {\n\ty;\n}\n",
+		},
+		{
+			"try{f()}catch(e){throw e}finally{close()}",
+			"try {\n\tf();\n} catch (e) {\n\tthrow e;\n} finally {\n\tclose();\n}\n",
+		},
+		{"let x=try{f()}catch{raise error(\"x\")}", "let x = try {\n\tf();\n} catch {\n\traise error(\"x\");\n};\n"},
+		{
+			"let add = fn(a, b) { let c = a + b;\n\n c };\nlet x = 1;\n\n\n\nadd(x, 2)",
+			"let add = fn(a, b) {\n\tlet c = a + b;\n\n\tc;\n};\nlet x = 1;\n\nadd(x, 2);\n",
+		},
+		{
+			"// Adds\nlet add=fn(a,b){ // the sum\n a+b // done\n // end\n};\n\n// call\nadd(1,2) // 3\n// last",
+			"// Adds\nlet add = fn(a, b) {\n\t// the sum\n\ta + b; // done\n\t// end\n};\n\n// call\nadd(1, 2); // 3\n// last\n",
+		},
+		{"let f=fn(){ // nothing yet\n}", "let f = fn() {\n\t// nothing yet\n};\n"},
+	}
+
+	for _, test := range tests {
+		formatted := Source(parse(t, test.input))
+		if formatted != test.expected {
+			t.Errorf("Source(%q) is incorrect. Expected: %q. Got: %q", test.input, test.expected, formatted)
+			continue
+		}
+
+		// Formatting must not change the meaning of the program
+		original, reparsed := parse(t, test.input).String(), parse(t, formatted).String()
+		if original != reparsed {
+			t.Errorf("Formatting %q changed the program. Expected: %q. Got: %q", test.input, original, reparsed)
+		}
+	}
+}
+
+func TestNode(t *testing.T) {
+	program := parse(t, "let f = fn(x) { x * (2 + x) };")
+	let := program.Statements[0].(*ast.LetStatement)
+
+	if formatted := Node(let.Value); formatted != "fn(x) {\n\tx * (2 + x);\n}" {
+		t.Errorf("Node(%s) is incorrect. Got: %q", let.Value.String(), formatted)
+	}
+}
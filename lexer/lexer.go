@@ -1,6 +1,9 @@
 package lexer
 
-import "monkeylang/token"
+import (
+	"monkeylang/token"
+	"strings"
+)
 
 // TODO: Instead of a string we should use store an io.Reader and the filename. That way we can read a file and
 // not load the entire script into memory. This would also allow for better error handling (right now we have pretty much nothing)
@@ -11,11 +14,14 @@ type Lexer struct {
 	position     int  // points to ch byte in the input string
 	readPosition int  // points to the next character in the input string
 	char         byte // current character
+	line         int  // line of the current character
+	column       int  // column of the current character
+	comments     []token.Token
 }
 
 // New - Creates new lexer pointer
 func New(input string) *Lexer {
-	lexer := &Lexer{input: input}
+	lexer := &Lexer{input: input, line: 1}
 	lexer.inititalizePointers()
 	return lexer
 }
@@ -27,6 +33,8 @@ func (lexer *Lexer) NextToken() token.Token {
 
 	lexer.skipWhitespace()
 
+	line, column := lexer.line, lexer.column
+
 	switch lexer.char {
 	case '!':
 		if lexer.peekChar() == '=' {
@@ -43,13 +51,21 @@ func (lexer *Lexer) NextToken() token.Token {
 			lexer.readChar()
 			literal := string(firstChar) + string(lexer.char)
 			tok = token.Token{Type: token.EQUAL, Literal: literal}
+		} else if lexer.peekChar() == '>' {
+			lexer.readChar()
+			tok = token.Token{Type: token.ARROW, Literal: "=>"}
 		} else {
 			tok = token.NewToken(token.ASSIGN, lexer.char)
 		}
 	case '+':
 		tok = token.NewToken(token.PLUS, lexer.char)
 	case '-':
-		tok = token.NewToken(token.MINUS, lexer.char)
+		if lexer.peekChar() == '>' {
+			lexer.readChar()
+			tok = token.Token{Type: token.RARROW, Literal: "->"}
+		} else {
+			tok = token.NewToken(token.MINUS, lexer.char)
+		}
 	case '*':
 		tok = token.NewToken(token.STAR, lexer.char)
 	case '/':
@@ -58,10 +74,39 @@ func (lexer *Lexer) NextToken() token.Token {
 		tok = token.NewToken(token.LESS, lexer.char)
 	case '>':
 		tok = token.NewToken(token.GREATER, lexer.char)
+	case '?':
+		// A lone ? is not an operator
+		switch lexer.peekChar() {
+		case '?':
+			lexer.readChar()
+			tok = token.Token{Type: token.NULLISH, Literal: "??"}
+		case '.':
+			lexer.readChar()
+			tok = token.Token{Type: token.QUESTION_DOT, Literal: "?."}
+		case '[':
+			lexer.readChar()
+			tok = token.Token{Type: token.QUESTION_BRACKET, Literal: "?["}
+		default:
+			tok = token.NewToken(token.ILLEGAL, lexer.char)
+		}
 	case ',':
 		tok = token.NewToken(token.COMMA, lexer.char)
 	case ';':
 		tok = token.NewToken(token.SEMICOLON, lexer.char)
+	case '.':
+		if lexer.peekChar() == '.' && lexer.readPosition+1 < len(lexer.input) && lexer.input[lexer.readPosition+1] == '.' {
+			lexer.readChar()
+			lexer.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = token.NewToken(token.DOT, lexer.char)
+		}
+	case ':':
+		tok = token.NewToken(token.COLON, lexer.char)
+	case '[':
+		tok = token.NewToken(token.LBRACKET, lexer.char)
+	case ']':
+		tok = token.NewToken(token.RBRACKET, lexer.char)
 	case '(':
 		tok = token.NewToken(token.LPAREN, lexer.char)
 	case ')':
@@ -80,16 +125,19 @@ func (lexer *Lexer) NextToken() token.Token {
 		if isLetter(lexer.char) {
 			tok.Literal = lexer.readIdentifier()
 			tok.Type = token.LookUpIdentifier(tok.Literal)
+			tok.Line, tok.Column = line, column
 			return tok
 		}
 		if isDigit(lexer.char) {
 			tok.Literal = lexer.readNumber()
 			tok.Type = token.INT
+			tok.Line, tok.Column = line, column
 			return tok
 		}
 		tok = token.NewToken(token.ILLEGAL, lexer.char)
 	}
 	lexer.readChar()
+	tok.Line, tok.Column = line, column
 	return tok
 }
 
@@ -98,6 +146,11 @@ func (lexer *Lexer) inititalizePointers() {
 }
 
 func (lexer *Lexer) readChar() {
+	if lexer.char == '\n' {
+		lexer.line++
+		lexer.column = 0
+	}
+
 	if lexer.readPosition >= len(lexer.input) {
 		lexer.char = 0
 	} else {
@@ -105,6 +158,7 @@ func (lexer *Lexer) readChar() {
 	}
 	lexer.position = lexer.readPosition
 	lexer.readPosition++
+	lexer.column++
 }
 
 func (lexer *Lexer) peekChar() byte {
@@ -142,10 +196,34 @@ func (lexer *Lexer) readString() string {
 	return lexer.input[startPosition:lexer.position]
 }
 
+// skipWhitespace - Skips whitespace and comments, keeping the comments for Comments
 func (lexer *Lexer) skipWhitespace() {
-	for lexer.char == ' ' || lexer.char == '\t' || lexer.char == '\n' || lexer.char == '\r' {
+	for {
+		switch {
+		case lexer.char == ' ' || lexer.char == '\t' || lexer.char == '\n' || lexer.char == '\r':
+			lexer.readChar()
+		case lexer.char == '/' && lexer.peekChar() == '/':
+			lexer.readComment()
+		default:
+			return
+		}
+	}
+}
+
+// readComment - Reads a comment up to the end of the line. Its literal is the text after "//"
+func (lexer *Lexer) readComment() {
+	comment := token.Token{Type: token.COMMENT, Line: lexer.line, Column: lexer.column}
+	startPosition := lexer.position + 2
+	for lexer.char != '\n' && lexer.char != 0 {
 		lexer.readChar()
 	}
+	comment.Literal = strings.TrimRight(lexer.input[startPosition:lexer.position], "\r")
+	lexer.comments = append(lexer.comments, comment)
+}
+
+// Comments - The comments read so far, in source order
+func (lexer *Lexer) Comments() []token.Token {
+	return lexer.comments
 }
 
 func isLetter(char byte) bool {
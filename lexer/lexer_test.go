@@ -135,3 +135,119 @@ func TestNextToken(t *testing.T) {
 	}
 
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  x + \"a b\";"
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"let", 1, 1},
+		{"x", 1, 5},
+		{"=", 1, 7},
+		{"5", 1, 9},
+		{";", 1, 10},
+		{"x", 2, 3},
+		{"+", 2, 5},
+		{"a b", 2, 7},
+		{";", 2, 12},
+	}
+
+	lexer := New(input)
+
+	for i, test := range tests {
+		currentToken := lexer.NextToken()
+
+		if currentToken.Literal != test.expectedLiteral {
+			t.Fatalf("Tests[%d] - incorrect Literal. Expected: %q, got: %q",
+				i, test.expectedLiteral, currentToken.Literal)
+		}
+
+		if currentToken.Line != test.expectedLine || currentToken.Column != test.expectedColumn {
+			t.Fatalf("Tests[%d] - incorrect position. Expected: %d:%d, got: %d:%d",
+				i, test.expectedLine, test.expectedColumn, currentToken.Line, currentToken.Column)
+		}
+	}
+}
+
+func TestNullSafeOperators(t *testing.T) {
+	input := "a ?? null; a?.b?[0] ? c ... ."
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.NULLISH, "??"},
+		{token.NULL, "null"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "a"},
+		{token.QUESTION_DOT, "?."},
+		{token.IDENT, "b"},
+		{token.QUESTION_BRACKET, "?["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.ILLEGAL, "?"},
+		{token.IDENT, "c"},
+		{token.ELLIPSIS, "..."},
+		{token.DOT, "."},
+		{token.EOF, ""},
+	}
+
+	lexer := New(input)
+
+	for i, test := range tests {
+		currentToken := lexer.NextToken()
+
+		if currentToken.Type != test.expectedType || currentToken.Literal != test.expectedLiteral {
+			t.Fatalf("Tests[%d] - incorrect token. Expected: %s %q, got: %s %q",
+				i, test.expectedType, test.expectedLiteral, currentToken.Type, currentToken.Literal)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := "// first\nlet x = 10 / 2; // lint:ignore unused-variable\n//"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "10"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	lexer := New(input)
+
+	for i, test := range tests {
+		currentToken := lexer.NextToken()
+
+		if currentToken.Type != test.expectedType || currentToken.Literal != test.expectedLiteral {
+			t.Fatalf("Tests[%d] - incorrect token. Expected: %s %q, got: %s %q",
+				i, test.expectedType, test.expectedLiteral, currentToken.Type, currentToken.Literal)
+		}
+	}
+
+	expected := []token.Token{
+		{Type: token.COMMENT, Literal: " first", Line: 1, Column: 1},
+		{Type: token.COMMENT, Literal: " lint:ignore unused-variable", Line: 2, Column: 17},
+		{Type: token.COMMENT, Literal: "", Line: 3, Column: 1},
+	}
+	comments := lexer.Comments()
+	if len(comments) != len(expected) {
+		t.Fatalf("Wrong number of comments. Expected: %d, got: %d", len(expected), len(comments))
+	}
+	for i, comment := range comments {
+		if comment != expected[i] {
+			t.Errorf("Comments[%d] is incorrect. Expected: %+v, got: %+v", i, expected[i], comment)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package lint
+
+import (
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/resolver"
+	"monkeylang/tester"
+	"sort"
+	"strings"
+)
+
+// The IDs of the rules, used in reports and to suppress problems
+const (
+	UNUSED_VARIABLE     = "unused-variable"
+	UNUSED_PARAMETER    = "unused-parameter"
+	SHADOWED_VARIABLE   = "shadowed-variable"
+	UNREACHABLE_CODE    = "unreachable-code"
+	CONSTANT_COMPARISON = "constant-comparison"
+	WRONG_ARITY         = "wrong-arity"
+	SELF_ASSIGNMENT     = "self-assignment"
+)
+
+// IGNORE - Starts a comment suppressing the problems of its line and of the line after it. It is followed
+// by the IDs of the rules to suppress, separated by commas or spaces, or by nothing to suppress all of them
+const IGNORE = "lint:ignore"
+
+// Problem - Something suspicious found by a rule at a position of the program
+type Problem struct {
+	Rule    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// linter - The state of the rules checking one program
+type linter struct {
+	resolution *resolver.Resolution
+	exported   map[*ast.Identifier]bool // the names declared by export let statements and test functions
+	problems   []Problem
+}
+
+// Lint - The problems the rules find in program, ordered by position. Names starting with "_" are never
+// reported as unused or shadowed
+func Lint(program *ast.Program) []Problem {
+	return LintFile("", program)
+}
+
+// LintFile - Like Lint for program read from the file name. The test functions of test files (named with
+// tester.FILE_SUFFIX) are run by `monkey test`, so they are not reported as unused
+func LintFile(name string, program *ast.Program) []Problem {
+	linter := &linter{
+		resolution: resolver.Resolve(program, evaluator.BuiltinNames()),
+		exported:   make(map[*ast.Identifier]bool),
+	}
+	if strings.HasSuffix(name, tester.FILE_SUFFIX) {
+		linter.tests()
+	}
+
+	linter.bindings()
+	ast.Walk(program, func(node ast.Node) bool {
+		linter.node(node)
+		return true
+	})
+
+	problems := suppress(linter.problems, program)
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+	return problems
+}
+
+func (linter *linter) report(rule string, node ast.Node, message string) {
+	linter.problems = append(linter.problems, Problem{Rule: rule, Line: node.Line(), Column: node.Column(), Message: message})
+}
+
+// suppress - problems without those suppressed by the lint:ignore comments of program
+func suppress(problems []Problem, program *ast.Program) []Problem {
+	ignored := make(map[int][]string) // lines to the rules ignored on them. "" ignores every rule
+	for _, comment := range program.Comments {
+		text := strings.TrimSpace(comment.Literal)
+		if !strings.HasPrefix(text, IGNORE) {
+			continue
+		}
+		rules := strings.FieldsFunc(strings.TrimPrefix(text, IGNORE), func(char rune) bool {
+			return char == ',' || char == ' ' || char == '\t'
+		})
+		if len(rules) == 0 {
+			rules = []string{""}
+		}
+		ignored[comment.Line] = append(ignored[comment.Line], rules...)
+		ignored[comment.Line+1] = append(ignored[comment.Line+1], rules...)
+	}
+
+	kept := []Problem{}
+	for _, problem := range problems {
+		if !isIgnored(ignored[problem.Line], problem.Rule) {
+			kept = append(kept, problem)
+		}
+	}
+	return kept
+}
+
+func isIgnored(ignored []string, rule string) bool {
+	for _, ignoredRule := range ignored {
+		if ignoredRule == "" || ignoredRule == rule {
+			return true
+		}
+	}
+	return false
+}
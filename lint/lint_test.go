@@ -0,0 +1,99 @@
+package lint
+
+import (
+	"fmt"
+	"monkeylang/lexer"
+	"monkeylang/parser"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // "<line>:<column> <rule>: <message>" for each problem
+	}{
+		{"let x = 1; x", []string{}},
+		{"let x = 1;", []string{"1:5 unused-variable: x is never used"}},
+		{"let _x = 1; export let y = 2;", []string{}},
+		{"let [a, b] = [1, 2]; a", []string{"1:9 unused-variable: b is never used"}},
+		{"let f = fn(a, _b) { 1 }; f(1, 2)", []string{"1:12 unused-parameter: Parameter a is never used"}},
+		{"let x = 1; let f = fn(x) { x }; f(x)", []string{"1:23 shadowed-variable: x shadows the let declared on line 1"}},
+		{"let f = fn(n) { fn(n) { n } }; f(1)", []string{
+			"1:12 unused-parameter: Parameter n is never used",
+			"1:20 shadowed-variable: n shadows the parameter declared on line 1",
+		}},
+		{"let len = fn(x) { x }; len(1)", []string{"1:5 shadowed-variable: len shadows the builtin len"}},
+		{"let f = fn() {\n return 1;\n puts(2);\n};\nf()", []string{"3:2 unreachable-code: Unreachable code after return on line 2"}},
+		{"throw \"x\"; 1; 2", []string{"1:12 unreachable-code: Unreachable code after throw on line 1"}},
+		{"let x = 1; [1 < 2, x == x, null != 1, true == false, x > x]", []string{
+			"1:15 constant-comparison: 1 < 2 is always true",
+			"1:22 constant-comparison: x == x is always true",
+			"1:33 constant-comparison: null != 1 is always true",
+			"1:44 constant-comparison: true == false is always false",
+			"1:56 constant-comparison: x > x is always false",
+		}},
+		{"let f = fn() { 1 }; [f() == f(), 1 + 1 == 1 + 1, 1 + 2]", []string{"1:40 constant-comparison: 1 + 1 == 1 + 1 is always true"}},
+		{"let s = \"a\"; let f = fn(x) { x }; [s == s, f == f, {} == {}, f(1) == f(1)]", []string{}},
+		{"let b = !true; let n = -1 * 2; let f = fn(x) { [x == x, b == b, b < b, n < n, null == null] }; f(1)", []string{
+			"1:59 constant-comparison: b == b is always true",
+			"1:74 constant-comparison: n < n is always false",
+			"1:84 constant-comparison: null == null is always true",
+		}},
+		{"let f = fn(a, b) { a + b }; f(1)", []string{"1:29 wrong-arity: Wrong number of arguments to f. Expected: 2, Got: 1"}},
+		{"len(1, 2); error(); puts(1, 2, 3)", []string{
+			"1:1 wrong-arity: Wrong number of arguments to len. Expected: 1, Got: 2",
+			"1:12 wrong-arity: Wrong number of arguments to error. Expected: 1 to 2, Got: 0",
+		}},
+		{"let f = fn(a) { a }; let f = fn(a, b) { a + b }; f(1, 2)", []string{"1:5 unused-variable: f is never used"}},
+		{"let x = 1; let x = x; x", []string{"1:16 self-assignment: x is assigned to itself"}},
+
+		{"let x = 1; // lint:ignore unused-variable", []string{}},
+		{"// lint:ignore\nlet x = x;", []string{}},
+		{"// lint:ignore self-assignment, unused-variable\nlet x = x;", []string{}},
+		{"// lint:ignore self-assignment\nlet x = x;", []string{"2:5 unused-variable: x is never used"}},
+		{"// lint:ignore unused-variable\n\nlet x = 1;", []string{"3:5 unused-variable: x is never used"}},
+	}
+
+	for _, test := range tests {
+		parser := parser.New(lexer.New(test.input))
+		program := parser.ParseProgram()
+		if len(parser.Errors()) != 0 {
+			t.Fatalf("Parser errors for %q: %v", test.input, parser.Errors())
+		}
+
+		problems := Lint(program)
+		if len(problems) != len(test.expected) {
+			t.Errorf("Wrong number of problems in %q. Expected: %d. Got: %d %v", test.input, len(test.expected), len(problems), problems)
+			continue
+		}
+		for i, problem := range problems {
+			got := fmt.Sprintf("%d:%d %s: %s", problem.Line, problem.Column, problem.Rule, problem.Message)
+			if got != test.expected[i] {
+				t.Errorf("Problem %d in %q is incorrect. Expected: %s. Got: %s", i, test.input, test.expected[i], got)
+			}
+		}
+	}
+}
+
+func TestLintFile(t *testing.T) {
+	source := "let test_sum = fn() { 1 }; let test_helper = fn(x) { x }; let helper = fn() { 1 };"
+	program := parser.New(lexer.New(source)).ParseProgram()
+
+	tests := []struct {
+		file     string
+		expected []string
+	}{
+		{"sum.mk", []string{"test_sum is never used", "test_helper is never used", "helper is never used"}},
+		{"sum_test.mk", []string{"test_helper is never used", "helper is never used"}},
+	}
+
+	for _, test := range tests {
+		messages := []string{}
+		for _, problem := range LintFile(test.file, program) {
+			messages = append(messages, problem.Message)
+		}
+		if fmt.Sprint(messages) != fmt.Sprint(test.expected) {
+			t.Errorf("Problems in %s are incorrect. Expected: %q. Got: %q", test.file, test.expected, messages)
+		}
+	}
+}
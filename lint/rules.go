@@ -0,0 +1,289 @@
+package lint
+
+import (
+	"fmt"
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/format"
+	"monkeylang/object"
+	"monkeylang/resolver"
+	"monkeylang/tester"
+	"strings"
+)
+
+// bindings - Reports unused lets and parameters, and bindings shadowing a name of an enclosing function
+// (or a builtin)
+func (linter *linter) bindings() {
+	ast.Walk(linter.resolution.Program, func(node ast.Node) bool {
+		if let, ok := node.(*ast.LetStatement); ok && let.Exported {
+			for _, name := range letNames(let) {
+				linter.exported[name] = true
+			}
+		}
+		return true
+	})
+
+	for _, binding := range linter.resolution.Bindings {
+		if binding.Declaration == nil || strings.HasPrefix(binding.Name, "_") {
+			continue
+		}
+
+		if len(binding.References) == 0 {
+			switch {
+			case binding.Kind == resolver.Let && !linter.exported[binding.Declaration]:
+				linter.report(UNUSED_VARIABLE, binding.Declaration, fmt.Sprintf("%s is never used", binding.Name))
+			case binding.Kind == resolver.Parameter:
+				linter.report(UNUSED_PARAMETER, binding.Declaration, fmt.Sprintf("Parameter %s is never used", binding.Name))
+			}
+		}
+
+		if shadowed := enclosingBinding(binding); shadowed != nil {
+			linter.report(SHADOWED_VARIABLE, binding.Declaration, fmt.Sprintf("%s shadows the %s declared on line %d", binding.Name, shadowed.Kind, shadowed.Declaration.Line()))
+		} else if isBuiltinName(binding.Name) {
+			linter.report(SHADOWED_VARIABLE, binding.Declaration, fmt.Sprintf("%s shadows the builtin %s", binding.Name, binding.Name))
+		}
+	}
+}
+
+// tests - Marks the test functions of a test file as exported, since they are called by the test runner
+func (linter *linter) tests() {
+	lines := map[string]int{} // test names to the line of the declaration that is run
+	for _, test := range tester.Tests(linter.resolution.Program) {
+		lines[test.Name] = test.Line
+	}
+	for _, statement := range linter.resolution.Program.Statements {
+		if let, ok := statement.(*ast.LetStatement); ok && let.Name != nil && lines[let.Name.Value] == let.Line() {
+			linter.exported[let.Name] = true
+		}
+	}
+}
+
+// enclosingBinding - A binding of the same name as binding in a scope enclosing binding's
+func enclosingBinding(binding *resolver.Binding) *resolver.Binding {
+	for scope := binding.Scope.Parent; scope != nil; scope = scope.Parent {
+		for _, enclosing := range scope.Bindings {
+			if enclosing.Name == binding.Name {
+				return enclosing
+			}
+		}
+	}
+	return nil
+}
+
+func isBuiltinName(name string) bool {
+	for _, builtin := range evaluator.BuiltinNames() {
+		if builtin == name {
+			return true
+		}
+	}
+	return false
+}
+
+// letNames - The names a let statement declares
+func letNames(let *ast.LetStatement) []*ast.Identifier {
+	if let.Pattern != nil {
+		return ast.PatternNames(let.Pattern)
+	}
+	return []*ast.Identifier{let.Name}
+}
+
+// node - Runs the rules that check single nodes
+func (linter *linter) node(node ast.Node) {
+	switch node := node.(type) {
+	case *ast.Program:
+		linter.unreachable(node.Statements)
+	case *ast.BlockStatement:
+		linter.unreachable(node.Statements)
+	case *ast.InfixExpression:
+		linter.comparison(node)
+	case *ast.CallExpression:
+		linter.arity(node)
+	case *ast.LetStatement:
+		if value, ok := node.Value.(*ast.Identifier); ok && node.Name != nil && value.Value == node.Name.Value {
+			linter.report(SELF_ASSIGNMENT, node.Name, fmt.Sprintf("%s is assigned to itself", node.Name.Value))
+		}
+	}
+}
+
+// unreachable - Reports the first of statements following a return or a throw
+func (linter *linter) unreachable(statements []ast.Statement) {
+	for i := 0; i < len(statements)-1; i++ {
+		exit := ""
+		switch statement := statements[i].(type) {
+		case *ast.ReturnStatement:
+			exit = "return"
+		case *ast.ExpressionStatement:
+			if throw, ok := statement.Expression.(*ast.ThrowExpression); ok {
+				exit = throw.Token.Literal
+			}
+		}
+		if exit != "" {
+			linter.report(UNREACHABLE_CODE, statements[i+1], fmt.Sprintf("Unreachable code after %s on line %d", exit, statements[i].Line()))
+			return
+		}
+	}
+}
+
+// comparison - Reports comparisons of constants, and of an expression with itself
+func (linter *linter) comparison(infix *ast.InfixExpression) {
+	switch infix.Operator {
+	case "==", "!=", "<", ">":
+	default:
+		return
+	}
+
+	result, constant := compareConstants(infix.Operator, infix.Left, infix.Right)
+	if !constant && isPure(infix.Left) && infix.Left.String() == infix.Right.String() {
+		// Other values (eg. strings and functions) cannot be compared, so the comparison raises an error
+		switch linter.scalarType(infix.Left, map[*resolver.Binding]bool{}) {
+		case object.INTEGER_OBJ:
+			result, constant = infix.Operator == "==", true
+		case object.BOOLEAN_OBJ, object.NULL_OBJ:
+			result, constant = infix.Operator == "==", infix.Operator == "==" || infix.Operator == "!="
+		}
+	}
+	if constant {
+		linter.report(CONSTANT_COMPARISON, infix, fmt.Sprintf("%s is always %t", format.Node(infix), result))
+	}
+}
+
+// compareConstants - The result of comparing two literals, when the evaluator compares them without an error
+func compareConstants(operator string, left ast.Expression, right ast.Expression) (result bool, ok bool) {
+	switch left := left.(type) {
+	case *ast.IntegerLiteral:
+		if right, isInteger := right.(*ast.IntegerLiteral); isInteger {
+			switch operator {
+			case "<":
+				return left.Value < right.Value, true
+			case ">":
+				return left.Value > right.Value, true
+			case "==":
+				return left.Value == right.Value, true
+			default:
+				return left.Value != right.Value, true
+			}
+		}
+	case *ast.BooleanLiteral:
+		if right, isBoolean := right.(*ast.BooleanLiteral); isBoolean && (operator == "==" || operator == "!=") {
+			return (left.Value == right.Value) == (operator == "=="), true
+		}
+	}
+
+	// Anything can be compared with null, which only equals itself
+	_, leftNull := left.(*ast.NullLiteral)
+	_, rightNull := right.(*ast.NullLiteral)
+	if (leftNull || rightNull) && isLiteral(left) && isLiteral(right) && (operator == "==" || operator == "!=") {
+		return (leftNull && rightNull) == (operator == "=="), true
+	}
+	return false, false
+}
+
+func isLiteral(expression ast.Expression) bool {
+	switch expression.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.BooleanLiteral, *ast.NullLiteral:
+		return true
+	default:
+		return false
+	}
+}
+
+// isPure - Whether evaluating expression twice gives the same value: it is made of names, literals and
+// operators, without calls
+func isPure(expression ast.Expression) bool {
+	pure := true
+	ast.Walk(expression, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.Identifier, *ast.IntegerLiteral, *ast.StringLiteral, *ast.BooleanLiteral, *ast.NullLiteral,
+			*ast.PrefixExpression, *ast.InfixExpression, *ast.MemberExpression, *ast.IndexExpression:
+		default:
+			pure = false
+		}
+		return pure
+	})
+	return pure
+}
+
+// scalarType - The type of the value of expression when it is known to be an integer, a boolean or null.
+// Names are followed to the let binding them, seen being the bindings already followed. "" otherwise
+func (linter *linter) scalarType(expression ast.Expression, seen map[*resolver.Binding]bool) object.ObjectType {
+	switch expression := expression.(type) {
+	case *ast.IntegerLiteral:
+		return object.INTEGER_OBJ
+	case *ast.BooleanLiteral:
+		return object.BOOLEAN_OBJ
+	case *ast.NullLiteral:
+		return object.NULL_OBJ
+	case *ast.PrefixExpression:
+		switch expression.Operator {
+		case "!":
+			return object.BOOLEAN_OBJ
+		case "-":
+			if linter.scalarType(expression.Right, seen) == object.INTEGER_OBJ {
+				return object.INTEGER_OBJ
+			}
+		}
+	case *ast.InfixExpression:
+		switch expression.Operator {
+		case "==", "!=", "<", ">":
+			return object.BOOLEAN_OBJ
+		case "+", "-", "*", "/":
+			if linter.scalarType(expression.Left, seen) == object.INTEGER_OBJ && linter.scalarType(expression.Right, seen) == object.INTEGER_OBJ {
+				return object.INTEGER_OBJ
+			}
+		}
+	case *ast.Identifier:
+		binding := linter.resolution.References[expression]
+		if binding == nil || binding.Kind != resolver.Let || binding.Value == nil || isRebound(binding) || seen[binding] {
+			return ""
+		}
+		seen[binding] = true
+		return linter.scalarType(binding.Value, seen)
+	}
+	return ""
+}
+
+// arity - Reports calls of builtins, and of functions bound once by a let, with the wrong number of arguments
+func (linter *linter) arity(call *ast.CallExpression) {
+	name, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return
+	}
+	binding := linter.resolution.References[name]
+	if binding == nil {
+		return
+	}
+
+	min, max := 0, 0
+	switch binding.Kind {
+	case resolver.Builtin:
+		if min, max, ok = evaluator.BuiltinArity(binding.Name); !ok {
+			return
+		}
+	case resolver.Let:
+		function, ok := binding.Value.(*ast.FunctionLiteral)
+		if !ok || function.IsMacro() || isRebound(binding) {
+			return
+		}
+		min, max = len(function.Parameters), len(function.Parameters)
+	default:
+		return
+	}
+
+	if count := len(call.Arguments); count < min || count > max {
+		expected := fmt.Sprint(min)
+		if min != max {
+			expected = fmt.Sprintf("%d to %d", min, max)
+		}
+		linter.report(WRONG_ARITY, call.Function, fmt.Sprintf("Wrong number of arguments to %s. Expected: %s, Got: %d", name.Value, expected, count))
+	}
+}
+
+// isRebound - Whether binding's name is bound more than once in its scope, so calls may not call its value
+func isRebound(binding *resolver.Binding) bool {
+	for _, other := range binding.Scope.Bindings {
+		if other != binding && other.Name == binding.Name {
+			return true
+		}
+	}
+	return false
+}
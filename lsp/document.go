@@ -0,0 +1,295 @@
+package lsp
+
+import (
+	"fmt"
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/format"
+	"monkeylang/lexer"
+	"monkeylang/parser"
+	"monkeylang/resolver"
+	"monkeylang/token"
+	"strings"
+)
+
+// document - An open text document and the result of analysing its current text
+type document struct {
+	uri        string
+	text       string
+	lines      []string
+	program    *ast.Program
+	errors     []parser.ParseError
+	warnings   []parser.ParseError
+	resolution *resolver.Resolution
+}
+
+func newDocument(uri string, text string) *document {
+	parser := parser.New(lexer.New(text))
+	program := parser.ParseProgram()
+
+	return &document{
+		uri:        uri,
+		text:       text,
+		lines:      strings.Split(text, "\n"),
+		program:    program,
+		errors:     parser.ParseErrors(),
+		warnings:   parser.Warnings(),
+		resolution: resolver.Resolve(program, evaluator.BuiltinNames()),
+	}
+}
+
+// diagnostics - Parser errors and warnings, and identifiers that do not refer to any binding
+func (document *document) diagnostics() []diagnostic {
+	diagnostics := []diagnostic{}
+	for _, parseError := range document.errors {
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    tokenRange(parseError.Token),
+			Severity: severityError,
+			Source:   "monkey",
+			Message:  parseError.Message,
+		})
+	}
+	for _, warning := range document.warnings {
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    tokenRange(warning.Token),
+			Severity: severityWarning,
+			Source:   "monkey",
+			Message:  warning.Message,
+		})
+	}
+
+	for _, identifier := range document.resolution.Unresolved {
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    identifierRange(identifier),
+			Severity: severityError,
+			Source:   "monkey",
+			Message:  "Unknown identifier: " + identifier.Value,
+		})
+	}
+	return diagnostics
+}
+
+// identifierAt - The identifier under (or just before) the cursor at pos
+func (document *document) identifierAt(pos position) *ast.Identifier {
+	line, column := pos.Line+1, pos.Character+1
+
+	var found *ast.Identifier
+	ast.Walk(document.program, func(node ast.Node) bool {
+		if identifier, ok := node.(*ast.Identifier); ok && identifier.Line() == line &&
+			identifier.Column() <= column && column <= identifier.Column()+len(identifier.Value) {
+			found = identifier
+		}
+		return found == nil
+	})
+	return found
+}
+
+// bindingAt - The binding the identifier at pos declares or refers to
+func (document *document) bindingAt(pos position) (*ast.Identifier, *resolver.Binding) {
+	identifier := document.identifierAt(pos)
+	if identifier == nil {
+		return nil, nil
+	}
+
+	if binding, ok := document.resolution.Declarations[identifier]; ok {
+		return identifier, binding
+	}
+	return identifier, document.resolution.References[identifier]
+}
+
+func (document *document) hover(pos position) interface{} {
+	identifier, binding := document.bindingAt(pos)
+	if binding == nil {
+		return nil
+	}
+
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: describe(binding)},
+		Range:    identifierRange(identifier),
+	}
+}
+
+func (document *document) definition(pos position) interface{} {
+	_, binding := document.bindingAt(pos)
+	if binding == nil || binding.Declaration == nil {
+		return nil
+	}
+	return location{URI: document.uri, Range: identifierRange(binding.Declaration)}
+}
+
+// symbols - The let bindings of the document. Bindings made inside a function are children of the
+// binding the function is assigned to
+func (document *document) symbols() []documentSymbol {
+	return document.statementSymbols(document.program.Statements)
+}
+
+func (document *document) statementSymbols(statements []ast.Statement) []documentSymbol {
+	symbols := []documentSymbol{}
+	for _, statement := range statements {
+		ast.Walk(statement, func(node ast.Node) bool {
+			let, ok := node.(*ast.LetStatement)
+			if !ok {
+				// Lets inside nested functions are collected by the function's own symbol
+				_, isFunction := node.(*ast.FunctionLiteral)
+				return !isFunction
+			}
+			if let.Pattern != nil {
+				for _, name := range ast.PatternNames(let.Pattern) {
+					symbols = append(symbols, documentSymbol{
+						Name:           name.Value,
+						Kind:           symbolVariable,
+						Range:          document.nodeRange(let),
+						SelectionRange: identifierRange(name),
+					})
+				}
+				return false
+			}
+			if let.Name == nil {
+				return false
+			}
+
+			symbol := documentSymbol{
+				Name:           let.Name.Value,
+				Kind:           symbolVariable,
+				Range:          document.nodeRange(let),
+				SelectionRange: identifierRange(let.Name),
+			}
+			if function, ok := let.Value.(*ast.FunctionLiteral); ok {
+				symbol.Kind = symbolFunction
+				symbol.Detail = signature(function)
+				if function.Body != nil {
+					symbol.Children = document.statementSymbols(function.Body.Statements)
+				}
+			}
+			symbols = append(symbols, symbol)
+			return false
+		})
+	}
+	return symbols
+}
+
+// completions - The names visible at pos (innermost first), then the builtins and keywords
+func (document *document) completions(pos position) []completionItem {
+	line, column := pos.Line+1, pos.Character+1
+	items := []completionItem{}
+	seen := make(map[string]bool)
+
+	add := func(item completionItem) {
+		if !seen[item.Label] {
+			seen[item.Label] = true
+			items = append(items, item)
+		}
+	}
+
+	innermost := document.resolution.ScopeAt(line, column)
+	for scope := innermost; scope != nil; scope = scope.Parent {
+		for i := len(scope.Bindings) - 1; i >= 0; i-- {
+			binding := scope.Bindings[i]
+			declaration := binding.Declaration
+			// Bindings of the innermost scope are only visible once they have been declared. Enclosing
+			// scopes are complete by the time a function body runs
+			if scope == innermost && (declaration.Line() > line || declaration.Line() == line && declaration.Column() >= column) {
+				continue
+			}
+
+			item := completionItem{Label: binding.Name, Kind: completionVariable, Detail: binding.Kind.String()}
+			if function, ok := binding.Value.(*ast.FunctionLiteral); ok {
+				item.Kind, item.Detail = completionFunction, signature(function)
+			}
+			add(item)
+		}
+	}
+
+	for _, name := range evaluator.BuiltinNames() {
+		add(completionItem{Label: name, Kind: completionFunction, Detail: "builtin"})
+	}
+	for _, keyword := range token.Keywords() {
+		add(completionItem{Label: keyword, Kind: completionKeyword})
+	}
+	return items
+}
+
+// formatting - An edit replacing the whole document with its formatted source. Documents that do not
+// parse are left alone
+func (document *document) formatting() []textEdit {
+	if len(document.errors) != 0 {
+		return []textEdit{}
+	}
+
+	formatted := format.Source(document.program)
+	if formatted == document.text {
+		return []textEdit{}
+	}
+
+	lastLine := len(document.lines) - 1
+	end := position{Line: lastLine, Character: len(document.lines[lastLine])}
+	return []textEdit{{Range: textRange{End: end}, NewText: formatted}}
+}
+
+// nodeRange - From the start of node to the end of the last line it spans
+func (document *document) nodeRange(node ast.Node) textRange {
+	endLine := format.EndLine(node)
+	end := position{Line: endLine - 1}
+	if endLine >= 1 && endLine <= len(document.lines) {
+		end.Character = len(document.lines[endLine-1])
+	}
+	return textRange{Start: position{Line: node.Line() - 1, Character: node.Column() - 1}, End: end}
+}
+
+// describe - Markdown shown when hovering over a name
+func describe(binding *resolver.Binding) string {
+	var code, detail string
+	switch binding.Kind {
+	case resolver.Let:
+		code = "let " + binding.Name
+		if function, ok := binding.Value.(*ast.FunctionLiteral); ok {
+			code += " = " + signature(function)
+		} else if value := format.Node(binding.Value); value != "" && !strings.Contains(value, "\n") {
+			code += " = " + value
+		}
+		detail = fmt.Sprintf("Declared on line %d", binding.Declaration.Line())
+	case resolver.Parameter:
+		code = "(parameter) " + binding.Name
+		detail = fmt.Sprintf("Parameter of `%s` declared on line %d", signature(binding.Function), binding.Declaration.Line())
+	case resolver.CatchParameter:
+		code = "(catch parameter) " + binding.Name
+		detail = fmt.Sprintf("Error caught on line %d", binding.Declaration.Line())
+	case resolver.PatternBinding:
+		code = "(pattern binding) " + binding.Name
+		detail = fmt.Sprintf("Bound by the match arm on line %d", binding.Declaration.Line())
+	case resolver.Builtin:
+		code = "(builtin) " + binding.Name
+		detail = "Builtin function"
+	}
+	return "```monkey\n" + code + "\n```\n" + detail
+}
+
+func signature(function *ast.FunctionLiteral) string {
+	parameters := []string{}
+	for i, parameter := range function.Parameters {
+		if parameterType := function.ParameterType(i); parameterType != nil {
+			parameters = append(parameters, parameter.String()+": "+parameterType.Name)
+		} else {
+			parameters = append(parameters, parameter.String())
+		}
+	}
+	signature := function.TokenLiteral() + "(" + strings.Join(parameters, ", ") + ")"
+	if function.ReturnType != nil {
+		signature += " -> " + function.ReturnType.Name
+	}
+	return signature
+}
+
+func tokenRange(tok token.Token) textRange {
+	start := position{Line: tok.Line - 1, Character: tok.Column - 1}
+	length := len(tok.Literal)
+	if length == 0 {
+		length = 1
+	}
+	return textRange{Start: start, End: position{Line: start.Line, Character: start.Character + length}}
+}
+
+func identifierRange(identifier *ast.Identifier) textRange {
+	start := position{Line: identifier.Line() - 1, Character: identifier.Column() - 1}
+	return textRange{Start: start, End: position{Line: start.Line, Character: start.Character + len(identifier.Value)}}
+}
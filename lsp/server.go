@@ -0,0 +1,171 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"monkeylang/protocol"
+)
+
+// Server - A Language Server Protocol server for MonkeyLang. It keeps every open document parsed and
+// resolved, publishes their diagnostics and answers hover, definition, symbol, completion and
+// formatting requests
+type Server struct {
+	reader    *bufio.Reader
+	writer    io.Writer
+	documents map[string]*document
+}
+
+// NewServer - Creates a server that reads messages from in and writes to out
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{reader: bufio.NewReader(in), writer: out, documents: make(map[string]*document)}
+}
+
+// Serve - Handles messages until the client sends exit or the input is closed
+func (server *Server) Serve() error {
+	for {
+		body, err := protocol.ReadMessage(server.reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, failure := server.handle(&req)
+		if req.ID == nil {
+			// Notifications have no response
+			continue
+		}
+		if failure != nil {
+			server.send(errorResponse{JSONRPC: "2.0", ID: req.ID, Error: *failure})
+		} else {
+			server.send(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+		}
+	}
+}
+
+// handle - Dispatches a request or notification and returns its result
+func (server *Server) handle(req *request) (interface{}, *responseError) {
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:           syncFull,
+				HoverProvider:              true,
+				DefinitionProvider:         true,
+				DocumentSymbolProvider:     true,
+				CompletionProvider:         completionOptions{},
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "monkey-lsp"},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		server.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		if changes := params.ContentChanges; len(changes) > 0 {
+			server.update(params.TextDocument.URI, changes[len(changes)-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalid(err)
+		}
+		delete(server.documents, params.TextDocument.URI)
+		server.publishDiagnostics(params.TextDocument.URI, []diagnostic{})
+		return nil, nil
+	case "textDocument/hover":
+		return server.atPosition(req, (*document).hover)
+	case "textDocument/definition":
+		return server.atPosition(req, (*document).definition)
+	case "textDocument/completion":
+		return server.atPosition(req, func(document *document, pos position) interface{} {
+			return document.completions(pos)
+		})
+	case "textDocument/documentSymbol":
+		document, err := server.document(req)
+		if err != nil {
+			return nil, err
+		}
+		return document.symbols(), nil
+	case "textDocument/formatting":
+		document, err := server.document(req)
+		if err != nil {
+			return nil, err
+		}
+		return document.formatting(), nil
+	default:
+		return nil, &responseError{Code: methodNotFound, Message: fmt.Sprintf("Unsupported method %q", req.Method)}
+	}
+}
+
+// update - Analyses the new text of a document and publishes its diagnostics
+func (server *Server) update(uri string, text string) {
+	document := newDocument(uri, text)
+	server.documents[uri] = document
+	server.publishDiagnostics(uri, document.diagnostics())
+}
+
+func (server *Server) publishDiagnostics(uri string, diagnostics []diagnostic) {
+	server.send(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics},
+	})
+}
+
+func (server *Server) document(req *request) (*document, *responseError) {
+	var params documentParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, invalid(err)
+	}
+
+	document, ok := server.documents[params.TextDocument.URI]
+	if !ok {
+		return nil, &responseError{Code: invalidParams, Message: fmt.Sprintf("Unknown document %q", params.TextDocument.URI)}
+	}
+	return document, nil
+}
+
+// atPosition - Answers a request about a position in a document with answer
+func (server *Server) atPosition(req *request, answer func(*document, position) interface{}) (interface{}, *responseError) {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, invalid(err)
+	}
+
+	document, err := server.document(req)
+	if err != nil {
+		return nil, err
+	}
+	return answer(document, params.Position), nil
+}
+
+func (server *Server) send(message interface{}) {
+	protocol.WriteMessage(server.writer, message)
+}
+
+func invalid(err error) *responseError {
+	return &responseError{Code: invalidParams, Message: err.Error()}
+}
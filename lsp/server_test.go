@@ -0,0 +1,226 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"monkeylang/protocol"
+	"strings"
+	"testing"
+)
+
+const uri = "file:///test.mk"
+
+const source = `let add = fn(x, y) {
+	let sum = x + y;
+	sum
+};
+let a = 1;
+add(a, b)`
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+// session - Sends every request to a server and returns the responses by id along with the notifications
+func session(t *testing.T, requests ...map[string]interface{}) (map[int]message, []message) {
+	var in, out bytes.Buffer
+	for _, req := range requests {
+		req["jsonrpc"] = "2.0"
+		if err := protocol.WriteMessage(&in, req); err != nil {
+			t.Fatalf("Could not write request: %s", err)
+		}
+	}
+
+	if err := NewServer(&in, &out).Serve(); err != nil {
+		t.Fatalf("Serve returned an error: %s", err)
+	}
+
+	responses := make(map[int]message)
+	notifications := []message{}
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := protocol.ReadMessage(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Could not read message: %s", err)
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("Could not decode %s: %s", body, err)
+		}
+		if msg.ID != nil {
+			responses[*msg.ID] = msg
+		} else {
+			notifications = append(notifications, msg)
+		}
+	}
+	return responses, notifications
+}
+
+func open(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"method": "textDocument/didOpen",
+		"params": map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri, "text": text}},
+	}
+}
+
+func at(id int, method string, line int, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"id":     id,
+		"method": method,
+		"params": map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+			"position":     map[string]interface{}{"line": line, "character": character},
+		},
+	}
+}
+
+func TestInitialize(t *testing.T) {
+	responses, _ := session(t, map[string]interface{}{"id": 1, "method": "initialize", "params": map[string]interface{}{}})
+
+	var result initializeResult
+	if err := json.Unmarshal(responses[1].Result, &result); err != nil {
+		t.Fatalf("Could not decode initialize result: %s", err)
+	}
+	if !result.Capabilities.HoverProvider || !result.Capabilities.DocumentFormattingProvider ||
+		result.Capabilities.TextDocumentSync != syncFull {
+		t.Errorf("Capabilities are incorrect. Got: %+v", result.Capabilities)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{source, []string{"5:7 Unknown identifier: b"}},
+		{"let x = ;", []string{"0:8 No prefix parse function found for tokentype: ;"}},
+		{"let a = 1;\nfoo(a)", []string{"1:0 Unknown identifier: foo"}},
+		{"let f = fn(x) { x", []string{"0:17 Expected token type }, got end of input instead"}},
+		{"match (1) {\n  n => n,\n  0 => 0\n}", []string{"2:2 Unreachable match arm: n on line 2 matches every value"}},
+	}
+
+	for _, test := range tests {
+		_, notifications := session(t, open(test.input))
+		if len(notifications) != 1 || notifications[0].Method != "textDocument/publishDiagnostics" {
+			t.Fatalf("Expected one publishDiagnostics notification. Got: %v", notifications)
+		}
+
+		var params publishDiagnosticsParams
+		json.Unmarshal(notifications[0].Params, &params)
+		got := []string{}
+		for _, diagnostic := range params.Diagnostics {
+			start := diagnostic.Range.Start
+			got = append(got, fmt.Sprintf("%d:%d %s", start.Line, start.Character, diagnostic.Message))
+		}
+		if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("Diagnostics for %q are incorrect. Expected: %q. Got: %q", test.input, test.expected, got)
+		}
+	}
+}
+
+func TestHoverAndDefinition(t *testing.T) {
+	responses, _ := session(t,
+		open(source),
+		at(1, "textDocument/hover", 2, 2),      // sum
+		at(2, "textDocument/definition", 2, 2), // sum
+		at(3, "textDocument/definition", 5, 5), // a
+		at(4, "textDocument/hover", 1, 12),     // x
+		at(5, "textDocument/hover", 5, 7),      // b, unresolved
+	)
+
+	var hovered hover
+	json.Unmarshal(responses[1].Result, &hovered)
+	if !strings.Contains(hovered.Contents.Value, "let sum = x + y") {
+		t.Errorf("Hover over sum is incorrect. Got: %q", hovered.Contents.Value)
+	}
+
+	definitions := map[int]position{2: {Line: 1, Character: 5}, 3: {Line: 4, Character: 4}}
+	for id, expected := range definitions {
+		var definition location
+		json.Unmarshal(responses[id].Result, &definition)
+		if definition.URI != uri || definition.Range.Start != expected {
+			t.Errorf("Definition %d is incorrect. Expected: %+v. Got: %+v", id, expected, definition)
+		}
+	}
+
+	json.Unmarshal(responses[4].Result, &hovered)
+	if !strings.Contains(hovered.Contents.Value, "(parameter) x") {
+		t.Errorf("Hover over parameter is incorrect. Got: %q", hovered.Contents.Value)
+	}
+
+	if string(responses[5].Result) != "null" {
+		t.Errorf("Hover over an unknown identifier should be null. Got: %s", responses[5].Result)
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	responses, _ := session(t, open(source), map[string]interface{}{
+		"id":     1,
+		"method": "textDocument/documentSymbol",
+		"params": map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri}},
+	})
+
+	var symbols []documentSymbol
+	json.Unmarshal(responses[1].Result, &symbols)
+	if len(symbols) != 2 || symbols[0].Name != "add" || symbols[1].Name != "a" {
+		t.Fatalf("Symbols are incorrect. Got: %+v", symbols)
+	}
+	if symbols[0].Kind != symbolFunction || symbols[0].Detail != "fn(x, y)" || symbols[0].Range.End.Line != 3 {
+		t.Errorf("Symbol add is incorrect. Got: %+v", symbols[0])
+	}
+	if len(symbols[0].Children) != 1 || symbols[0].Children[0].Name != "sum" {
+		t.Errorf("Children of add are incorrect. Got: %+v", symbols[0].Children)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	responses, _ := session(t, open(source), at(1, "textDocument/completion", 2, 1))
+
+	var items []completionItem
+	json.Unmarshal(responses[1].Result, &items)
+	labels := map[string]bool{}
+	for _, item := range items {
+		labels[item.Label] = true
+	}
+
+	for _, expected := range []string{"sum", "x", "y", "add", "a", "len", "let", "fn"} {
+		if !labels[expected] {
+			t.Errorf("Completions do not include %q", expected)
+		}
+	}
+	if items[0].Label != "sum" {
+		t.Errorf("Innermost binding should be offered first. Got: %q", items[0].Label)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	responses, _ := session(t, open("let   x=5\nx"), map[string]interface{}{
+		"id":     1,
+		"method": "textDocument/formatting",
+		"params": map[string]interface{}{"textDocument": map[string]interface{}{"uri": uri}},
+	})
+
+	var edits []textEdit
+	json.Unmarshal(responses[1].Result, &edits)
+	if len(edits) != 1 || edits[0].NewText != "let x = 5;\nx;\n" || edits[0].Range.End != (position{Line: 1, Character: 1}) {
+		t.Errorf("Formatting edits are incorrect. Got: %+v", edits)
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	responses, _ := session(t, map[string]interface{}{"id": 1, "method": "textDocument/rename"})
+	if responses[1].Error == nil || responses[1].Error.Code != methodNotFound {
+		t.Errorf("Expected a method not found error. Got: %+v", responses[1])
+	}
+}
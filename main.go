@@ -1,13 +1,71 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"monkeylang/ast"
+	"monkeylang/checker"
+	"monkeylang/coverage"
+	"monkeylang/dap"
+	"monkeylang/debugger"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/lint"
+	"monkeylang/lsp"
+	"monkeylang/module"
+	"monkeylang/object"
+	"monkeylang/optimizer"
+	"monkeylang/parser"
+	"monkeylang/profiler"
 	"monkeylang/repl"
+	"monkeylang/stdlib"
+	"monkeylang/tester"
+	"monkeylang/tracer"
 	"os"
 	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
+const USAGE = `Usage:
+  monkey                    start the REPL
+  monkey run [-path dirs] [-stdlib modules] [-optimize=false] [-profile file] [-top n] [-trace] [coverage flags] <script.mk> [args]
+                            run a script. Modules are looked up in the standard library (all of it
+                            unless -stdlib lists the modules to allow), dirs and MONKEY_PATH. Scripts
+                            and modules are optimized before they run unless -optimize=false. With
+                            -profile the time spent in each function and line is measured, the top n
+                            of them are reported on stderr and a pprof profile is written to file.
+                            -trace writes the statements, calls, bindings and errors to stderr
+  monkey lint <script.mk>... report suspicious code (see the rules in the lint package). A problem is
+                            suppressed by a "// lint:ignore rule-id" comment on its line or the line before
+  monkey check <script.mk>... report type errors before running, using the optional type annotations
+                            of lets (let x: int = 1), parameters and results (fn(s: string) -> int)
+  monkey test [-path dirs] [-stdlib modules] [-run regexp] [-v] [coverage flags] [files or dirs]
+                            run the test functions (top level "let test_name = fn() {...}") of the given
+                            files and of the *_test.mk files in the given directories (by default the
+                            current one). Tests fail by raising an error, eg. with assert or assert_eq
+  monkey debug [-path dirs] [-stdlib modules] <script.mk> [args]
+                            run a script under the step debugger
+  monkey dap [-path dirs] [-stdlib modules]
+                            serve the Debug Adapter Protocol over stdio
+  monkey lsp                serve the Language Server Protocol over stdio
+  monkey serve [flags]      serve the REPL over a Unix socket or localhost TCP port (see monkey serve -h)
+
+Coverage flags measure which statements and if branches of the script (for run) and of the modules it
+imports run, turning the optimizer off: -cover reports a summary, -coverprofile file writes an LCOV file
+and -coverhtml file writes an annotated HTML report
+`
+
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -15,3 +73,503 @@ func main() {
 	fmt.Printf("Welcome %s to MonkeyLangauge Version %.1f\n", user.Username, 0.1)
 	repl.Start(os.Stdin, os.Stdout)
 }
+
+// debug - Runs a script under the step debugger, importing modules like run
+func debug(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ContinueOnError)
+	path := flags.String("path", "", "directories to look up modules in, separated like PATH")
+	modules := flags.String("stdlib", "all", "comma separated standard library modules scripts can import")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 1 {
+		fmt.Fprint(os.Stderr, USAGE)
+		return 2
+	}
+	library, err := stdlib.Parse(*modules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 2
+	}
+	library.Args = flags.Args()
+
+	script := flags.Arg(0)
+	source, err := ioutil.ReadFile(script)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 1
+	}
+
+	debugger := debugger.New(string(source), os.Stdin, os.Stdout)
+	debugger.Loader = module.NewLoader(module.SearchPath(*path))
+	debugger.Loader.Builtins = library
+	debugger.Dir = filepath.Dir(script)
+	if _, ok := debugger.Run().(*object.Error); ok {
+		return 1
+	}
+	return 0
+}
+
+// serveDAP - Serves the Debug Adapter Protocol over stdio. Launched programs import modules like run
+func serveDAP(args []string) int {
+	flags := flag.NewFlagSet("dap", flag.ContinueOnError)
+	path := flags.String("path", "", "directories to look up modules in, separated like PATH")
+	modules := flags.String("stdlib", "all", "comma separated standard library modules programs can import")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	library, err := stdlib.Parse(*modules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 2
+	}
+
+	server := dap.NewServer(os.Stdin, os.Stdout)
+	server.Loader = module.NewLoader(module.SearchPath(*path))
+	server.Loader.Builtins = library
+	if err := server.Serve(); err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runCommand - Runs a monkey sub command and returns the process exit code
+func runCommand(command string, args []string) int {
+	switch command {
+	case "run":
+		return run(args)
+	case "lint":
+		return lintScripts(args)
+	case "check":
+		return checkScripts(args)
+	case "test":
+		return testScripts(args)
+	case "debug":
+		return debug(args)
+	case "dap":
+		return serveDAP(args)
+	case "lsp":
+		if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+			fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+			return 1
+		}
+		return 0
+	case "serve":
+		return serve(args)
+	default:
+		fmt.Fprintf(os.Stderr, "monkey: unknown command %q\n", command)
+		fmt.Fprint(os.Stderr, USAGE)
+		return 2
+	}
+}
+
+// run - Runs a script, reporting parser errors and an error result on stderr
+func run(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	path := flags.String("path", "", "directories to look up modules in, separated like PATH")
+	modules := flags.String("stdlib", "all", "comma separated standard library modules scripts can import")
+	optimize := flags.Bool("optimize", true, "fold constants and inline small functions before running")
+	profile := flags.String("profile", "", "write a pprof profile of the script to this file")
+	top := flags.Int("top", 10, "number of functions and lines in the profile report")
+	trace := flags.Bool("trace", false, "write the events of the running script to stderr")
+	cover := addCoverageFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 1 {
+		fmt.Fprint(os.Stderr, USAGE)
+		return 2
+	}
+	library, err := stdlib.Parse(*modules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 2
+	}
+	library.Args = flags.Args()
+
+	script := flags.Arg(0)
+	source, err := ioutil.ReadFile(script)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 1
+	}
+
+	parser := parser.New(lexer.New(string(source)))
+	program := parser.ParseProgram()
+	if errors := parser.ParseErrors(); len(errors) != 0 {
+		for _, parseError := range errors {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", script, parseError.Token.Line, parseError.Token.Column, parseError.Message)
+		}
+		return 1
+	}
+	for _, warning := range parser.Warnings() {
+		fmt.Fprintf(os.Stderr, "%s:%d:%d: warning: %s\n", script, warning.Token.Line, warning.Token.Column, warning.Message)
+	}
+
+	env := object.NewEnvironment()
+	loader := module.NewLoader(module.SearchPath(*path))
+	loader.Builtins = library
+	// Coverage, profiles and traces describe the program as written, not as the optimizer rewrote it
+	loader.Optimize = *optimize && !cover.enabled() && *profile == "" && !*trace
+	loader.Attach(env, filepath.Dir(script))
+	if err := evaluator.ExpandMacros(env, program); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", script, err.Message)
+		return 1
+	}
+	if loader.Optimize {
+		program = optimizer.Optimize(program)
+	}
+
+	observers := object.Observers{}
+	var covered *coverage.Coverage
+	if cover.enabled() {
+		covered = coverage.New()
+		covered.Add(script, program)
+		loader.Evaluating = covered.Add
+		observers = append(observers, covered)
+	}
+	if *trace {
+		observers = append(observers, tracer.New(os.Stderr))
+	}
+	var profiled *profiler.Profiler
+	if *profile != "" {
+		profiled = profiler.New(script)
+		observers = append(observers, profiled)
+	}
+	switch len(observers) {
+	case 0:
+	case 1:
+		env.SetObserver(observers[0])
+	default:
+		env.SetObserver(observers)
+	}
+
+	if profiled != nil {
+		profiled.Start()
+	}
+	result := evaluator.Eval(env, program)
+	if profiled != nil {
+		profiled.Stop()
+		if err := writeProfile(profiled, *profile, *top); err != nil {
+			fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+			return 1
+		}
+	}
+
+	if covered != nil {
+		if err := cover.write(covered, os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+			return 1
+		}
+	}
+
+	if errorObject, ok := result.(*object.Error); ok {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", script, errorObject.Message)
+		return 1
+	}
+	return 0
+}
+
+// coverageFlags - The flags of the run and test commands asking for coverage reports
+type coverageFlags struct {
+	summary *bool
+	lcov    *string
+	html    *string
+}
+
+func addCoverageFlags(flags *flag.FlagSet) *coverageFlags {
+	return &coverageFlags{
+		summary: flags.Bool("cover", false, "report the statement and branch coverage of each file"),
+		lcov:    flags.String("coverprofile", "", "write the coverage to this file in the LCOV format"),
+		html:    flags.String("coverhtml", "", "write an HTML coverage report to this file"),
+	}
+}
+
+// enabled - Whether coverage has to be measured
+func (cover *coverageFlags) enabled() bool {
+	return *cover.summary || *cover.lcov != "" || *cover.html != ""
+}
+
+// write - Writes the reports the flags ask for: the summary to out and the LCOV and HTML reports to their
+// files
+func (cover *coverageFlags) write(covered *coverage.Coverage, out io.Writer) error {
+	if *cover.summary {
+		covered.Summary(out)
+	}
+	reports := []struct {
+		file  string
+		write func(io.Writer) error
+	}{
+		{*cover.lcov, covered.WriteLCOV},
+		{*cover.html, covered.WriteHTML},
+	}
+	for _, report := range reports {
+		if report.file == "" {
+			continue
+		}
+		out, err := os.Create(report.file)
+		if err != nil {
+			return err
+		}
+		if err := report.write(out); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeProfile - Reports the top functions and lines measured by profiled on stderr and writes its pprof
+// profile to file
+func writeProfile(profiled *profiler.Profiler, file string, top int) error {
+	profiled.Report(os.Stderr, top)
+
+	out, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := profiled.WriteProfile(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// lintScripts - Lints each script, reporting parser errors and problems on stderr. The exit code is 1 if
+// there were any
+func lintScripts(scripts []string) int {
+	if len(scripts) == 0 {
+		fmt.Fprint(os.Stderr, USAGE)
+		return 2
+	}
+
+	status := 0
+	for _, script := range scripts {
+		program := parseScript(script)
+		if program == nil {
+			status = 1
+			continue
+		}
+
+		for _, problem := range lint.LintFile(script, program) {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s (%s)\n", script, problem.Line, problem.Column, problem.Message, problem.Rule)
+			status = 1
+		}
+	}
+	return status
+}
+
+// checkScripts - Type checks each script, reporting parser and type errors on stderr. The exit code is 1
+// if there were any
+func checkScripts(scripts []string) int {
+	if len(scripts) == 0 {
+		fmt.Fprint(os.Stderr, USAGE)
+		return 2
+	}
+
+	status := 0
+	for _, script := range scripts {
+		program := parseScript(script)
+		if program == nil {
+			status = 1
+			continue
+		}
+
+		for _, problem := range checker.Check(program) {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", script, problem.Line, problem.Column, problem.Message)
+			status = 1
+		}
+	}
+	return status
+}
+
+// testScripts - Runs the tests of test files, reporting the failed ones (and with -v the passed ones) on
+// stdout. The exit code is 1 if a test failed or a file could not be run
+func testScripts(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	path := flags.String("path", "", "directories to look up modules in, separated like PATH")
+	modules := flags.String("stdlib", "all", "comma separated standard library modules tests can import")
+	pattern := flags.String("run", "", "only run the tests whose name matches this regular expression")
+	verbose := flags.Bool("v", false, "also report the tests that passed")
+	cover := addCoverageFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	library, err := stdlib.Parse(*modules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 2
+	}
+	var filter *regexp.Regexp
+	if *pattern != "" {
+		if filter, err = regexp.Compile(*pattern); err != nil {
+			fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+			return 2
+		}
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	files, err := tester.Discover(paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 1
+	}
+
+	var covered *coverage.Coverage
+	if cover.enabled() {
+		covered = coverage.New()
+	}
+
+	status, passed, failed := 0, 0, 0
+	for _, file := range files {
+		program := parseScript(file)
+		if program == nil {
+			status = 1
+			continue
+		}
+
+		env := object.NewEnvironment()
+		loader := module.NewLoader(module.SearchPath(*path))
+		loader.Builtins = library
+		loader.Attach(env, filepath.Dir(file))
+		if covered != nil {
+			// Test files imported by the tests (eg. shared helpers) are not the code being tested
+			loader.Evaluating = func(path string, program *ast.Program) {
+				if !strings.HasSuffix(path, tester.FILE_SUFFIX) {
+					covered.Add(path, program)
+				}
+			}
+			env.SetObserver(covered)
+		}
+		results, err := tester.Run(env, program, filter)
+		if err != nil {
+			fmt.Printf("FAIL %s: %s\n", file, err.Message)
+			status = 1
+			continue
+		}
+
+		for _, result := range results {
+			if result.Passed() {
+				passed++
+				if *verbose {
+					fmt.Printf("ok   %s:%d: %s (%s)\n", file, result.Line, result.Name, result.Duration)
+				}
+				continue
+			}
+
+			failed++
+			fmt.Printf("FAIL %s:%d: %s (%s)\n", file, result.Line, result.Name, result.Duration)
+			fmt.Printf("    %s\n", strings.Replace(result.Error.Message, "\n", "\n    ", -1))
+			for _, frame := range result.Error.Stack {
+				fmt.Printf("        %s\n", frame)
+			}
+		}
+	}
+
+	fmt.Printf("%d passed, %d failed in %d files\n", passed, failed, len(files))
+	if covered != nil {
+		if err := cover.write(covered, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+			return 1
+		}
+	}
+	if failed > 0 {
+		status = 1
+	}
+	return status
+}
+
+// parseScript - Parses the file script, reporting an error reading it or its parser errors on stderr. nil
+// if there were any
+func parseScript(script string) *ast.Program {
+	source, err := ioutil.ReadFile(script)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return nil
+	}
+
+	parser := parser.New(lexer.New(string(source)))
+	program := parser.ParseProgram()
+	if errors := parser.ParseErrors(); len(errors) != 0 {
+		for _, parseError := range errors {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", script, parseError.Token.Line, parseError.Token.Column, parseError.Message)
+		}
+		return nil
+	}
+	return program
+}
+
+// serve - Runs the network REPL server. Without a token from -token or MONKEY_REPL_TOKEN a random one is
+// generated and printed
+func serve(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	unix := flags.String("unix", "", "listen on the Unix socket at this path")
+	tcp := flags.String("tcp", "", "listen on this localhost TCP address (eg. localhost:4000)")
+	token := flags.String("token", os.Getenv("MONKEY_REPL_TOKEN"), "token clients must send to connect")
+	shared := flags.Bool("shared", false, "share one Environment between every connection")
+	timeout := flags.Duration("timeout", 0, "maximum time per input (eg. 5s)")
+	maxSteps := flags.Int("max-steps", 0, "maximum statements run per input")
+	maxDepth := flags.Int("max-depth", repl.DEFAULT_MAX_DEPTH, "maximum nested function calls per input (0 for no limit)")
+	path := flags.String("path", "", "directories to look up modules in, separated like PATH")
+	modules := flags.String("stdlib", "all", "comma separated standard library modules clients can import")
+	root := flags.String("fs-root", "", "directory the fs module is confined to")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	library, err := stdlib.Parse(*modules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 2
+	}
+	library.Root = *root
+
+	network, address := "unix", *unix
+	if *tcp != "" {
+		network, address = "tcp", *tcp
+	}
+	if (*unix == "") == (*tcp == "") {
+		fmt.Fprintln(os.Stderr, "monkey: serve needs exactly one of -unix or -tcp")
+		return 2
+	}
+
+	if *token == "" {
+		random := make([]byte, 16)
+		if _, err := rand.Read(random); err != nil {
+			fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+			return 1
+		}
+		*token = hex.EncodeToString(random)
+		fmt.Fprintf(os.Stderr, "Token: %s\n", *token)
+	}
+
+	server := &repl.Server{
+		Token:       *token,
+		Limits:      repl.Limits{Timeout: *timeout, MaxSteps: *maxSteps, MaxDepth: *maxDepth},
+		ModulePaths: module.SearchPath(*path),
+		Library:     library,
+	}
+	if *shared {
+		server.Env = object.NewEnvironment()
+	}
+
+	listener, err := repl.Listen(network, address)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 1
+	}
+	defer listener.Close()
+
+	fmt.Fprintf(os.Stderr, "Serving the REPL on %s %s\n", network, address)
+	if err := server.Serve(listener); err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,203 @@
+package module
+
+import (
+	"fmt"
+	"io/ioutil"
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/object"
+	"monkeylang/optimizer"
+	"monkeylang/parser"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// EXTENSION - Added to imported names that have no extension
+const EXTENSION = ".mk"
+
+// Loader - Loads the files named by import expressions. Each file is evaluated once, in its own
+// Environment, and the module made from its exported bindings is shared by every importer
+//
+// Names starting with "./" or "../" are relative to the directory of the importing file. Other relative
+// names are first offered to Builtins and then looked up in Paths, in order. A Loader must not be used
+// by programs running concurrently
+type Loader struct {
+	Paths    []string
+	Builtins Builtins // modules implemented in Go (eg. the standard library). nil when there are none
+	Optimize bool     // whether modules are optimized before they are evaluated (see optimizer.Optimize)
+
+	// Evaluating is called, when it is set, with the path and program of each module right before it is
+	// evaluated (after its macros are expanded and it is optimized), eg. to measure its coverage
+	Evaluating func(path string, program *ast.Program)
+
+	modules map[string]*object.Module      // loaded modules by absolute path
+	loading []string                       // absolute paths of the modules being evaluated, outermost first
+	dirs    map[*object.Environment]string // root Environments to the directory of their file
+}
+
+// Builtins - Modules implemented in Go. Module returns nil (without an error) for names it does not
+// provide, which are then looked up as files
+type Builtins interface {
+	Module(name string) (*object.Module, error)
+}
+
+// NewLoader - Creates a loader searching paths for modules
+func NewLoader(paths []string) *Loader {
+	return &Loader{
+		Paths:   paths,
+		modules: make(map[string]*object.Module),
+		dirs:    make(map[*object.Environment]string),
+	}
+}
+
+// SearchPath - The module search path made of the entries of list followed by those of the MONKEY_PATH
+// environment variable. Both are separated like PATH (":" on Unix)
+func SearchPath(list string) []string {
+	paths := []string{}
+	for _, path := range append(filepath.SplitList(list), filepath.SplitList(os.Getenv("MONKEY_PATH"))...) {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// Attach - Makes the root Environment env import modules through the loader. Relative imports made by
+// code evaluated in env are resolved against dir
+func (loader *Loader) Attach(env *object.Environment, dir string) {
+	env.SetImporter(loader)
+	loader.dirs[env] = dir
+}
+
+// Import - Loads the module name, evaluating its file the first time it is imported. Implements
+// object.Importer
+func (loader *Loader) Import(env *object.Environment, name string) object.Object {
+	if loader.Builtins != nil && !isPath(name) {
+		module, err := loader.Builtins.Module(name)
+		if err != nil {
+			return newError("Cannot import %q: %s", name, err)
+		}
+		if module != nil {
+			return module
+		}
+	}
+
+	path, err := loader.resolve(env, name)
+	if err != nil {
+		return newError("Cannot import %q: %s", name, err)
+	}
+
+	if module, ok := loader.modules[path]; ok {
+		return module
+	}
+	for i, loading := range loader.loading {
+		if loading == path {
+			cycle := append(append([]string{}, loader.loading[i:]...), path)
+			return newError("Circular import: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		return newError("Cannot import %q: %s", name, err)
+	}
+
+	parser := parser.New(lexer.New(string(source)))
+	program := parser.ParseProgram()
+	if errors := parser.ParseErrors(); len(errors) != 0 {
+		return newError("Cannot import %q: %s:%d:%d: %s", name, path, errors[0].Token.Line, errors[0].Token.Column, errors[0].Message)
+	}
+
+	moduleEnv := object.NewEnvironment()
+	moduleEnv.SetObserver(env.Observer())
+	moduleEnv.SetOutput(env.Output())
+	moduleEnv.SetErrorOutput(env.ErrorOutput())
+	loader.Attach(moduleEnv, filepath.Dir(path))
+
+	result := loader.evaluate(path, moduleEnv, program)
+	if errorObject, ok := result.(*object.Error); ok {
+		// The kind and the stack are kept so that the error can still be caught and traced like it was raised
+		// by the importing code
+		stack := append(append([]string{}, errorObject.Stack...), fmt.Sprintf("import %q", name))
+		return &object.Error{Message: fmt.Sprintf("In module %q: %s", name, errorObject.Message), Kind: errorObject.Kind, Stack: stack}
+	}
+
+	module := &object.Module{Name: name, Path: path, Exports: make(map[string]object.Object)}
+	for _, statement := range program.Statements {
+		let, ok := statement.(*ast.LetStatement)
+		if !ok || !let.Exported {
+			continue
+		}
+		names := []*ast.Identifier{let.Name}
+		if let.Pattern != nil {
+			names = ast.PatternNames(let.Pattern)
+		}
+		for _, name := range names {
+			module.Exports[name.Value], _ = moduleEnv.Get(name.Value)
+		}
+	}
+	loader.modules[path] = module
+	return module
+}
+
+// evaluate - Evaluates the program of the module at path, which is loading until it returns (or panics,
+// eg. when the evaluation is abandoned by a debugger or a limit)
+func (loader *Loader) evaluate(path string, env *object.Environment, program *ast.Program) object.Object {
+	loader.loading = append(loader.loading, path)
+	defer func() { loader.loading = loader.loading[:len(loader.loading)-1] }()
+	if err := evaluator.ExpandMacros(env, program); err != nil {
+		return err
+	}
+	if loader.Optimize {
+		program = optimizer.Optimize(program)
+	}
+	if loader.Evaluating != nil {
+		loader.Evaluating(path, program)
+	}
+	return evaluator.Eval(env, program)
+}
+
+// resolve - The absolute path of the file name refers to when imported from env
+func (loader *Loader) resolve(env *object.Environment, name string) (string, error) {
+	file := name
+	if filepath.Ext(file) == "" {
+		file += EXTENSION
+	}
+
+	if filepath.IsAbs(file) {
+		return file, nil
+	}
+	if isPath(name) {
+		return filepath.Abs(filepath.Join(loader.dir(env), file))
+	}
+
+	for _, dir := range loader.Paths {
+		candidate := filepath.Join(dir, file)
+		if _, err := os.Stat(candidate); err == nil {
+			return filepath.Abs(candidate)
+		}
+	}
+	return "", fmt.Errorf("not found in the module search path %q", loader.Paths)
+}
+
+// isPath - Whether name is a path (absolute or relative to the importing file) rather than a name to look up
+func isPath(name string) bool {
+	return filepath.IsAbs(name) || strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../")
+}
+
+// dir - The directory of the file the code running in env was loaded from
+func (loader *Loader) dir(env *object.Environment) string {
+	for env.Outer != nil {
+		env = env.Outer
+	}
+	if dir, ok := loader.dirs[env]; ok {
+		return dir
+	}
+	return "."
+}
+
+func newError(message string, args ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(message, args...)}
+}
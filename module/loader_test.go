@@ -0,0 +1,165 @@
+package module
+
+import (
+	"fmt"
+	"io/ioutil"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles - Creates files (path to source) under a new temporary directory and returns it
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(source), 0644); err != nil {
+			t.Fatalf("Could not write %s: %s", path, err)
+		}
+	}
+	return dir
+}
+
+func run(t *testing.T, loader *Loader, dir string, input string) object.Object {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		t.Fatalf("Parser errors for %q: %v", input, parser.Errors())
+	}
+
+	env := object.NewEnvironment()
+	loader.Attach(env, dir)
+	return evaluator.Eval(env, program)
+}
+
+func TestImport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main/helpers.mk":    `let secret = 10; export let add = fn(x) { x + secret + import "./nested/one".value };`,
+		"main/nested/one.mk": `export let value = 1;`,
+		"lib/math.mk":        `export let double = fn(x) { x * 2 };`,
+		"lib/pair.mk":        `export let [left, {right}] = [1, {"right": 2}];`,
+	})
+	loader := NewLoader([]string{filepath.Join(dir, "lib")})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let h = import "./helpers"; h.add(5)`, "16"},
+		{`import "./helpers.mk".add(1)`, "12"},
+		{`import "math".double(21)`, "42"},
+		{`let pair = import "pair"; pair.left + pair.right`, "3"},
+		{`import "./helpers".secret`, `ERROR: Module ./helpers has no export secret`},
+		{`import "./missing"`, `ERROR: Cannot import "./missing": open ` + filepath.Join(dir, "main", "missing.mk") + `: no such file or directory`},
+		{`import "nope"`, `ERROR: Cannot import "nope": not found in the module search path ["` + filepath.Join(dir, "lib") + `"]`},
+		{`let x = 5; x.y`, "ERROR: Cannot access y of INTEGER"},
+	}
+
+	for _, test := range tests {
+		result := run(t, loader, filepath.Join(dir, "main"), test.input)
+		if result.Inspect() != test.expected {
+			t.Errorf("Result of %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, result.Inspect())
+		}
+	}
+}
+
+func TestImportEvaluatesOnce(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"a.mk": "export let f = fn() { 1 };"})
+	loader := NewLoader(nil)
+
+	first := run(t, loader, dir, `import "./a"`)
+	second := run(t, loader, dir, `import "./a.mk"`)
+	if first != second {
+		t.Errorf("Importing a module twice should return the cached module")
+	}
+}
+
+// builtins - Provides the module "answer" and refuses "forbidden"
+type builtins struct{}
+
+func (builtins) Module(name string) (*object.Module, error) {
+	switch name {
+	case "answer":
+		return &object.Module{Name: name, Exports: map[string]object.Object{"value": &object.Integer{Value: 42}}}, nil
+	case "forbidden":
+		return nil, fmt.Errorf("the module %s is disabled", name)
+	}
+	return nil, nil
+}
+
+func TestImportBuiltins(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"lib/answer.mk": `export let value = 1;`,
+		"lib/other.mk":  `export let value = 2;`,
+	})
+	loader := NewLoader([]string{filepath.Join(dir, "lib")})
+	loader.Builtins = builtins{}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "answer".value`, "42"},
+		{`import "other".value`, "2"},
+		{`import "./lib/answer".value`, "1"},
+		{`import "forbidden"`, `ERROR: Cannot import "forbidden": the module forbidden is disabled`},
+	}
+
+	for _, test := range tests {
+		result := run(t, loader, dir, test.input)
+		if result.Inspect() != test.expected {
+			t.Errorf("Result of %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, result.Inspect())
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.mk":      `export let b = import "./b";`,
+		"b.mk":      `export let a = import "./a";`,
+		"broken.mk": `let = 5;`,
+		"fails.mk":  `let x = 1 + true;`,
+		"throws.mk": "let check = fn(x) { if (x < 0) { throw error(\"negative\", \"ValueError\") } };\ncheck(-1);",
+	})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "./a"`, "Circular import: " + filepath.Join(dir, "a.mk") + " -> " + filepath.Join(dir, "b.mk") + " -> " + filepath.Join(dir, "a.mk")},
+		{`import "./broken"`, "Cannot import \"./broken\": " + filepath.Join(dir, "broken.mk") + ":1:5: Expected token type IDENT, got = instead"},
+		{`import "./fails"`, "In module \"./fails\": Mismatch types: INTEGER + BOOLEAN"},
+		{`try { import "./throws" } catch (e) { [e.kind, e.message, e.stack] }`, `["ValueError", "In module \"./throws\": negative", ["thrown at line 1", "check called at line 2", "import \"./throws\""]]`},
+	}
+
+	for _, test := range tests {
+		result := run(t, NewLoader(nil), dir, test.input)
+		if !strings.HasSuffix(result.Inspect(), test.expected) {
+			t.Errorf("Result of %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, result.Inspect())
+		}
+	}
+}
+
+func TestImportWithoutLoader(t *testing.T) {
+	program := parser.New(lexer.New(`import "a"`)).ParseProgram()
+	result := evaluator.Eval(object.NewEnvironment(), program)
+	if result.Inspect() != `ERROR: Cannot import "a": modules are not available` {
+		t.Errorf("Import without a loader is incorrect. Got: %q", result.Inspect())
+	}
+}
+
+func TestSearchPath(t *testing.T) {
+	os.Setenv("MONKEY_PATH", "/env/a"+string(os.PathListSeparator)+"/env/b")
+	defer os.Unsetenv("MONKEY_PATH")
+
+	paths := SearchPath("/flag" + string(os.PathListSeparator))
+	if strings.Join(paths, ",") != "/flag,/env/a,/env/b" {
+		t.Errorf("SearchPath is incorrect. Got: %q", paths)
+	}
+}
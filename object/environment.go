@@ -1,8 +1,24 @@
 package object
 
+import (
+	"io"
+	"os"
+	"sort"
+)
+
+// Importer - Loads the module an import expression names. Like the Observer it is set on the root
+// Environment and inherited by every Environment enclosed by it. env is where the import is evaluated
+type Importer interface {
+	Import(env *Environment, name string) Object
+}
+
 type Environment struct {
-	Outer *Environment
-	store map[string]Object
+	Outer       *Environment
+	store       map[string]Object
+	observer    Observer
+	importer    Importer
+	output      io.Writer
+	errorOutput io.Writer
 }
 
 func NewEnvironment() *Environment {
@@ -13,6 +29,10 @@ func NewEnvironment() *Environment {
 func NewEnclosedEnvrionment(outerEnv *Environment) *Environment {
 	env := NewEnvironment()
 	env.Outer = outerEnv
+	env.observer = outerEnv.observer
+	env.importer = outerEnv.importer
+	env.output = outerEnv.output
+	env.errorOutput = outerEnv.errorOutput
 	return env
 }
 
@@ -25,6 +45,60 @@ func (env *Environment) Get(name string) (Object, bool) {
 }
 
 func (env *Environment) Set(name string, obj Object) Object {
+	if observer, ok := env.observer.(BindingObserver); ok {
+		observer.Bind(env, name, obj)
+	}
 	env.store[name] = obj
 	return obj
 }
+
+// Names - The sorted names bound directly in this Environment (not including Outer environments)
+func (env *Environment) Names() []string {
+	names := make([]string, 0, len(env.store))
+	for name := range env.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (env *Environment) Observer() Observer {
+	return env.observer
+}
+
+func (env *Environment) SetObserver(observer Observer) {
+	env.observer = observer
+}
+
+func (env *Environment) Importer() Importer {
+	return env.importer
+}
+
+func (env *Environment) SetImporter(importer Importer) {
+	env.importer = importer
+}
+
+// Output - Where scripts print to (eg. with puts). Like the Observer it is set on the root Environment
+// and inherited by every Environment enclosed by it. It is os.Stdout unless set
+func (env *Environment) Output() io.Writer {
+	if env == nil || env.output == nil {
+		return os.Stdout
+	}
+	return env.output
+}
+
+func (env *Environment) SetOutput(output io.Writer) {
+	env.output = output
+}
+
+// ErrorOutput - Where scripts print errors to (eg. with eprint). It is os.Stderr unless set
+func (env *Environment) ErrorOutput() io.Writer {
+	if env == nil || env.errorOutput == nil {
+		return os.Stderr
+	}
+	return env.errorOutput
+}
+
+func (env *Environment) SetErrorOutput(errorOutput io.Writer) {
+	env.errorOutput = errorOutput
+}
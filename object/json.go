@@ -0,0 +1,177 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EncodeJSON - Converts integers, strings, booleans, null, arrays and hashes (with string keys) to JSON
+// text. A non-empty indent puts each element on its own line, indented by indent per level
+func EncodeJSON(obj Object, indent string) (string, error) {
+	var out bytes.Buffer
+	if err := encodeJSON(&out, obj); err != nil {
+		return "", err
+	}
+	if indent == "" {
+		return out.String(), nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out.Bytes(), "", indent); err != nil {
+		return "", err
+	}
+	return indented.String(), nil
+}
+
+func encodeJSON(out *bytes.Buffer, obj Object) error {
+	switch obj := obj.(type) {
+	case *Integer:
+		out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *String:
+		encodeJSONString(out, obj.Value)
+	case *Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *Null:
+		out.WriteString("null")
+	case *Array:
+		out.WriteString("[")
+		for i, element := range obj.Elements {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			if err := encodeJSON(out, element); err != nil {
+				return err
+			}
+		}
+		out.WriteString("]")
+	case *Hash:
+		out.WriteString("{")
+		for i, pair := range obj.Pairs() {
+			key, ok := pair.Key.(*String)
+			if !ok {
+				return fmt.Errorf("Cannot encode %s hash key %s as JSON: keys must be strings", pair.Key.Type(), pair.Key.Inspect())
+			}
+			if i > 0 {
+				out.WriteString(",")
+			}
+			encodeJSONString(out, key.Value)
+			out.WriteString(":")
+			if err := encodeJSON(out, pair.Value); err != nil {
+				return err
+			}
+		}
+		out.WriteString("}")
+	default:
+		return fmt.Errorf("Cannot encode %s as JSON", obj.Type())
+	}
+	return nil
+}
+
+// encodeJSONString - Writes str quoted and escaped, leaving HTML characters as they are
+func encodeJSONString(out *bytes.Buffer, str string) {
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(str) // Encoding a string cannot fail
+	out.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+}
+
+// DecodeJSON - Converts JSON text to the corresponding values: objects become hashes (keeping the order
+// of their keys), numbers integers and JSON nulls null (the evaluator's Null, which is compared by
+// identity). Errors give the byte offset of the problem
+func DecodeJSON(text string, null Object) (Object, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	obj, err := decodeJSON(decoder, null)
+	if err != nil {
+		return nil, jsonError(decoder, err)
+	}
+	end := decoder.InputOffset()
+	if _, err := decoder.Token(); err != io.EOF {
+		extra := end + int64(len(text[end:])-len(strings.TrimLeft(text[end:], " \t\r\n")))
+		return nil, fmt.Errorf("Invalid JSON at offset %d: unexpected data after the value", extra)
+	}
+	return obj, nil
+}
+
+func decodeJSON(decoder *json.Decoder, null Object) (Object, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		if token == '[' {
+			array := &Array{Elements: []Object{}}
+			for decoder.More() {
+				element, err := decodeJSON(decoder, null)
+				if err != nil {
+					return nil, err
+				}
+				array.Elements = append(array.Elements, element)
+			}
+			_, err := decoder.Token()
+			return array, err
+		}
+
+		hash := NewHash()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(decoder, null)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(&String{Value: key.(string)}, value)
+		}
+		_, err := decoder.Token()
+		return hash, err
+	case json.Number:
+		value, err := strconv.ParseInt(string(token), 10, 64)
+		if err != nil {
+			return nil, unsupportedNumber{fmt.Sprintf("Unsupported number %s at offset %d: only integers are supported", token, decoder.InputOffset()-int64(len(token)))}
+		}
+		return &Integer{Value: value}, nil
+	case string:
+		return &String{Value: token}, nil
+	case bool:
+		return &Boolean{Value: token}, nil
+	default:
+		return null, nil
+	}
+}
+
+// unsupportedNumber - A valid JSON number that has no integer value
+type unsupportedNumber struct {
+	message string
+}
+
+func (err unsupportedNumber) Error() string { return err.message }
+
+// jsonError - Adds the byte offset of the problem to the errors of the encoding/json decoder
+func jsonError(decoder *json.Decoder, err error) error {
+	switch err := err.(type) {
+	case unsupportedNumber:
+		return err
+	case *json.SyntaxError:
+		// The offset is after the invalid character, which is more useful to point at
+		offset := err.Offset
+		if strings.HasPrefix(err.Error(), "invalid character") {
+			offset--
+		}
+		return fmt.Errorf("Invalid JSON at offset %d: %s", offset, err.Error())
+	default:
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("Invalid JSON at offset %d: unexpected end of input", decoder.InputOffset())
+		}
+		return fmt.Errorf("Invalid JSON at offset %d: %s", decoder.InputOffset(), err.Error())
+	}
+}
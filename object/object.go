@@ -3,8 +3,11 @@ package object
 import (
 	"bytes"
 	"fmt"
+	"hash/fnv"
 	"monkeylang/ast"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 const (
@@ -16,6 +19,14 @@ const (
 	ERROR_OBJ        = "ERROR_OBJ"
 	FUNCTION_OBJ     = "FUNCTION_OBJ"
 	BUILTIN_OBJ      = "BUILTIN"
+	TAIL_CALL_OBJ    = "TAIL_CALL"
+	MODULE_OBJ       = "MODULE"
+	ARRAY_OBJ        = "ARRAY"
+	HASH_OBJ         = "HASH"
+	ERROR_VALUE_OBJ  = "ERROR_VALUE"
+	QUOTE_OBJ        = "QUOTE"
+	MACRO_OBJ        = "MACRO"
+	REF_OBJ          = "REF"
 )
 
 type ObjectType string
@@ -58,15 +69,43 @@ type ReturnValue struct {
 func (returnValue *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (returnValue *ReturnValue) Inspect() string  { return returnValue.Value.Inspect() }
 
+// TailCall - a deferred function call in tail position. It is returned instead of growing the
+// Go stack and is resolved by the evaluator's trampoline
+type TailCall struct {
+	Env       *Environment // where the call is made
+	Call      *ast.CallExpression
+	Function  Object
+	Arguments []Object
+}
+
+func (tailCall *TailCall) Type() ObjectType { return TAIL_CALL_OBJ }
+func (tailCall *TailCall) Inspect() string  { return "tail call" }
+
+// Error - An error being raised. It stops the evaluation of everything it goes through until a try
+// catches it, where it becomes an ErrorValue
 type Error struct {
 	Message string
+	Kind    string   // set by scripts that throw errors and by failed assertions. Empty for other errors raised by the interpreter
+	Stack   []string // the calls the error went through, innermost first
+	Node    ast.Node // the innermost node whose evaluation raised the error. nil until the evaluator sets it
 }
 
 func (errorObject *Error) Type() ObjectType { return ERROR_OBJ }
 func (errorObject *Error) Inspect() string  { return fmt.Sprintf("ERROR: " + errorObject.Message) }
 
+// ErrorValue - An error as a value scripts can inspect (kind, message and stack) and throw: either a
+// caught Error or one made with the error builtin
+type ErrorValue struct {
+	Kind    string
+	Message string
+	Stack   []string
+}
+
+func (errorValue *ErrorValue) Type() ObjectType { return ERROR_VALUE_OBJ }
+func (errorValue *ErrorValue) Inspect() string  { return errorValue.Kind + ": " + errorValue.Message }
+
 type Function struct {
-	Parameters []*ast.Identifier
+	Parameters []ast.Pattern
 	Body       *ast.BlockStatement
 	Env        *Environment
 }
@@ -89,7 +128,34 @@ func (function *Function) Inspect() string {
 	return out.String()
 }
 
-type BuiltinFunction func(args ...Object) Object
+// Quote - Code made by quote, which macros return to replace their calls
+type Quote struct {
+	Node ast.Node
+}
+
+func (quote *Quote) Type() ObjectType { return QUOTE_OBJ }
+func (quote *Quote) Inspect() string  { return "QUOTE(" + quote.Node.String() + ")" }
+
+// Macro - A macro defined by a top level let. It is called with its arguments quoted while the program is
+// expanded, before it runs
+type Macro struct {
+	Parameters []ast.Pattern
+	Body       *ast.BlockStatement
+	Env        *Environment
+}
+
+func (macro *Macro) Type() ObjectType { return MACRO_OBJ }
+func (macro *Macro) Inspect() string {
+	parameters := []string{}
+	for _, param := range macro.Parameters {
+		parameters = append(parameters, param.String())
+	}
+	return "macro(" + strings.Join(parameters, ", ") + ") {\n" + macro.Body.String() + "\n}"
+}
+
+// BuiltinFunction - A function implemented in Go. env is the Environment of the call, which gives access
+// to the interpreter's state (eg. its Output). It can be nil when a builtin is called from Go
+type BuiltinFunction func(env *Environment, args ...Object) Object
 
 type Builtin struct {
 	Fn BuiltinFunction
@@ -97,3 +163,177 @@ type Builtin struct {
 
 func (builtin *Builtin) Type() ObjectType { return BUILTIN_OBJ }
 func (builtin *Builtin) Inspect() string  { return "builtin function" }
+
+// Module - The exported bindings of an imported file
+type Module struct {
+	Name    string // the path it was imported with
+	Path    string // the file it was loaded from
+	Exports map[string]Object
+}
+
+func (module *Module) Type() ObjectType { return MODULE_OBJ }
+func (module *Module) Inspect() string  { return fmt.Sprintf("module(%s)", module.Name) }
+
+// Ref - A mutable cell holding a value, shared by every closure that captures it. It can be read and
+// changed by programs running concurrently
+type Ref struct {
+	mutex sync.Mutex
+	value Object
+}
+
+// NewRef - Creates a cell holding value
+func NewRef(value Object) *Ref {
+	return &Ref{value: value}
+}
+
+func (ref *Ref) Type() ObjectType { return REF_OBJ }
+
+// Inspect - The value held by the cell in ref(). A cell met again inside its own value (eg. one holding an
+// array that holds the cell) is written as ref(...)
+func (ref *Ref) Inspect() string { return inspect(ref, map[*Ref]bool{}) }
+
+// Get - The value held by the cell
+func (ref *Ref) Get() Object {
+	ref.mutex.Lock()
+	defer ref.mutex.Unlock()
+	return ref.value
+}
+
+// Set - Replaces the value held by the cell
+func (ref *Ref) Set(value Object) {
+	ref.mutex.Lock()
+	defer ref.mutex.Unlock()
+	ref.value = value
+}
+
+// CompareAndSet - Replaces the value held by the cell with value if it still holds old, and returns
+// whether it did
+func (ref *Ref) CompareAndSet(old Object, value Object) bool {
+	ref.mutex.Lock()
+	defer ref.mutex.Unlock()
+	if ref.value != old {
+		return false
+	}
+	ref.value = value
+	return true
+}
+
+type Array struct {
+	Elements []Object
+}
+
+func (array *Array) Type() ObjectType { return ARRAY_OBJ }
+func (array *Array) Inspect() string  { return inspect(array, map[*Ref]bool{}) }
+
+// HashKey - Identifies a hashable value: values of the same type and contents have the same key
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// Hashable - Implemented by the objects that can be used as hash keys
+type Hashable interface {
+	HashKey() HashKey
+}
+
+func (integer *Integer) HashKey() HashKey {
+	return HashKey{Type: integer.Type(), Value: uint64(integer.Value)}
+}
+
+func (boolean *Boolean) HashKey() HashKey {
+	if boolean.Value {
+		return HashKey{Type: boolean.Type(), Value: 1}
+	}
+	return HashKey{Type: boolean.Type(), Value: 0}
+}
+
+func (str *String) HashKey() HashKey {
+	hash := fnv.New64a()
+	hash.Write([]byte(str.Value))
+	return HashKey{Type: str.Type(), Value: hash.Sum64()}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash - A map from hashable values to values that remembers the order keys were first set in
+type Hash struct {
+	pairs map[HashKey]HashPair
+	keys  []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{pairs: make(map[HashKey]HashPair)}
+}
+
+// Set - Maps key to value. key must be Hashable
+func (hash *Hash) Set(key Object, value Object) {
+	hashKey := key.(Hashable).HashKey()
+	if _, ok := hash.pairs[hashKey]; !ok {
+		hash.keys = append(hash.keys, hashKey)
+	}
+	hash.pairs[hashKey] = HashPair{Key: key, Value: value}
+}
+
+// Get - The value key maps to. Keys that are not Hashable are never found
+func (hash *Hash) Get(key Object) (Object, bool) {
+	hashable, ok := key.(Hashable)
+	if !ok {
+		return nil, false
+	}
+	pair, ok := hash.pairs[hashable.HashKey()]
+	return pair.Value, ok
+}
+
+// Pairs - The pairs of the hash in the order their keys were first set
+func (hash *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, 0, len(hash.keys))
+	for _, key := range hash.keys {
+		pairs = append(pairs, hash.pairs[key])
+	}
+	return pairs
+}
+
+func (hash *Hash) Len() int { return len(hash.keys) }
+
+func (hash *Hash) Type() ObjectType { return HASH_OBJ }
+func (hash *Hash) Inspect() string  { return inspect(hash, map[*Ref]bool{}) }
+
+// inspect - Inspects obj, writing the cells met again inside their own values (visiting) as ref(...), so
+// that a cell holding itself can be inspected. Each Inspect call tracks its own cells, so cells can be
+// inspected concurrently
+func inspect(obj Object, visiting map[*Ref]bool) string {
+	switch obj := obj.(type) {
+	case *Array:
+		elements := []string{}
+		for _, element := range obj.Elements {
+			elements = append(elements, inspectElement(element, visiting))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *Hash:
+		pairs := []string{}
+		for _, pair := range obj.Pairs() {
+			pairs = append(pairs, inspectElement(pair.Key, visiting)+": "+inspectElement(pair.Value, visiting))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	case *Ref:
+		if visiting[obj] {
+			return "ref(...)"
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+		return "ref(" + inspect(obj.Get(), visiting) + ")"
+	default:
+		return obj.Inspect()
+	}
+}
+
+// inspectElement - Inspects a value inside an array or hash, quoting strings so they can be told apart
+func inspectElement(obj Object, visiting map[*Ref]bool) string {
+	if str, ok := obj.(*String); ok {
+		return strconv.Quote(str.Value)
+	}
+	return inspect(obj, visiting)
+}
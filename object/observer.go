@@ -0,0 +1,147 @@
+package object
+
+import "monkeylang/ast"
+
+// Observer - Receives events from the evaluator as a program runs. It is set on the root Environment
+// and inherited by every Environment enclosed by it, so it must be set before evaluation starts
+//
+// An Observer can receive more events by also implementing the interfaces below. When no Observer is set
+// the evaluator only checks for one, so observing costs nothing to programs that are not observed
+type Observer interface {
+	// Statement is called before each statement is evaluated
+	Statement(env *Environment, statement ast.Statement)
+	// Call is called when a function body is entered. env is the function's new Environment
+	Call(env *Environment, call *ast.CallExpression, function *Function, args []Object)
+	// Return is called when a function body is left, with the value it produced
+	Return(function *Function, result Object)
+}
+
+// BuiltinObserver - An Observer that is also told about the calls of builtin functions (eg. to time them)
+type BuiltinObserver interface {
+	Observer
+	// CallBuiltin is called before a builtin runs. call is nil when it is not called by Monkey code
+	CallBuiltin(env *Environment, call *ast.CallExpression, builtin *Builtin, args []Object)
+	// ReturnBuiltin is called when a builtin returns, with the value it produced
+	ReturnBuiltin(builtin *Builtin, result Object)
+}
+
+// BranchObserver - An Observer that is also told which branch of each if expression runs (eg. to measure
+// coverage)
+type BranchObserver interface {
+	Observer
+	// Branch is called once the condition of ifExpression is evaluated. consequence is whether the
+	// consequence runs rather than the alternative, which may be missing
+	Branch(env *Environment, ifExpression *ast.IfExpression, consequence bool)
+}
+
+// NodeObserver - An Observer that is also told about the evaluation of every node (eg. to trace it)
+type NodeObserver interface {
+	Observer
+	// Enter is called before node is evaluated
+	Enter(env *Environment, node ast.Node)
+	// Exit is called when node has been evaluated, with its value. The value of a call in tail position
+	// is a TailCall, which is run after Exit is called
+	Exit(env *Environment, node ast.Node, result Object)
+}
+
+// ErrorObserver - An Observer that is also told about the errors raised by the program (eg. to log them)
+type ErrorObserver interface {
+	Observer
+	// Error is called once for each error, when it is raised by the evaluation of node (see Error.Node)
+	Error(env *Environment, node ast.Node, err *Error)
+}
+
+// BindingObserver - An Observer that is also told about the names bound in Environments (by lets,
+// parameters, catch blocks and match patterns)
+type BindingObserver interface {
+	Observer
+	// Bind is called before name is bound to value in env. The parameters of a function are bound before
+	// Call is called
+	Bind(env *Environment, name string, value Object)
+}
+
+// BaseObserver - An Observer ignoring every event, to embed in Observers that only need some of them
+type BaseObserver struct{}
+
+func (base BaseObserver) Statement(env *Environment, statement ast.Statement) {}
+func (base BaseObserver) Call(env *Environment, call *ast.CallExpression, function *Function, args []Object) {
+}
+func (base BaseObserver) Return(function *Function, result Object) {}
+
+// Observers - Forwards every event to each of its Observers that receives it, so that several of them
+// can observe the same program
+type Observers []Observer
+
+func (observers Observers) Statement(env *Environment, statement ast.Statement) {
+	for _, observer := range observers {
+		observer.Statement(env, statement)
+	}
+}
+
+func (observers Observers) Call(env *Environment, call *ast.CallExpression, function *Function, args []Object) {
+	for _, observer := range observers {
+		observer.Call(env, call, function, args)
+	}
+}
+
+func (observers Observers) Return(function *Function, result Object) {
+	for _, observer := range observers {
+		observer.Return(function, result)
+	}
+}
+
+func (observers Observers) CallBuiltin(env *Environment, call *ast.CallExpression, builtin *Builtin, args []Object) {
+	for _, observer := range observers {
+		if observer, ok := observer.(BuiltinObserver); ok {
+			observer.CallBuiltin(env, call, builtin, args)
+		}
+	}
+}
+
+func (observers Observers) ReturnBuiltin(builtin *Builtin, result Object) {
+	for _, observer := range observers {
+		if observer, ok := observer.(BuiltinObserver); ok {
+			observer.ReturnBuiltin(builtin, result)
+		}
+	}
+}
+
+func (observers Observers) Branch(env *Environment, ifExpression *ast.IfExpression, consequence bool) {
+	for _, observer := range observers {
+		if observer, ok := observer.(BranchObserver); ok {
+			observer.Branch(env, ifExpression, consequence)
+		}
+	}
+}
+
+func (observers Observers) Enter(env *Environment, node ast.Node) {
+	for _, observer := range observers {
+		if observer, ok := observer.(NodeObserver); ok {
+			observer.Enter(env, node)
+		}
+	}
+}
+
+func (observers Observers) Exit(env *Environment, node ast.Node, result Object) {
+	for _, observer := range observers {
+		if observer, ok := observer.(NodeObserver); ok {
+			observer.Exit(env, node, result)
+		}
+	}
+}
+
+func (observers Observers) Error(env *Environment, node ast.Node, err *Error) {
+	for _, observer := range observers {
+		if observer, ok := observer.(ErrorObserver); ok {
+			observer.Error(env, node, err)
+		}
+	}
+}
+
+func (observers Observers) Bind(env *Environment, name string, value Object) {
+	for _, observer := range observers {
+		if observer, ok := observer.(BindingObserver); ok {
+			observer.Bind(env, name, value)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package optimizer
+
+import (
+	"monkeylang/ast"
+	"monkeylang/token"
+	"strconv"
+)
+
+// fold - node with its constant expressions evaluated, for nodes whose children are already folded.
+// Only expressions the evaluator would evaluate without an error are folded, so errors (eg. a division
+// by zero or mismatched types) still happen when the program runs
+func fold(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		if folded := foldPrefix(node); folded != nil {
+			return folded
+		}
+	case *ast.InfixExpression:
+		if folded := foldInfix(node); folded != nil {
+			return folded
+		}
+	case *ast.IfExpression:
+		if folded := foldIf(node); folded != nil {
+			return folded
+		}
+	case *ast.BlockStatement:
+		copied := *node
+		copied.Statements = foldStatements(node.Statements)
+		return &copied
+	case *ast.Program:
+		copied := *node
+		copied.Statements = foldStatements(node.Statements)
+		return &copied
+	}
+	return node
+}
+
+func foldPrefix(prefix *ast.PrefixExpression) ast.Expression {
+	if !isConstant(prefix.Right) {
+		return nil
+	}
+
+	switch prefix.Operator {
+	case "!":
+		return booleanLiteral(prefix.Token, !isTruthy(prefix.Right))
+	case "-":
+		if integer, ok := prefix.Right.(*ast.IntegerLiteral); ok {
+			return integerLiteral(prefix.Token, -integer.Value)
+		}
+	}
+	return nil
+}
+
+func foldInfix(infix *ast.InfixExpression) ast.Expression {
+	// The right operand of ?? is only evaluated when the left one is null
+	if infix.Operator == "??" && isConstant(infix.Left) {
+		if _, ok := infix.Left.(*ast.NullLiteral); ok {
+			return infix.Right
+		}
+		return infix.Left
+	}
+	if !isConstant(infix.Left) || !isConstant(infix.Right) {
+		return nil
+	}
+
+	switch left := infix.Left.(type) {
+	case *ast.IntegerLiteral:
+		if right, ok := infix.Right.(*ast.IntegerLiteral); ok {
+			return foldIntegers(infix, left.Value, right.Value)
+		}
+	case *ast.BooleanLiteral:
+		if right, ok := infix.Right.(*ast.BooleanLiteral); ok {
+			switch infix.Operator {
+			case "==":
+				return booleanLiteral(infix.Token, left.Value == right.Value)
+			case "!=":
+				return booleanLiteral(infix.Token, left.Value != right.Value)
+			}
+			return nil
+		}
+	}
+
+	// Anything can be compared with null, which only equals itself
+	_, leftNull := infix.Left.(*ast.NullLiteral)
+	_, rightNull := infix.Right.(*ast.NullLiteral)
+	if leftNull || rightNull {
+		switch infix.Operator {
+		case "==":
+			return booleanLiteral(infix.Token, leftNull && rightNull)
+		case "!=":
+			return booleanLiteral(infix.Token, !(leftNull && rightNull))
+		}
+	}
+	return nil
+}
+
+func foldIntegers(infix *ast.InfixExpression, left int64, right int64) ast.Expression {
+	switch infix.Operator {
+	case "+":
+		return integerLiteral(infix.Token, left+right)
+	case "-":
+		return integerLiteral(infix.Token, left-right)
+	case "*":
+		return integerLiteral(infix.Token, left*right)
+	case "/":
+		if right == 0 {
+			return nil
+		}
+		return integerLiteral(infix.Token, left/right)
+	case "<":
+		return booleanLiteral(infix.Token, left < right)
+	case ">":
+		return booleanLiteral(infix.Token, left > right)
+	case "==":
+		return booleanLiteral(infix.Token, left == right)
+	case "!=":
+		return booleanLiteral(infix.Token, left != right)
+	default:
+		return nil
+	}
+}
+
+// foldIf - The expression an if with a constant condition evaluates to, when the branch that runs is a
+// single expression (or missing). Other branches are spliced into the enclosing block by foldStatements
+func foldIf(ifExpression *ast.IfExpression) ast.Expression {
+	if !isConstant(ifExpression.Condition) {
+		return nil
+	}
+
+	branch := ifExpression.Alternative
+	if isTruthy(ifExpression.Condition) {
+		branch = ifExpression.Consequence
+	}
+	if branch == nil {
+		return &ast.NullLiteral{Token: at(ifExpression.Token, token.NULL, "null")}
+	}
+	if len(branch.Statements) == 1 {
+		if statement, ok := branch.Statements[0].(*ast.ExpressionStatement); ok && statement.Expression != nil {
+			return statement.Expression
+		}
+	}
+	return nil
+}
+
+// foldStatements - statements with each if statement that has a constant condition replaced by the
+// statements of the branch that runs. Blocks run in the enclosing Environment, so this changes nothing
+// but an empty branch ending statements, which would change the value of the statements
+func foldStatements(statements []ast.Statement) []ast.Statement {
+	folded := []ast.Statement{}
+	for i, statement := range statements {
+		branch := constantBranch(statement)
+		if branch == nil || (len(branch.Statements) == 0 && i == len(statements)-1) {
+			folded = append(folded, statement)
+			continue
+		}
+		folded = append(folded, branch.Statements...)
+	}
+	return folded
+}
+
+// constantBranch - The branch that runs of the if with a constant condition that statement is, if it is one
+func constantBranch(statement ast.Statement) *ast.BlockStatement {
+	expressionStatement, ok := statement.(*ast.ExpressionStatement)
+	if !ok {
+		return nil
+	}
+	ifExpression, ok := expressionStatement.Expression.(*ast.IfExpression)
+	if !ok || !isConstant(ifExpression.Condition) {
+		return nil
+	}
+
+	if isTruthy(ifExpression.Condition) {
+		return ifExpression.Consequence
+	}
+	return ifExpression.Alternative
+}
+
+// isConstant - Whether expression is a literal integer, string, boolean or null
+func isConstant(expression ast.Expression) bool {
+	switch expression.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.BooleanLiteral, *ast.NullLiteral:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTruthy - Whether a constant counts as true in a condition, like evaluator.IsTruthy
+func isTruthy(constant ast.Expression) bool {
+	switch constant := constant.(type) {
+	case *ast.BooleanLiteral:
+		return constant.Value
+	case *ast.NullLiteral:
+		return false
+	default:
+		return true
+	}
+}
+
+func integerLiteral(tok token.Token, value int64) *ast.IntegerLiteral {
+	return &ast.IntegerLiteral{Token: at(tok, token.INT, strconv.FormatInt(value, 10)), Value: value}
+}
+
+func booleanLiteral(tok token.Token, value bool) *ast.BooleanLiteral {
+	if value {
+		return &ast.BooleanLiteral{Token: at(tok, token.TRUE, "true"), Value: true}
+	}
+	return &ast.BooleanLiteral{Token: at(tok, token.FALSE, "false"), Value: false}
+}
+
+// at - A token for a folded literal, positioned where the expression it replaces was
+func at(tok token.Token, tokenType token.TokenType, literal string) token.Token {
+	return token.Token{Type: tokenType, Literal: literal, Line: tok.Line, Column: tok.Column}
+}
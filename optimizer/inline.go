@@ -0,0 +1,164 @@
+package optimizer
+
+import (
+	"monkeylang/ast"
+	"monkeylang/resolver"
+)
+
+// MAX_INLINE_NODES - The most nodes the body of a function can have to be inlined
+const MAX_INLINE_NODES = 24
+
+// inlinable - A function whose calls can be replaced by its body
+type inlinable struct {
+	function  *ast.FunctionLiteral
+	body      ast.Expression
+	statement int // the index of the top level let defining the function
+}
+
+// findInlinable - Finds the functions whose calls can be inlined: small functions bound once by a top level
+// let, taking names as parameters and returning a single expression that only uses those parameters. Such
+// functions are not recursive and their bodies mean the same wherever they are put, as they bind nothing
+// in the Environment they run in and define no functions
+func (optimizer *optimizer) findInlinable(program *ast.Program) {
+	optimizer.inlinable = make(map[*resolver.Binding]*inlinable)
+	optimizer.statements = make(map[*ast.Identifier]int)
+
+	bindings := make(map[string]int)
+	for _, binding := range optimizer.resolution.Global.Bindings {
+		bindings[binding.Name]++
+	}
+
+	for i, statement := range program.Statements {
+		ast.Walk(statement, func(node ast.Node) bool {
+			if identifier, ok := node.(*ast.Identifier); ok {
+				optimizer.statements[identifier] = i
+			}
+			return true
+		})
+
+		let, ok := statement.(*ast.LetStatement)
+		if !ok || let.Name == nil || bindings[let.Name.Value] != 1 {
+			continue
+		}
+		function, ok := let.Value.(*ast.FunctionLiteral)
+		if !ok || function.IsMacro() {
+			continue
+		}
+		if candidate := optimizer.candidate(function); candidate != nil {
+			candidate.statement = i
+			optimizer.inlinable[optimizer.resolution.Declarations[let.Name]] = candidate
+		}
+	}
+}
+
+// candidate - function as an inlinable, or nil if it cannot be inlined
+func (optimizer *optimizer) candidate(function *ast.FunctionLiteral) *inlinable {
+	for _, parameter := range function.Parameters {
+		if _, ok := parameter.(*ast.Identifier); !ok {
+			return nil
+		}
+	}
+	if len(function.Body.Statements) != 1 {
+		return nil
+	}
+
+	candidate := &inlinable{function: function}
+	switch statement := function.Body.Statements[0].(type) {
+	case *ast.ExpressionStatement:
+		candidate.body = statement.Expression
+	case *ast.ReturnStatement:
+		candidate.body = statement.ReturnValue
+	}
+	if candidate.body == nil {
+		return nil
+	}
+
+	nodes, inlinable := 0, true
+	properties := make(map[*ast.Identifier]bool)
+	ast.Walk(candidate.body, func(node ast.Node) bool {
+		nodes++
+		switch node := node.(type) {
+		case *ast.LetStatement, *ast.TryExpression, *ast.MatchExpression:
+			// These bind names in the Environment they run in
+			inlinable = false
+		case *ast.FunctionLiteral:
+			// Its parameters could capture the names passed as arguments
+			inlinable = false
+		case *ast.MemberExpression:
+			properties[node.Property] = true
+		case *ast.Identifier:
+			if properties[node] {
+				break
+			}
+			binding := optimizer.resolution.References[node]
+			if binding == nil || binding.Function != function {
+				inlinable = false
+			}
+		}
+		return inlinable
+	})
+
+	if !inlinable || nodes > MAX_INLINE_NODES {
+		return nil
+	}
+	return candidate
+}
+
+// inline - The body of the function call calls with the arguments in place of the parameters, or nil if
+// the call cannot be inlined. Arguments must be constants or names that are certainly bound, since the body
+// may skip evaluating a parameter (eg. in a branch of an if) and an argument that raises an error must
+// raise it whatever the body does. The function must be defined by an earlier statement than the call, so
+// it is bound when the call runs
+func (optimizer *optimizer) inline(call *ast.CallExpression) ast.Expression {
+	name, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil
+	}
+	candidate := optimizer.inlinable[optimizer.resolution.References[name]]
+	if candidate == nil || optimizer.statements[name] <= candidate.statement {
+		return nil
+	}
+	if len(call.Arguments) != len(candidate.function.Parameters) {
+		return nil
+	}
+
+	arguments := make(map[*resolver.Binding]ast.Expression)
+	for i, argument := range call.Arguments {
+		parameter := optimizer.resolution.Declarations[candidate.function.Parameters[i].(*ast.Identifier)]
+		if name, isName := argument.(*ast.Identifier); !isConstant(argument) && !(isName && optimizer.isBound(name)) {
+			return nil
+		}
+		arguments[parameter] = argument
+	}
+
+	return ast.Modify(candidate.body, func(node ast.Node) ast.Node {
+		if identifier, ok := node.(*ast.Identifier); ok {
+			if argument, ok := arguments[optimizer.resolution.References[identifier]]; ok {
+				return argument
+			}
+		}
+		return node
+	}).(ast.Expression)
+}
+
+// isBound - Whether name is certainly bound when it is evaluated, so that evaluating it cannot raise an
+// error: it is a parameter of a function it is in, or a name bound by a top level let that
+// runs before the statement name is in
+func (optimizer *optimizer) isBound(name *ast.Identifier) bool {
+	binding := optimizer.resolution.References[name]
+	if binding == nil {
+		return false
+	}
+	switch binding.Kind {
+	case resolver.Parameter:
+		return true
+	case resolver.Let:
+		if binding.Scope != optimizer.resolution.Global {
+			return false
+		}
+		declaration := optimizer.statements[binding.Declaration]
+		let, ok := optimizer.resolution.Program.Statements[declaration].(*ast.LetStatement)
+		return ok && let.Name == binding.Declaration && declaration < optimizer.statements[name]
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package optimizer
+
+import (
+	"monkeylang/ast"
+	"monkeylang/resolver"
+)
+
+// Optimize - A copy of program that evaluates to the same results with less work. Constant arithmetic,
+// comparisons and logic are folded, if expressions with a constant condition are replaced by the branch
+// that runs and calls of small functions are replaced by their bodies (see inlinable). program itself is
+// not changed
+//
+// Folding never changes what a program does, except that errors raised in inlined code do not list the
+// inlined call in their stack (like calls in tail position)
+func Optimize(program *ast.Program) *ast.Program {
+	optimizer := &optimizer{resolution: resolver.Resolve(program, nil)}
+	optimizer.findInlinable(program)
+
+	optimized, _ := ast.Modify(program, optimizer.optimize).(*ast.Program)
+	return optimized
+}
+
+type optimizer struct {
+	resolution *resolver.Resolution
+	inlinable  map[*resolver.Binding]*inlinable
+	// statements are the indexes of the top level statements the identifiers of the program are in
+	statements map[*ast.Identifier]int
+}
+
+// optimize - The modifier of the program's nodes, which are modified after their children
+func (optimizer *optimizer) optimize(node ast.Node) ast.Node {
+	if call, ok := node.(*ast.CallExpression); ok {
+		if inlined := optimizer.inline(call); inlined != nil {
+			return ast.Modify(inlined, fold)
+		}
+		return call
+	}
+	return fold(node)
+}
@@ -0,0 +1,114 @@
+package optimizer
+
+import (
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		t.Fatalf("Parser errors for %q: %v", input, parser.Errors())
+	}
+	return program
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // the source of the optimized program
+	}{
+		{"60 * 60 * 24", "86400"},
+		{"let day = 60 * 60 * 24; day", "let day = 86400; day"},
+		{"(2 + 3) * 4 / 3 - -1", "7"},
+		{"[1 < 2, 2 > 3, 1 == 1, 1 != 1, true == false, true != false]", "[true, false, true, false, false, true]"},
+		{`[!true, !null, !0, !"", null == null, null != 1, "a" == null]`, "[false, true, false, false, true, true, false]"},
+		{"[null ?? 1, 2 ?? x, x ?? 3]", "[1, 2, x ?? 3]"},
+		{"x + 1 * 2", "x + 2"},
+		{"1 / 0", "1 / 0"},
+		{"1 + true", "1 + true"},
+		{`"a" + "b"`, `"a" + "b"`},
+
+		{"if (1 < 2) { x } else { y }", "x"},
+		{"if (false) { x }", "null"},
+		{"let f = fn() { if (!true) { 1 } else { let a = 2; a } }", "let f = fn() { let a = 2; a }"},
+		{"if (true) { let a = 1 }; a", "let a = 1; a"},
+		{"if (false) { 1 }; x", "null; x"},
+		{"1; if (true) {}", "1; if (true) {}"},
+		{"if (x) { 1 + 1 }", "if (x) { 2 }"},
+
+		{"let square = fn(x) { x * x }; square(3)", "let square = fn(x) { x * x }; 9"},
+		{"let add = fn(a, b) { return a + b }; let n = 1; add(n, 2 * 3)", "let add = fn(a, b) { return a + b }; let n = 1; n + 6"},
+		{"let double = fn(x) { x * 2 }; double(double(2))", "let double = fn(x) { x * 2 }; 8"},
+		{"let first = fn(a, b) { a }; first(1, n)", "let first = fn(a, b) { a }; first(1, n)"},
+		{"let f = fn(x) { x }; f(g())", "let f = fn(x) { x }; f(g())"},
+		{"f(1); let f = fn(x) { x }", "f(1); let f = fn(x) { x }"},
+		{"let f = fn(x) { x }; let f = fn(x) { x + 1 }; f(1)", "let f = fn(x) { x }; let f = fn(x) { x + 1 }; f(1)"},
+		{"let f = fn(x) { f(x) }; f(1)", "let f = fn(x) { f(x) }; f(1)"},
+		{"let k = 1; let f = fn(x) { x + k }; f(1)", "let k = 1; let f = fn(x) { x + k }; f(1)"},
+		{"let f = fn(x) { fn(y) { x + y } }; f(1)", "let f = fn(x) { fn(y) { x + y } }; f(1)"},
+		{"let f = fn(x) { let y = x; y }; f(1)", "let f = fn(x) { let y = x; y }; f(1)"},
+		{"let f = fn(x) { x }; f(1, 2)", "let f = fn(x) { x }; f(1, 2)"},
+		{"let x = {}; let f = fn(h) { h.size }; f(x)", "let x = {}; let f = fn(h) { h.size }; x.size"},
+		{"let f = fn(h) { h.size }; f(x)", "let f = fn(h) { h.size }; f(x)"},
+		{"let f = fn(c, x) { if (c) { x } else { 0 } }; let g = fn(n) { f(true, n) }", "let f = fn(c, x) { if (c) { x } else { 0 } }; let g = fn(n) { n }"},
+	}
+
+	for _, test := range tests {
+		program := parse(t, test.input)
+		original := program.String()
+
+		optimized := Optimize(program).String()
+		expected := parse(t, test.expected).String()
+		if optimized != expected {
+			t.Errorf("Optimized %q incorrectly. Expected: %s. Got: %s", test.input, expected, optimized)
+		}
+		if program.String() != original {
+			t.Errorf("Optimizing %q changed the program. Expected: %s. Got: %s", test.input, original, program.String())
+		}
+	}
+}
+
+func TestOptimizedResults(t *testing.T) {
+	tests := []string{
+		"let seconds = 60 * 60 * 24 * 7; seconds / 7",
+		"let f = fn(n) { if (n < 2) { n } else { f(n - 1) + f(n - 2) } }; f(10)",
+		"let square = fn(x) { x * x }; let sum = fn(a, b) { a + b }; sum(square(3), square(4))",
+		"let x = 5; let inc = fn(n) { n + 1 }; [inc(x), inc(inc(x)), inc(-1)]",
+		"let config = {\"debug\": false}; if (false) { config.debug } else { config }",
+		"let pick = fn(a, b) { a ?? b }; [pick(null, 2), pick(1, 2)]",
+		"if (true) { let a = 1; let b = 2 }; a + b",
+		"let f = fn() { if (true) { return 1 }; 2 }; f()",
+		"let f = fn() { if (1 > 2) { 1 } }; f()",
+		"if (true) { 1 } else { 2 }; if (false) { 3 }",
+		"let f = fn(x) { x * 2 }; f(true)",
+		"let f = fn(a, b) { a }; f(1, 1 + true)",
+		"let f = fn(x) { x }; f(missing)",
+		"let pick = fn(c, x) { if (c) { x } else { 0 } }; pick(false, nope)",
+		"let pick = fn(a, b) { a ?? b }; pick(1, nope)",
+		"if (false) { let y = 1 }; let pick = fn(c, x) { if (c) { x } else { 0 } }; pick(false, y)",
+		"let pick = fn(c, x) { if (c) { x } else { 0 } }; let f = fn(n) { pick(false, n) }; let y = 2; [f(1), pick(true, y)]",
+		"1 + true",
+		"let g = fn() { h(1) }; let h = fn(x) { x + 1 }; g()",
+	}
+
+	for _, input := range tests {
+		expected := evaluator.Eval(object.NewEnvironment(), parse(t, input))
+		evaluated := evaluator.Eval(object.NewEnvironment(), Optimize(parse(t, input)))
+		if inspect(evaluated) != inspect(expected) {
+			t.Errorf("Optimized %q evaluated differently. Expected: %s. Got: %s", input, inspect(expected), inspect(evaluated))
+		}
+	}
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+	return obj.Inspect()
+}
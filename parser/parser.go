@@ -11,12 +11,14 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	NULLISH     // a ?? b
 	EQUALS      // == or !=
 	LESSGREATER // < or >
 	SUM         // + or -
 	PRODUCT     // * or /
 	PREFIX      // -x or !x
 	CALL        // func(x + y)
+	INDEX       // array[index]
 )
 
 type (
@@ -25,26 +27,42 @@ type (
 )
 
 var infixPrecedences = map[token.TokenType]int{
-	token.EQUAL:      EQUALS,
-	token.BANG_EQUAL: EQUALS,
-	token.LESS:       LESSGREATER,
-	token.GREATER:    LESSGREATER,
-	token.PLUS:       SUM,
-	token.MINUS:      SUM,
-	token.STAR:       PRODUCT,
-	token.SLASH:      PRODUCT,
-	token.FUNCTION:   CALL,
-	token.LPAREN:     CALL,
+	token.NULLISH:          NULLISH,
+	token.EQUAL:            EQUALS,
+	token.BANG_EQUAL:       EQUALS,
+	token.LESS:             LESSGREATER,
+	token.GREATER:          LESSGREATER,
+	token.PLUS:             SUM,
+	token.MINUS:            SUM,
+	token.STAR:             PRODUCT,
+	token.SLASH:            PRODUCT,
+	token.FUNCTION:         CALL,
+	token.LPAREN:           CALL,
+	token.DOT:              CALL,
+	token.QUESTION_DOT:     CALL,
+	token.LBRACKET:         INDEX,
+	token.QUESTION_BRACKET: INDEX,
+}
+
+// ParseError - A parser error (or warning) and the token it was found at
+type ParseError struct {
+	Token   token.Token
+	Message string
 }
 
 // Parser ...
 type Parser struct {
-	lexer  *lexer.Lexer
-	errors []string
+	lexer       *lexer.Lexer
+	errors      []string
+	parseErrors []ParseError
+	warnings    []ParseError
 
 	currToken token.Token
 	peekToken token.Token
 
+	// How many blocks enclose the current token. Exports are only allowed outside of blocks
+	blockDepth int
+
 	// Maps that associated a token to a parsing function
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
@@ -69,6 +87,14 @@ func New(l *lexer.Lexer) *Parser {
 	parser.registerPrefix(token.LPAREN, parser.parseGroupedExpression)
 	parser.registerPrefix(token.IF, parser.parseIfExpression)
 	parser.registerPrefix(token.FUNCTION, parser.parseFunctionLiteral)
+	parser.registerPrefix(token.IMPORT, parser.parseImportExpression)
+	parser.registerPrefix(token.LBRACKET, parser.parseArrayLiteral)
+	parser.registerPrefix(token.LBRACE, parser.parseHashLiteral)
+	parser.registerPrefix(token.NULL, parser.parseNullLiteral)
+	parser.registerPrefix(token.TRY, parser.parseTryExpression)
+	parser.registerPrefix(token.MATCH, parser.parseMatchExpression)
+	parser.registerPrefix(token.MACRO, parser.parseFunctionLiteral)
+	parser.registerPrefix(token.THROW, parser.parseThrowExpression)
 
 	parser.infixParseFns = make(map[token.TokenType]infixParseFn)
 	parser.registerInfix(token.PLUS, parser.parseInfixExpression)
@@ -80,6 +106,11 @@ func New(l *lexer.Lexer) *Parser {
 	parser.registerInfix(token.EQUAL, parser.parseInfixExpression)
 	parser.registerInfix(token.BANG_EQUAL, parser.parseInfixExpression)
 	parser.registerInfix(token.LPAREN, parser.parseFunctionCallExpression)
+	parser.registerInfix(token.NULLISH, parser.parseInfixExpression)
+	parser.registerInfix(token.DOT, parser.parseMemberExpression)
+	parser.registerInfix(token.QUESTION_DOT, parser.parseMemberExpression)
+	parser.registerInfix(token.LBRACKET, parser.parseIndexExpression)
+	parser.registerInfix(token.QUESTION_BRACKET, parser.parseIndexExpression)
 
 	return parser
 }
@@ -110,6 +141,7 @@ func (parser *Parser) ParseProgram() *ast.Program {
 		}
 		parser.nextToken()
 	}
+	program.Comments = parser.lexer.Comments()
 
 	return program
 }
@@ -120,6 +152,8 @@ func (parser *Parser) parseStatement() ast.Statement {
 		return parser.parseLetStatement()
 	case token.RETURN:
 		return parser.parseReturnStatement()
+	case token.EXPORT:
+		return parser.parseExportStatement()
 	default:
 		return parser.parseExpressionStatement()
 	}
@@ -143,6 +177,8 @@ func (parser *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: parser.currToken}
 	block.Statements = []ast.Statement{}
 
+	parser.blockDepth++
+	defer func() { parser.blockDepth-- }()
 	parser.nextToken()
 
 	for !parser.isCurrTokenType(token.RBRACE) && !parser.isCurrTokenType(token.EOF) {
@@ -154,6 +190,11 @@ func (parser *Parser) parseBlockStatement() *ast.BlockStatement {
 		parser.nextToken()
 	}
 
+	if parser.isCurrTokenType(token.EOF) {
+		parser.addError(parser.currToken, fmt.Sprintf("Expected token type %s, got end of input instead", token.RBRACE))
+	}
+	block.EndToken = parser.currToken
+
 	return block
 }
 
@@ -228,12 +269,26 @@ func (parser *Parser) parseGroupedExpression() ast.Expression {
 func (parser *Parser) parseLetStatement() ast.Statement {
 	statement := &ast.LetStatement{Token: parser.currToken}
 
-	if !parser.expectPeek(token.IDENT) {
-		parser.peekError(token.IDENT)
-		return nil
+	if parser.isPeekTokenType(token.LBRACKET) || parser.isPeekTokenType(token.LBRACE) {
+		parser.nextToken()
+		statement.Pattern = parser.parsePattern()
+		if statement.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !parser.expectPeek(token.IDENT) {
+			parser.peekError(token.IDENT)
+			return nil
+		}
+		statement.Name = &ast.Identifier{Token: parser.currToken, Value: parser.currToken.Literal}
 	}
 
-	statement.Name = &ast.Identifier{Token: parser.currToken, Value: parser.currToken.Literal}
+	if parser.isPeekTokenType(token.COLON) {
+		parser.nextToken()
+		if statement.Type = parser.parseTypeAnnotation(); statement.Type == nil {
+			return nil
+		}
+	}
 
 	if !parser.expectPeek(token.ASSIGN) {
 		parser.peekError(token.ASSIGN)
@@ -251,10 +306,53 @@ func (parser *Parser) parseLetStatement() ast.Statement {
 	return statement
 }
 
+func (parser *Parser) parseExportStatement() ast.Statement {
+	exportToken := parser.currToken
+	if parser.blockDepth > 0 {
+		parser.addError(exportToken, "Exports are only allowed at the top level of a module")
+	}
+
+	if !parser.expectPeek(token.LET) {
+		parser.peekError(token.LET)
+		return nil
+	}
+
+	statement := parser.parseLetStatement()
+	if let, ok := statement.(*ast.LetStatement); ok {
+		let.Exported = true
+	}
+	return statement
+}
+
+func (parser *Parser) parseImportExpression() ast.Expression {
+	expression := &ast.ImportExpression{Token: parser.currToken}
+
+	if !parser.expectPeek(token.STRING) {
+		parser.peekError(token.STRING)
+		return nil
+	}
+
+	expression.Path = &ast.StringLiteral{Token: parser.currToken, Value: parser.currToken.Literal}
+	return expression
+}
+
+func (parser *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	expression := &ast.MemberExpression{Token: parser.currToken, Object: object, Optional: parser.isCurrTokenType(token.QUESTION_DOT)}
+
+	if !parser.expectPeek(token.IDENT) {
+		parser.peekError(token.IDENT)
+		return nil
+	}
+
+	expression.Property = &ast.Identifier{Token: parser.currToken, Value: parser.currToken.Literal}
+	return expression
+}
+
 func (parser *Parser) parseIfExpression() ast.Expression {
 	expression := &ast.IfExpression{Token: parser.currToken}
 
 	if !parser.expectPeek(token.LPAREN) {
+		parser.peekError(token.LPAREN)
 		return nil
 	}
 
@@ -262,10 +360,12 @@ func (parser *Parser) parseIfExpression() ast.Expression {
 	expression.Condition = parser.parseExpression(LOWEST)
 
 	if !parser.expectPeek(token.RPAREN) {
+		parser.peekError(token.RPAREN)
 		return nil
 	}
 
 	if !parser.expectPeek(token.LBRACE) {
+		parser.peekError(token.LBRACE)
 		return nil
 	}
 
@@ -275,6 +375,7 @@ func (parser *Parser) parseIfExpression() ast.Expression {
 		parser.nextToken()
 
 		if !parser.expectPeek(token.LBRACE) {
+			parser.peekError(token.LBRACE)
 			return nil
 		}
 		expression.Alternative = parser.parseBlockStatement()
@@ -283,16 +384,202 @@ func (parser *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+func (parser *Parser) parseTryExpression() ast.Expression {
+	expression := &ast.TryExpression{Token: parser.currToken}
+
+	if !parser.expectPeek(token.LBRACE) {
+		parser.peekError(token.LBRACE)
+		return nil
+	}
+	expression.Block = parser.parseBlockStatement()
+
+	if parser.isPeekTokenType(token.CATCH) {
+		parser.nextToken()
+
+		if parser.isPeekTokenType(token.LPAREN) {
+			parser.nextToken()
+			if !parser.expectPeek(token.IDENT) {
+				parser.peekError(token.IDENT)
+				return nil
+			}
+			expression.Parameter = &ast.Identifier{Token: parser.currToken, Value: parser.currToken.Literal}
+			if !parser.expectPeek(token.RPAREN) {
+				parser.peekError(token.RPAREN)
+				return nil
+			}
+		}
+
+		if !parser.expectPeek(token.LBRACE) {
+			parser.peekError(token.LBRACE)
+			return nil
+		}
+		expression.Catch = parser.parseBlockStatement()
+	}
+
+	if parser.isPeekTokenType(token.FINALLY) {
+		parser.nextToken()
+		if !parser.expectPeek(token.LBRACE) {
+			parser.peekError(token.LBRACE)
+			return nil
+		}
+		expression.Finally = parser.parseBlockStatement()
+	}
+
+	if expression.Catch == nil && expression.Finally == nil {
+		parser.addError(expression.Token, "A try needs a catch or a finally block")
+		return nil
+	}
+	return expression
+}
+
+func (parser *Parser) parseMatchExpression() ast.Expression {
+	expression := &ast.MatchExpression{Token: parser.currToken}
+
+	if !parser.expectPeek(token.LPAREN) {
+		parser.peekError(token.LPAREN)
+		return nil
+	}
+	parser.nextToken()
+	expression.Value = parser.parseExpression(LOWEST)
+	if !parser.expectPeek(token.RPAREN) {
+		parser.peekError(token.RPAREN)
+		return nil
+	}
+	if !parser.expectPeek(token.LBRACE) {
+		parser.peekError(token.LBRACE)
+		return nil
+	}
+
+	for !parser.isPeekTokenType(token.RBRACE) {
+		parser.nextToken()
+		arm := parser.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		parser.checkReachable(expression.Arms, arm)
+		expression.Arms = append(expression.Arms, arm)
+
+		if !parser.isPeekTokenType(token.RBRACE) && !parser.expectPeek(token.COMMA) {
+			parser.peekError(token.COMMA)
+			return nil
+		}
+	}
+	parser.nextToken()
+	expression.EndToken = parser.currToken
+
+	if len(expression.Arms) == 0 {
+		parser.addError(expression.Token, "A match needs at least one arm")
+		return nil
+	}
+	return expression
+}
+
+func (parser *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Token: parser.currToken}
+
+	errors := len(parser.errors)
+	arm.Pattern = parser.parseExpression(LOWEST)
+	if len(parser.errors) > errors {
+		return nil
+	}
+	if !parser.checkPattern(arm.Pattern) {
+		parser.addError(arm.Token, fmt.Sprintf("Invalid pattern: %s", arm.Pattern))
+		return nil
+	}
+
+	if parser.isPeekTokenType(token.IF) {
+		parser.nextToken()
+		parser.nextToken()
+		arm.Guard = parser.parseExpression(LOWEST)
+	}
+
+	if !parser.expectPeek(token.ARROW) {
+		parser.peekError(token.ARROW)
+		return nil
+	}
+	parser.nextToken()
+	arm.Body = parser.parseExpression(LOWEST)
+	return arm
+}
+
+// checkPattern - Whether pattern can be matched against: a literal (a negative integer included), an
+// identifier or an array or hash literal of patterns whose keys are literals
+func (parser *Parser) checkPattern(pattern ast.Expression) bool {
+	switch pattern := pattern.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.BooleanLiteral, *ast.NullLiteral, *ast.Identifier:
+		return true
+	case *ast.PrefixExpression:
+		_, ok := pattern.Right.(*ast.IntegerLiteral)
+		return ok && pattern.Operator == "-"
+	case *ast.ArrayLiteral:
+		for _, element := range pattern.Elements {
+			if !parser.checkPattern(element) {
+				return false
+			}
+		}
+		return true
+	case *ast.HashLiteral:
+		for i, key := range pattern.Keys {
+			switch key.(type) {
+			case *ast.IntegerLiteral, *ast.StringLiteral, *ast.BooleanLiteral:
+			default:
+				return false
+			}
+			if !parser.checkPattern(pattern.Values[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// checkReachable - Warns when an earlier arm without a guard matches every value arm could match: it
+// binds or ignores the whole value, or has the same pattern
+func (parser *Parser) checkReachable(earlier []*ast.MatchArm, arm *ast.MatchArm) {
+	for _, previous := range earlier {
+		if previous.Guard != nil {
+			continue
+		}
+		if _, ok := previous.Pattern.(*ast.Identifier); ok {
+			parser.addWarning(arm.Token, fmt.Sprintf("Unreachable match arm: %s on line %d matches every value", previous.Pattern, previous.Line()))
+			return
+		}
+		if previous.Pattern.String() == arm.Pattern.String() {
+			parser.addWarning(arm.Token, fmt.Sprintf("Unreachable match arm: %s is already matched on line %d", arm.Pattern, previous.Line()))
+			return
+		}
+	}
+}
+
+func (parser *Parser) parseThrowExpression() ast.Expression {
+	expression := &ast.ThrowExpression{Token: parser.currToken}
+
+	parser.nextToken()
+	expression.Value = parser.parseExpression(LOWEST)
+	return expression
+}
+
 func (parser *Parser) parseFunctionLiteral() ast.Expression {
 	functionLiteral := &ast.FunctionLiteral{Token: parser.currToken}
 
 	if !parser.expectPeek(token.LPAREN) {
+		parser.peekError(token.LPAREN)
 		return nil
 	}
 
-	functionLiteral.Parameters = parser.parseFunctionParameters()
+	functionLiteral.Parameters, functionLiteral.ParameterTypes = parser.parseFunctionParameters()
+
+	if parser.isPeekTokenType(token.RARROW) {
+		parser.nextToken()
+		if functionLiteral.ReturnType = parser.parseTypeAnnotation(); functionLiteral.ReturnType == nil {
+			return nil
+		}
+	}
 
 	if !parser.expectPeek(token.LBRACE) {
+		parser.peekError(token.LBRACE)
 		return nil
 	}
 
@@ -301,31 +588,157 @@ func (parser *Parser) parseFunctionLiteral() ast.Expression {
 	return functionLiteral
 }
 
-func (parser *Parser) parseFunctionParameters() []*ast.Identifier {
-	parameters := []*ast.Identifier{}
+// parseFunctionParameters - The parameters of a function literal and their types (nil for parameters
+// without an annotation)
+func (parser *Parser) parseFunctionParameters() ([]ast.Pattern, []*ast.TypeAnnotation) {
+	parameters := []ast.Pattern{}
+	types := []*ast.TypeAnnotation{}
 
 	if parser.isPeekTokenType(token.RPAREN) {
 		parser.nextToken()
-		return parameters
+		return parameters, types
 	}
 
 	parser.nextToken()
 
-	firstParam := &ast.Identifier{Token: parser.currToken, Value: parser.currToken.Literal}
-	parameters = append(parameters, firstParam)
+	for {
+		param := parser.parsePattern()
+		if param == nil {
+			return nil, nil
+		}
+		parameters = append(parameters, param)
 
-	for parser.isPeekTokenType(token.COMMA) {
+		var paramType *ast.TypeAnnotation
+		if parser.isPeekTokenType(token.COLON) {
+			parser.nextToken()
+			if paramType = parser.parseTypeAnnotation(); paramType == nil {
+				return nil, nil
+			}
+		}
+		types = append(types, paramType)
+
+		if !parser.isPeekTokenType(token.COMMA) {
+			break
+		}
 		parser.nextToken()
 		parser.nextToken()
-		param := &ast.Identifier{Token: parser.currToken, Value: parser.currToken.Literal}
-		parameters = append(parameters, param)
 	}
 
 	if !parser.expectPeek(token.RPAREN) {
+		parser.peekError(token.RPAREN)
+		return nil, nil
+	}
+
+	return parameters, types
+}
+
+// parseTypeAnnotation - The type named after the ":" or "->" at the current token
+func (parser *Parser) parseTypeAnnotation() *ast.TypeAnnotation {
+	switch parser.peekToken.Type {
+	case token.IDENT, token.NULL, token.FUNCTION:
+		parser.nextToken()
+		return &ast.TypeAnnotation{Token: parser.currToken, Name: parser.currToken.Literal}
+	default:
+		parser.addError(parser.peekToken, fmt.Sprintf("Expected a type, got %s instead", parser.peekToken.Type))
 		return nil
 	}
+}
 
-	return parameters
+// parsePattern - A name, or an array or hash pattern destructuring the value bound to it
+func (parser *Parser) parsePattern() ast.Pattern {
+	switch parser.currToken.Type {
+	case token.IDENT:
+		return &ast.Identifier{Token: parser.currToken, Value: parser.currToken.Literal}
+	case token.LBRACKET:
+		pattern := &ast.ArrayPattern{Token: parser.currToken}
+		if !parser.parsePatternElements(pattern.Token, token.RBRACKET, &pattern.Elements, &pattern.Rest) {
+			return nil
+		}
+		return pattern
+	case token.LBRACE:
+		pattern := &ast.HashPattern{Token: parser.currToken}
+		if !parser.parsePatternElements(pattern.Token, token.RBRACE, &pattern.Elements, &pattern.Rest) {
+			return nil
+		}
+		return pattern
+	default:
+		parser.addError(parser.currToken, fmt.Sprintf("Expected a name or a destructuring pattern, got %s instead", parser.currToken.Type))
+		return nil
+	}
+}
+
+// parsePatternElements - The elements of the pattern opened by open, up to end. A ...rest can only come last
+func (parser *Parser) parsePatternElements(open token.Token, end token.TokenType, elements *[]*ast.PatternElement, rest **ast.Identifier) bool {
+	for !parser.isPeekTokenType(end) {
+		parser.nextToken()
+
+		if parser.isCurrTokenType(token.ELLIPSIS) {
+			if !parser.expectPeek(token.IDENT) {
+				parser.peekError(token.IDENT)
+				return false
+			}
+			*rest = &ast.Identifier{Token: parser.currToken, Value: parser.currToken.Literal}
+			break
+		}
+
+		element := &ast.PatternElement{Token: parser.currToken}
+		if open.Type == token.LBRACE && !parser.parsePatternKey(element) {
+			return false
+		}
+		if element.Pattern == nil {
+			if element.Pattern = parser.parsePattern(); element.Pattern == nil {
+				return false
+			}
+		}
+
+		if parser.isPeekTokenType(token.ASSIGN) {
+			parser.nextToken()
+			parser.nextToken()
+			element.Default = parser.parseExpression(LOWEST)
+		}
+		*elements = append(*elements, element)
+
+		if !parser.isPeekTokenType(end) && !parser.expectPeek(token.COMMA) {
+			parser.peekError(token.COMMA)
+			return false
+		}
+	}
+
+	if !parser.expectPeek(end) {
+		parser.peekError(end)
+		return false
+	}
+	return true
+}
+
+// parsePatternKey - The key of a hash pattern element: a name, which also binds the value unless it is
+// followed by ": pattern", or a string followed by ": pattern"
+func (parser *Parser) parsePatternKey(element *ast.PatternElement) bool {
+	switch parser.currToken.Type {
+	case token.IDENT:
+		element.Key = parser.currToken.Literal
+		if !parser.isPeekTokenType(token.COLON) {
+			element.Pattern = &ast.Identifier{Token: parser.currToken, Value: parser.currToken.Literal}
+			return true
+		}
+	case token.STRING:
+		element.Key = parser.currToken.Literal
+		if element.Key == "" {
+			parser.addError(parser.currToken, "Empty keys cannot be destructured")
+			return false
+		}
+		if !parser.isPeekTokenType(token.COLON) {
+			parser.peekError(token.COLON)
+			return false
+		}
+	default:
+		parser.addError(parser.currToken, fmt.Sprintf("Expected a key to destructure, got %s instead", parser.currToken.Type))
+		return false
+	}
+
+	parser.nextToken()
+	parser.nextToken()
+	return true
 }
 
 func (parser *Parser) parseFunctionCallExpression(functionName ast.Expression) ast.Expression {
@@ -335,27 +748,80 @@ func (parser *Parser) parseFunctionCallExpression(functionName ast.Expression) a
 }
 
 func (parser *Parser) parseFunctionArguments() []ast.Expression {
-	args := []ast.Expression{}
+	return parser.parseExpressionList(token.RPAREN)
+}
 
-	if parser.isPeekTokenType(token.RPAREN) {
+// parseExpressionList - Parses comma separated expressions up to the end token
+func (parser *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
+	list := []ast.Expression{}
+
+	if parser.isPeekTokenType(end) {
 		parser.nextToken()
-		return args
+		return list
 	}
 
 	parser.nextToken()
-	args = append(args, parser.parseExpression(LOWEST))
+	list = append(list, parser.parseExpression(LOWEST))
 
 	for parser.isPeekTokenType(token.COMMA) {
 		parser.nextToken()
 		parser.nextToken()
-		args = append(args, parser.parseExpression(LOWEST))
+		list = append(list, parser.parseExpression(LOWEST))
 	}
 
-	if !parser.expectPeek(token.RPAREN) {
+	if !parser.expectPeek(end) {
+		parser.peekError(end)
 		return nil
 	}
 
-	return args
+	return list
+}
+
+func (parser *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: parser.currToken}
+	array.Elements = parser.parseExpressionList(token.RBRACKET)
+	return array
+}
+
+func (parser *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: parser.currToken, Keys: []ast.Expression{}, Values: []ast.Expression{}}
+
+	for !parser.isPeekTokenType(token.RBRACE) {
+		parser.nextToken()
+		key := parser.parseExpression(LOWEST)
+
+		if !parser.expectPeek(token.COLON) {
+			parser.peekError(token.COLON)
+			return nil
+		}
+
+		parser.nextToken()
+		value := parser.parseExpression(LOWEST)
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+
+		if !parser.isPeekTokenType(token.RBRACE) && !parser.expectPeek(token.COMMA) {
+			parser.peekError(token.COMMA)
+			return nil
+		}
+	}
+
+	parser.nextToken()
+	return hash
+}
+
+func (parser *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	expression := &ast.IndexExpression{Token: parser.currToken, Left: left, Optional: parser.isCurrTokenType(token.QUESTION_BRACKET)}
+
+	parser.nextToken()
+	expression.Index = parser.parseExpression(LOWEST)
+
+	if !parser.expectPeek(token.RBRACKET) {
+		parser.peekError(token.RBRACKET)
+		return nil
+	}
+
+	return expression
 }
 
 func (parser *Parser) parseReturnStatement() ast.Statement {
@@ -383,8 +849,8 @@ func (parser *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(parser.currToken.Literal, 0, 64)
 	if err != nil {
-		errorMsg := fmt.Sprintf("Could not parse %q as an integer", literal.Value)
-		parser.errors = append(parser.errors, errorMsg)
+		errorMsg := fmt.Sprintf("Could not parse %q as an integer", parser.currToken.Literal)
+		parser.addError(parser.currToken, errorMsg)
 		return nil
 	}
 
@@ -400,6 +866,10 @@ func (parser *Parser) parseBooleanLiteral() ast.Expression {
 	return &ast.BooleanLiteral{Token: parser.currToken, Value: parser.isCurrTokenType(token.TRUE)}
 }
 
+func (parser *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: parser.currToken}
+}
+
 // Helper functions
 
 func (parser *Parser) isCurrTokenType(expectedTokenType token.TokenType) bool {
@@ -425,6 +895,14 @@ func (parser *Parser) peekPrecedence() int {
 	return LOWEST
 }
 
+// Precedence - The precedence of an infix operator token type, or LOWEST if it is not one
+func Precedence(tokenType token.TokenType) int {
+	if precedence, ok := infixPrecedences[tokenType]; ok {
+		return precedence
+	}
+	return LOWEST
+}
+
 func (parser *Parser) currPrecedence() int {
 	if precedence, ok := infixPrecedences[parser.currToken.Type]; ok {
 		return precedence
@@ -437,14 +915,34 @@ func (parser *Parser) currPrecedence() int {
 func (parser *Parser) Errors() []string {
 	return parser.errors
 }
+
+// ParseErrors - The same errors as Errors, with the token each one was found at
+func (parser *Parser) ParseErrors() []ParseError {
+	return parser.parseErrors
+}
+
+// Warnings - Problems that do not stop the program from running, like unreachable match arms
+func (parser *Parser) Warnings() []ParseError {
+	return parser.warnings
+}
+
+func (parser *Parser) addWarning(tok token.Token, message string) {
+	parser.warnings = append(parser.warnings, ParseError{Token: tok, Message: message})
+}
+
+func (parser *Parser) addError(tok token.Token, errorMsg string) {
+	parser.errors = append(parser.errors, errorMsg)
+	parser.parseErrors = append(parser.parseErrors, ParseError{Token: tok, Message: errorMsg})
+}
+
 func (parser *Parser) peekError(expectedTokenType token.TokenType) {
 	errorMsg := fmt.Sprintf("Expected token type %s, got %s instead", expectedTokenType, parser.peekToken.Type)
-	parser.errors = append(parser.errors, errorMsg)
+	parser.addError(parser.peekToken, errorMsg)
 	return
 }
 
 func (parser *Parser) noPrefixParseFnError(tokenType token.TokenType) {
 	msg := fmt.Sprintf("No prefix parse function found for tokentype: %s", tokenType)
-	parser.errors = append(parser.errors, msg)
+	parser.addError(parser.currToken, msg)
 	return
 }
@@ -5,6 +5,7 @@ import (
 	"monkeylang/ast"
 	"monkeylang/lexer"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -468,6 +469,10 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a + b + c * d / f + g)",
 			"add((((a + b) + ((c * d) / f)) + g))",
 		},
+		{
+			"-m.x * m.f(a).b",
+			"((-m.x) * m.f(a).b)",
+		},
 	}
 
 	for _, test := range tests {
@@ -603,10 +608,10 @@ func TestFunctionLiteral(t *testing.T) {
 		t.Fatalf("Number of parameters is incorrect. Expected: 2. Got: %d", len(expression.Parameters))
 	}
 
-	if !testLiteralExpression(t, expression.Parameters[0], "x") {
+	if !testLiteralExpression(t, expression.Parameters[0].(*ast.Identifier), "x") {
 		return
 	}
-	if !testLiteralExpression(t, expression.Parameters[1], "y") {
+	if !testLiteralExpression(t, expression.Parameters[1].(*ast.Identifier), "y") {
 		return
 	}
 
@@ -649,7 +654,7 @@ func TestFunctionParameters(t *testing.T) {
 		}
 
 		for i, ident := range test.expectedParameters {
-			testLiteralExpression(t, function.Parameters[i], ident)
+			testLiteralExpression(t, function.Parameters[i].(*ast.Identifier), ident)
 		}
 	}
 }
@@ -793,3 +798,303 @@ func testInfixExpression(t *testing.T, expression ast.Expression, left interface
 
 	return true
 }
+
+func TestArrayHashAndIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[]", "[]"},
+		{"[1, 2 * 2, x]", "[1, (2 * 2), x]"},
+		{"{}", "{}"},
+		{`{"one": 1, two: 1 + 1, 3: [3]}`, "{one: 1, two: (1 + 1), 3: [3]}"},
+		{"a[1 + 1]", "(a[(1 + 1)])"},
+		{"a * [1, 2][b * c] * d", "((a * ([1, 2][(b * c)])) * d)"},
+		{"add(a[0], m.list[1])(2)", "add((a[0]), (m.list[1]))(2)"},
+	}
+
+	for _, test := range tests {
+		parser := New(lexer.New(test.input))
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		if program.String() != test.expected {
+			t.Errorf("Program for %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, program.String())
+		}
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	input := `export let math = import "lib/math";`
+
+	parser := New(lexer.New(input))
+	program := parser.ParseProgram()
+	checkParseErrors(t, parser)
+
+	statement, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok || !statement.Exported {
+		t.Fatalf("Statement is incorrect. Expected: an exported *ast.LetStatement. Got: %#v", program.Statements[0])
+	}
+
+	importExpression, ok := statement.Value.(*ast.ImportExpression)
+	if !ok {
+		t.Fatalf("Statement.Value type is incorrect. Expected: *ast.ImportExpression. Got: %T", statement.Value)
+	}
+	if importExpression.Path.Value != "lib/math" {
+		t.Errorf("ImportExpression.Path is incorrect. Expected: lib/math. Got: %s", importExpression.Path.Value)
+	}
+
+	if program.String() != input {
+		t.Errorf("Program.String() is incorrect. Expected: %q. Got: %q", input, program.String())
+	}
+}
+
+func TestImportExportErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"import math", "Expected token type STRING, got IDENT instead"},
+		{"let f = fn() { export let x = 1; }", "Exports are only allowed at the top level of a module"},
+		{"export fn() {}", "Expected token type LET, got FUNCTION instead"},
+		{"m.1", "Expected token type IDENT, got INT instead"},
+	}
+
+	for _, test := range tests {
+		parser := New(lexer.New(test.input))
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+		if len(errors) == 0 || errors[0] != test.expected {
+			t.Errorf("Errors for %q are incorrect. Expected: %q first. Got: %q", test.input, test.expected, errors)
+		}
+	}
+}
+
+func TestTryAndThrowExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { f() } catch (e) { e.message }", "try f() catch (e) e.message"},
+		{"try { f() } catch { 0 }", "try f() catch 0"},
+		{"try { f() } finally { close() }", "try f() finally close()"},
+		{"try { f() } catch (e) { throw e } finally { 1 }", "try f() catch (e) throw e finally 1"},
+		{`throw "a" + b`, "throw (a + b)"},
+		{`raise error("x")`, "raise error(x)"},
+	}
+
+	for _, test := range tests {
+		parser := New(lexer.New(test.input))
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		if program.String() != test.expected {
+			t.Errorf("Program for %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { f() }", "A try needs a catch or a finally block"},
+		{"try { f() } catch (1) {}", "Expected token type IDENT, got INT instead"},
+		{"try f()", "Expected token type {, got IDENT instead"},
+	}
+
+	for _, test := range errorTests {
+		parser := New(lexer.New(test.input))
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+		if len(errors) == 0 || errors[0] != test.expected {
+			t.Errorf("Errors for %q are incorrect. Expected: %q first. Got: %q", test.input, test.expected, errors)
+		}
+	}
+}
+
+func TestNullSafeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"null", "null"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a == null ?? b + 1", "((a == null) ?? (b + 1))"},
+		{"a?.b.c", "a?.b.c"},
+		{"a?[1 + 1]", "(a?[(1 + 1)])"},
+		{"m?.list?[0] ?? f(x)?.y", "((m?.list?[0]) ?? f(x)?.y)"},
+	}
+
+	for _, test := range tests {
+		parser := New(lexer.New(test.input))
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		if program.String() != test.expected {
+			t.Errorf("Program for %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, program.String())
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { 1 => a, _ => b }", "match (x) { 1 => a, _ => b }"},
+		{"match (f(x)) { -1 => 0, n if n > 0 => n * 2, }", "match (f(x)) { (-1) => 0, n if (n > 0) => (n * 2) }"},
+		{`match (p) { [a, [b, _]] => a, {"x": 0, "y": y} => {"y": y}, null => true }`, `match (p) { [a, [b, _]] => a, {x: 0, y: y} => {y: y}, null => true }`},
+	}
+
+	for _, test := range tests {
+		parser := New(lexer.New(test.input))
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		if program.String() != test.expected {
+			t.Errorf("Program for %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, program.String())
+		}
+		if len(parser.Warnings()) != 0 {
+			t.Errorf("Warnings for %q are incorrect. Expected: none. Got: %v", test.input, parser.Warnings())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) {}", "A match needs at least one arm"},
+		{"match (x) { a + 1 => a }", "Invalid pattern: (a + 1)"},
+		{"match (x) { {k: 1} => 1 }", "Invalid pattern: {k: 1}"},
+		{"match (x) { 1 => 1 2 => 2 }", "Expected token type ,, got INT instead"},
+		{"match (x) { 1: 1 }", "Expected token type =>, got : instead"},
+		{"match (x) { 1 -> 1 }", "Expected token type =>, got -> instead"},
+		{"match x { 1 => 1 }", "Expected token type (, got IDENT instead"},
+	}
+
+	for _, test := range errorTests {
+		parser := New(lexer.New(test.input))
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+		if len(errors) == 0 || errors[0] != test.expected {
+			t.Errorf("Errors for %q are incorrect. Expected: %q first. Got: %q", test.input, test.expected, errors)
+		}
+	}
+
+	warningTests := []struct {
+		input    string
+		expected []string
+	}{
+		{"match (x) { 1 => a, 1 => b }", []string{"1:21 Unreachable match arm: 1 is already matched on line 1"}},
+		{"match (x) { 1 if y => a, 1 => b }", []string{}},
+		{"match (x) {\n  n => n,\n  0 => 0,\n  _ => 1\n}", []string{"3:3 Unreachable match arm: n on line 2 matches every value", "4:3 Unreachable match arm: n on line 2 matches every value"}},
+	}
+
+	for _, test := range warningTests {
+		parser := New(lexer.New(test.input))
+		parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		got := []string{}
+		for _, warning := range parser.Warnings() {
+			got = append(got, fmt.Sprintf("%d:%d %s", warning.Token.Line, warning.Token.Column, warning.Message))
+		}
+		if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("Warnings for %q are incorrect. Expected: %q. Got: %q", test.input, test.expected, got)
+		}
+	}
+}
+
+func TestDestructuringPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b] = xs;", "let [a, b] = xs;"},
+		{"let [first, ...rest] = xs;", "let [first, ...rest] = xs;"},
+		{"let [a, [b, c], d = 1 + 1] = xs;", "let [a, [b, c], d = (1 + 1)] = xs;"},
+		{"let {name, age} = person;", "let {name, age} = person;"},
+		{`let {name: n, "full name": full, pos: [x, y], size = 0, ...others} = p;`, `let {name: n, "full name": full, pos: [x, y], size = 0, ...others} = p;`},
+		{"let [] = xs; let {} = h;", "let [] = xs;let {} = h;"},
+		{"fn([a, b], {c}) { a }", "fn( [a, b],{c}) a"},
+	}
+
+	for _, test := range tests {
+		parser := New(lexer.New(test.input))
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		if program.String() != test.expected {
+			t.Errorf("Program for %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, 1] = xs;", "Expected a name or a destructuring pattern, got INT instead"},
+		{"let [...rest, a] = xs;", "Expected token type ], got , instead"},
+		{"let [a b] = xs;", "Expected token type ,, got IDENT instead"},
+		{"let {1: a} = h;", "Expected a key to destructure, got INT instead"},
+		{`let {"a"} = h;`, "Expected token type :, got } instead"},
+		{`let {"": a} = h;`, "Empty keys cannot be destructured"},
+		{"fn(a, 1) { a }", "Expected a name or a destructuring pattern, got INT instead"},
+	}
+
+	for _, test := range errorTests {
+		parser := New(lexer.New(test.input))
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+		if len(errors) == 0 || errors[0] != test.expected {
+			t.Errorf("Errors for %q are incorrect. Expected: %q first. Got: %q", test.input, test.expected, errors)
+		}
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let [a, b]: array = xs;", "let [a, b]: array = xs;"},
+		{"fn(a: string, b) -> int { len(a) }", "fn( a: string,b) -> int len(a)"},
+		{"fn({name}: hash, f: fn) -> null { f(name) }", "fn( {name}: hash,f: fn) -> null f(name)"},
+		{"let f = fn() -> bool { true };", "let f = fn( ) -> bool true;"},
+	}
+
+	for _, test := range tests {
+		parser := New(lexer.New(test.input))
+		program := parser.ParseProgram()
+		checkParseErrors(t, parser)
+
+		if program.String() != test.expected {
+			t.Errorf("Program for %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, program.String())
+		}
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: = 5;", "Expected a type, got = instead"},
+		{"let x: int;", "Expected token type =, got ; instead"},
+		{"fn(a: 1) { a }", "Expected a type, got INT instead"},
+		{"fn(a) -> { a }", "Expected a type, got { instead"},
+	}
+
+	for _, test := range errorTests {
+		parser := New(lexer.New(test.input))
+		parser.ParseProgram()
+
+		errors := parser.Errors()
+		if len(errors) == 0 || errors[0] != test.expected {
+			t.Errorf("Errors for %q are incorrect. Expected: %q first. Got: %q", test.input, test.expected, errors)
+		}
+	}
+}
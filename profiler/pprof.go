@@ -0,0 +1,158 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// Field numbers of the messages of the pprof profile format (profile.proto of github.com/google/pprof)
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID       = 1
+	functionName     = 2
+	functionFilename = 4
+)
+
+// WriteProfile - Writes the samples to out as a gzipped pprof profile, which "go tool pprof" can read. Each
+// sample has two values: the number of events measured with its stack ("samples/count") and the time
+// spent ("time/nanoseconds")
+func (profiler *Profiler) WriteProfile(out io.Writer) error {
+	profile := &protoMessage{}
+	table := map[string]int64{}
+	index := func(str string) int64 {
+		if i, ok := table[str]; ok {
+			return i
+		}
+		table[str] = int64(len(table))
+		profile.bytes(profileStringTable, []byte(str))
+		return table[str]
+	}
+	index("")
+
+	valueType := func(field int, typ string, unit string) {
+		message := &protoMessage{}
+		message.int(valueTypeType, index(typ))
+		message.int(valueTypeUnit, index(unit))
+		profile.bytes(field, message.Bytes())
+	}
+	valueType(profileSampleType, "samples", "count")
+	valueType(profileSampleType, "time", "nanoseconds")
+
+	functions := map[Function]uint64{}
+	locations := map[Location]uint64{}
+	for _, sample := range profiler.Samples() {
+		ids := []uint64{}
+		for _, location := range sample.Stack {
+			id, ok := locations[location]
+			if !ok {
+				id = uint64(len(locations) + 1)
+				locations[location] = id
+				profile.bytes(profileLocation, profiler.location(location, id, functions, profile, index))
+			}
+			ids = append(ids, id)
+		}
+
+		message := &protoMessage{}
+		message.uints(sampleLocationID, ids)
+		message.uints(sampleValue, []uint64{uint64(sample.Count), uint64(sample.Time)})
+		profile.bytes(profileSample, message.Bytes())
+	}
+
+	profile.int(profileTimeNanos, profiler.start.UnixNano())
+	profile.int(profileDurationNanos, int64(profiler.duration))
+	valueType(profilePeriodType, "time", "nanoseconds")
+	profile.int(profilePeriod, 1)
+
+	writer := gzip.NewWriter(out)
+	if _, err := writer.Write(profile.Bytes()); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// location - The Location message of location, adding the Function message of its function to profile
+// the first time it is seen
+func (profiler *Profiler) location(location Location, id uint64, functions map[Function]uint64, profile *protoMessage, index func(string) int64) []byte {
+	function, ok := functions[location.Function]
+	if !ok {
+		function = uint64(len(functions) + 1)
+		functions[location.Function] = function
+
+		message := &protoMessage{}
+		message.int(functionID, int64(function))
+		message.int(functionName, index(location.Function.String()))
+		if !location.Builtin {
+			message.int(functionFilename, index(profiler.File))
+		}
+		profile.bytes(profileFunction, message.Bytes())
+	}
+
+	line := &protoMessage{}
+	line.int(lineFunctionID, int64(function))
+	line.int(lineLine, int64(location.Line))
+
+	message := &protoMessage{}
+	message.int(locationID, int64(id))
+	message.bytes(locationLine, line.Bytes())
+	return message.Bytes()
+}
+
+// protoMessage - Encodes a protocol buffers message
+type protoMessage struct {
+	bytes.Buffer
+}
+
+func (message *protoMessage) varint(value uint64) {
+	for value >= 0x80 {
+		message.WriteByte(byte(value) | 0x80)
+		value >>= 7
+	}
+	message.WriteByte(byte(value))
+}
+
+// int - Writes an integer field. Zero values are left out, as they are the default
+func (message *protoMessage) int(field int, value int64) {
+	if value == 0 {
+		return
+	}
+	message.varint(uint64(field) << 3)
+	message.varint(uint64(value))
+}
+
+// uints - Writes a packed repeated integer field
+func (message *protoMessage) uints(field int, values []uint64) {
+	packed := &protoMessage{}
+	for _, value := range values {
+		packed.varint(value)
+	}
+	message.bytes(field, packed.Bytes())
+}
+
+// bytes - Writes a length delimited field: a string, an embedded message or a packed repeated field
+func (message *protoMessage) bytes(field int, value []byte) {
+	message.varint(uint64(field)<<3 | 2)
+	message.varint(uint64(len(value)))
+	message.Write(value)
+}
@@ -0,0 +1,296 @@
+package profiler
+
+import (
+	"fmt"
+	"io"
+	"monkeylang/ast"
+	"monkeylang/object"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SCRIPT - The name of the function standing for the top level code of the program
+const SCRIPT = "<script>"
+
+// Function - A function of the profiled program, or a builtin it called
+type Function struct {
+	Name    string
+	Builtin bool
+}
+
+func (function Function) String() string {
+	if function.Builtin {
+		return function.Name + " (builtin)"
+	}
+	return function.Name
+}
+
+// Location - A line of a function. The line of a builtin is the line it was called from
+type Location struct {
+	Function
+	Line int
+}
+
+// Sample - The time spent with the same call stack
+type Sample struct {
+	Stack []Location // innermost first
+	Count int64      // the number of times the program ran with this stack, between two events
+	Time  time.Duration
+}
+
+// Profiler - Measures the time a program spends in each function and line as an object.BuiltinObserver.
+// Whenever a statement starts or a function (or builtin) is called or returns, the time since the previous
+// event is added to the current call stack and its innermost line, so every moment between Start and Stop
+// is accounted for exactly once. Calls made in tail position replace the frame of their caller
+//
+// A Profiler must be set as the Observer of the root Environment before the program is evaluated. Lines of
+// imported modules are reported like those of the profiled file
+type Profiler struct {
+	File string // the file of the profiled program, written in the pprof profile
+
+	stack    []Location // outermost first
+	samples  map[string]*Sample
+	order    []string // the keys of samples in the order they were first seen
+	calls    map[Function]int
+	start    time.Time
+	last     time.Time
+	duration time.Duration
+	now      func() time.Time
+}
+
+// New - Creates a profiler for the program of file
+func New(file string) *Profiler {
+	return &Profiler{
+		File:    file,
+		stack:   []Location{{Function: Function{Name: SCRIPT}}},
+		samples: make(map[string]*Sample),
+		calls:   make(map[Function]int),
+		now:     time.Now,
+	}
+}
+
+// Start - Starts measuring, right before the program is evaluated
+func (profiler *Profiler) Start() {
+	profiler.start = profiler.now()
+	profiler.last = profiler.start
+}
+
+// Stop - Stops measuring, right after the program has been evaluated
+func (profiler *Profiler) Stop() {
+	profiler.charge()
+	profiler.duration = profiler.last.Sub(profiler.start)
+}
+
+// charge - Adds the time since the previous event to the current call stack
+func (profiler *Profiler) charge() {
+	now := profiler.now()
+	elapsed := now.Sub(profiler.last)
+	profiler.last = now
+
+	stack := make([]Location, len(profiler.stack))
+	keys := make([]string, len(profiler.stack))
+	for i, location := range profiler.stack {
+		stack[len(stack)-1-i] = location
+		keys[len(keys)-1-i] = fmt.Sprintf("%s:%t:%d", location.Name, location.Builtin, location.Line)
+	}
+	key := strings.Join(keys, "\n")
+
+	sample, ok := profiler.samples[key]
+	if !ok {
+		sample = &Sample{Stack: stack}
+		profiler.samples[key] = sample
+		profiler.order = append(profiler.order, key)
+	}
+	sample.Count++
+	sample.Time += elapsed
+}
+
+func (profiler *Profiler) push(location Location) {
+	profiler.calls[location.Function]++
+	profiler.stack = append(profiler.stack, location)
+}
+
+func (profiler *Profiler) pop() {
+	if len(profiler.stack) > 1 {
+		profiler.stack = profiler.stack[:len(profiler.stack)-1]
+	}
+}
+
+// Statement - Moves the current function to the line of statement. Implements object.Observer
+func (profiler *Profiler) Statement(env *object.Environment, statement ast.Statement) {
+	profiler.charge()
+	profiler.stack[len(profiler.stack)-1].Line = statement.Line()
+}
+
+// Call - Enters function. Implements object.Observer
+func (profiler *Profiler) Call(env *object.Environment, call *ast.CallExpression, function *object.Function, args []object.Object) {
+	profiler.charge()
+	profiler.push(Location{Function: Function{Name: name(call, "<anonymous>")}, Line: function.Body.Line()})
+}
+
+// Return - Leaves the current function. Implements object.Observer
+func (profiler *Profiler) Return(function *object.Function, result object.Object) {
+	profiler.charge()
+	profiler.pop()
+}
+
+// CallBuiltin - Enters builtin. Implements object.BuiltinObserver
+func (profiler *Profiler) CallBuiltin(env *object.Environment, call *ast.CallExpression, builtin *object.Builtin, args []object.Object) {
+	profiler.charge()
+	location := Location{Function: Function{Name: name(call, "<builtin>"), Builtin: true}}
+	if call != nil {
+		location.Line = call.Line()
+	}
+	profiler.push(location)
+}
+
+// ReturnBuiltin - Leaves the current builtin. Implements object.BuiltinObserver
+func (profiler *Profiler) ReturnBuiltin(builtin *object.Builtin, result object.Object) {
+	profiler.charge()
+	profiler.pop()
+}
+
+// name - The name of the function call calls, or unknown when it is not called by name
+func name(call *ast.CallExpression, unknown string) string {
+	if call == nil {
+		return unknown
+	}
+	switch function := call.Function.(type) {
+	case *ast.Identifier:
+		return function.Value
+	case *ast.MemberExpression:
+		return function.String()
+	}
+	return unknown
+}
+
+// Samples - The time spent with each call stack, in the order the stacks were first seen
+func (profiler *Profiler) Samples() []*Sample {
+	samples := make([]*Sample, 0, len(profiler.order))
+	for _, key := range profiler.order {
+		samples = append(samples, profiler.samples[key])
+	}
+	return samples
+}
+
+// Duration - The time between Start and Stop
+func (profiler *Profiler) Duration() time.Duration {
+	return profiler.duration
+}
+
+// FunctionStats - The time spent in a function: Flat in its own code, Cumulative including the functions
+// it called
+type FunctionStats struct {
+	Function
+	Flat       time.Duration
+	Cumulative time.Duration
+	Calls      int
+}
+
+// LineStats - The time spent running a line of a function, not including the functions it called
+type LineStats struct {
+	Location
+	Flat time.Duration
+}
+
+// Functions - The time spent in each function and builtin, by decreasing flat time
+func (profiler *Profiler) Functions() []FunctionStats {
+	stats := map[Function]*FunctionStats{}
+	get := func(function Function) *FunctionStats {
+		if stats[function] == nil {
+			stats[function] = &FunctionStats{Function: function, Calls: profiler.calls[function]}
+		}
+		return stats[function]
+	}
+
+	for _, sample := range profiler.Samples() {
+		get(sample.Stack[0].Function).Flat += sample.Time
+		seen := map[Function]bool{}
+		for _, location := range sample.Stack {
+			if !seen[location.Function] {
+				seen[location.Function] = true
+				get(location.Function).Cumulative += sample.Time
+			}
+		}
+	}
+
+	functions := make([]FunctionStats, 0, len(stats))
+	for _, stat := range stats {
+		functions = append(functions, *stat)
+	}
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].Flat != functions[j].Flat {
+			return functions[i].Flat > functions[j].Flat
+		}
+		return functions[i].String() < functions[j].String()
+	})
+	return functions
+}
+
+// Lines - The time spent on each line of the program's functions, by decreasing time. The time spent in
+// builtins is not included
+func (profiler *Profiler) Lines() []LineStats {
+	stats := map[Location]time.Duration{}
+	for _, sample := range profiler.Samples() {
+		if !sample.Stack[0].Builtin {
+			stats[sample.Stack[0]] += sample.Time
+		}
+	}
+
+	lines := make([]LineStats, 0, len(stats))
+	for location, flat := range stats {
+		lines = append(lines, LineStats{Location: location, Flat: flat})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Flat != lines[j].Flat {
+			return lines[i].Flat > lines[j].Flat
+		}
+		if lines[i].Line != lines[j].Line {
+			return lines[i].Line < lines[j].Line
+		}
+		return lines[i].Name < lines[j].Name
+	})
+	return lines
+}
+
+// Report - Writes the top functions and lines by flat time to out, at most top of each
+func (profiler *Profiler) Report(out io.Writer, top int) {
+	total := profiler.Duration()
+	percent := func(part time.Duration) float64 {
+		if total == 0 {
+			return 0
+		}
+		return 100 * float64(part) / float64(total)
+	}
+
+	functions := profiler.Functions()
+	var builtins time.Duration
+	for _, function := range functions {
+		if function.Builtin {
+			builtins += function.Flat
+		}
+	}
+	fmt.Fprintf(out, "Total: %s, %s (%.1f%%) in builtins\n", milliseconds(total), milliseconds(builtins), percent(builtins))
+
+	fmt.Fprintf(out, "\nFunctions by own time:\n%10s %6s %10s %6s %7s  %s\n", "flat", "flat%", "cum", "cum%", "calls", "function")
+	for i, function := range functions {
+		if i == top {
+			break
+		}
+		fmt.Fprintf(out, "%10s %5.1f%% %10s %5.1f%% %7d  %s\n", milliseconds(function.Flat), percent(function.Flat), milliseconds(function.Cumulative), percent(function.Cumulative), function.Calls, function)
+	}
+
+	fmt.Fprintf(out, "\nLines by own time:\n%10s %6s  %s\n", "flat", "flat%", "line")
+	for i, line := range profiler.Lines() {
+		if i == top {
+			break
+		}
+		fmt.Fprintf(out, "%10s %5.1f%%  %s:%d (%s)\n", milliseconds(line.Flat), percent(line.Flat), profiler.File, line.Line, line.Name)
+	}
+}
+
+// milliseconds - duration as milliseconds with 3 decimals
+func milliseconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3fms", float64(duration)/float64(time.Millisecond))
+}
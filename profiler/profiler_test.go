@@ -0,0 +1,167 @@
+package profiler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// profile - Profiles input with a clock advancing by a millisecond at every event
+func profile(t *testing.T, input string) *Profiler {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		t.Fatalf("Parser errors for %q: %v", input, parser.Errors())
+	}
+
+	profiler := New("script.mk")
+	clock := time.Unix(0, 0)
+	profiler.now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+
+	env := object.NewEnvironment()
+	env.SetOutput(ioutil.Discard)
+	env.SetObserver(profiler)
+	profiler.Start()
+	evaluator.Eval(env, program)
+	profiler.Stop()
+	return profiler
+}
+
+const input = `let double = fn(x) {
+  let y = x * 2;
+  y
+};
+let total = double(len("ab"));
+puts(total);`
+
+func TestReport(t *testing.T) {
+	var out bytes.Buffer
+	profile(t, input).Report(&out, 3)
+
+	expected := `Total: 12.000ms, 2.000ms (16.7%) in builtins
+
+Functions by own time:
+      flat  flat%        cum   cum%   calls  function
+   7.000ms  58.3%   12.000ms 100.0%       0  <script>
+   3.000ms  25.0%    3.000ms  25.0%       1  double
+   1.000ms   8.3%    1.000ms   8.3%       1  len (builtin)
+
+Lines by own time:
+      flat  flat%  line
+   3.000ms  25.0%  script.mk:5 (<script>)
+   2.000ms  16.7%  script.mk:6 (<script>)
+   1.000ms   8.3%  script.mk:0 (<script>)
+`
+	if out.String() != expected {
+		t.Errorf("Report is incorrect. Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+}
+
+func TestSamples(t *testing.T) {
+	// Each event (a statement, a call or a return) charges a millisecond to the stack it ends
+	expected := []string{
+		"<script>:0 1 1ms",
+		"<script>:1 1 1ms",
+		"<script>:5 3 3ms",
+		"len (builtin):5 < <script>:5 1 1ms",
+		"double:1 < <script>:5 1 1ms",
+		"double:2 < <script>:5 1 1ms",
+		"double:3 < <script>:5 1 1ms",
+		"<script>:6 2 2ms",
+		"puts (builtin):6 < <script>:6 1 1ms",
+	}
+
+	samples := profile(t, input).Samples()
+	if len(samples) != len(expected) {
+		t.Fatalf("Wrong number of samples. Expected: %d. Got: %d", len(expected), len(samples))
+	}
+	for i, sample := range samples {
+		locations := []string{}
+		for _, location := range sample.Stack {
+			locations = append(locations, location.String()+":"+strconv.Itoa(location.Line))
+		}
+		got := strings.Join(locations, " < ") + " " + strconv.Itoa(int(sample.Count)) + " " + sample.Time.String()
+		if got != expected[i] {
+			t.Errorf("Sample %d is incorrect. Expected: %s. Got: %s", i, expected[i], got)
+		}
+	}
+}
+
+func TestWriteProfile(t *testing.T) {
+	var out bytes.Buffer
+	if err := profile(t, input).WriteProfile(&out); err != nil {
+		t.Fatalf("WriteProfile failed: %s", err)
+	}
+
+	reader, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("The profile is not gzipped: %s", err)
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("The profile is not gzipped: %s", err)
+	}
+
+	fields := map[int]int{}
+	table := []string{}
+	for len(data) > 0 {
+		key, n := readVarint(data)
+		data = data[n:]
+		field, wireType := int(key>>3), key&7
+		if wireType == 0 {
+			_, n = readVarint(data)
+			data = data[n:]
+		} else {
+			length, n := readVarint(data)
+			if field == profileStringTable {
+				table = append(table, string(data[n:n+int(length)]))
+			}
+			data = data[n+int(length):]
+		}
+		fields[field]++
+	}
+
+	expected := map[int]int{
+		profileSampleType:    2,
+		profileSample:        9,
+		profileLocation:      9,
+		profileFunction:      4,
+		profileTimeNanos:     1,
+		profileDurationNanos: 1,
+		profilePeriodType:    1,
+		profilePeriod:        1,
+	}
+	for field, count := range expected {
+		if fields[field] != count {
+			t.Errorf("Wrong number of fields %d. Expected: %d. Got: %d", field, count, fields[field])
+		}
+	}
+	expectedTable := `["" "samples" "count" "time" "nanoseconds" "<script>" "script.mk" "len (builtin)" "double" "puts (builtin)"]`
+	if got := fmt.Sprintf("%q", table); got != expectedTable {
+		t.Errorf("String table is incorrect. Expected: %s. Got: %s", expectedTable, got)
+	}
+}
+
+func readVarint(data []byte) (uint64, int) {
+	value, shift := uint64(0), uint(0)
+	for i, b := range data {
+		value |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return value, i + 1
+		}
+		shift += 7
+	}
+	return value, len(data)
+}
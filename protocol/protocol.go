@@ -0,0 +1,52 @@
+// Package protocol implements the base protocol shared by the Debug Adapter Protocol and the Language
+// Server Protocol: JSON messages preceded by a Content-Length header
+package protocol
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// MAX_MESSAGE_SIZE - The longest body ReadMessage accepts, so that a bad header cannot exhaust memory
+const MAX_MESSAGE_SIZE = 64 << 20
+
+// ReadMessage - Reads one base protocol message: headers, a blank line, then a Content-Length long body
+func ReadMessage(reader *bufio.Reader) ([]byte, error) {
+	headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(strings.TrimSpace(headers.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", headers.Get("Content-Length"))
+	}
+	if length > MAX_MESSAGE_SIZE {
+		return nil, fmt.Errorf("message of %d bytes is larger than the maximum of %d", length, MAX_MESSAGE_SIZE)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// WriteMessage - Writes message as JSON with a Content-Length header
+func WriteMessage(writer io.Writer, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = writer.Write(body)
+	return err
+}
@@ -0,0 +1,470 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// MAX_HISTORY - The number of lines kept in the history and its file, which is rewritten with the kept
+// lines when older ones are dropped
+const MAX_HISTORY = 1000
+
+// ErrInterrupt - Returned by ReadLine when the user presses Ctrl-C
+var ErrInterrupt = errors.New("interrupted")
+
+// lineReader - Reads one line of input after showing prompt. It returns io.EOF once the input is exhausted
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// scannerReader - Reads lines from input that is not a terminal (eg. a pipe), where no editing is needed
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (reader *scannerReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(reader.out, prompt)
+	if !reader.scanner.Scan() {
+		if err := reader.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return reader.scanner.Text(), nil
+}
+
+// Editor - An emacs style line editor for terminals in raw mode. It supports cursor movement, history
+// navigation, reverse search (Ctrl-R) and tab completion
+//
+// Lines are assumed to fit on one row of the terminal
+type Editor struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// Complete returns the candidates for the word before the cursor. It may be nil
+	Complete func(word string) []string
+	// HistoryFile is where history is appended as lines are entered. Empty disables saving
+	HistoryFile string
+
+	history []string
+	buffer  []rune
+	cursor  int
+	prompt  string
+}
+
+// NewEditor - Creates an editor reading keys from in and drawing on out
+func NewEditor(in io.Reader, out io.Writer) *Editor {
+	return &Editor{in: bufio.NewReader(in), out: out}
+}
+
+// History - The lines entered so far, oldest first
+func (editor *Editor) History() []string {
+	return editor.history
+}
+
+// LoadHistory - Reads the history saved in HistoryFile. A missing file is not an error
+func (editor *Editor) LoadHistory() error {
+	if editor.HistoryFile == "" {
+		return nil
+	}
+
+	file, err := os.Open(editor.HistoryFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			editor.history = append(editor.history, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(editor.history) > MAX_HISTORY {
+		editor.history = editor.history[len(editor.history)-MAX_HISTORY:]
+		return editor.saveHistory()
+	}
+	return nil
+}
+
+// saveHistory - Replaces the content of HistoryFile with the history
+func (editor *Editor) saveHistory() error {
+	return ioutil.WriteFile(editor.HistoryFile, []byte(strings.Join(editor.history, "\n")+"\n"), 0600)
+}
+
+// AddHistory - Adds line to the history and appends it to HistoryFile. Blank lines and repeats of the
+// previous line are skipped
+func (editor *Editor) AddHistory(line string) error {
+	if strings.TrimSpace(line) == "" ||
+		len(editor.history) > 0 && editor.history[len(editor.history)-1] == line {
+		return nil
+	}
+
+	editor.history = append(editor.history, line)
+	trimmed := len(editor.history) > MAX_HISTORY
+	if trimmed {
+		editor.history = editor.history[1:]
+	}
+
+	if editor.HistoryFile == "" {
+		return nil
+	}
+	if trimmed {
+		return editor.saveHistory()
+	}
+	file, err := os.OpenFile(editor.HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintln(file, line)
+	return err
+}
+
+// Keys
+const (
+	ctrlA     = 1
+	ctrlB     = 2
+	ctrlC     = 3
+	ctrlD     = 4
+	ctrlE     = 5
+	ctrlF     = 6
+	ctrlG     = 7
+	backspace = 8
+	tab       = 9
+	ctrlK     = 11
+	ctrlL     = 12
+	enter     = 13
+	ctrlN     = 14
+	ctrlP     = 16
+	ctrlR     = 18
+	ctrlU     = 21
+	ctrlW     = 23
+	escape    = 27
+	delete    = 127
+)
+
+// Keys decoded from escape sequences, outside the range of runes
+const (
+	keyUp rune = -(iota + 1)
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyUnknown
+)
+
+// ReadLine - Reads and edits one line. Lines are added to the history by the caller (see AddHistory)
+func (editor *Editor) ReadLine(prompt string) (string, error) {
+	editor.prompt = prompt
+	editor.buffer = editor.buffer[:0]
+	editor.cursor = 0
+	editor.refresh()
+
+	// index into history while navigating it. The line being edited is saved as if it were the newest entry
+	index := len(editor.history)
+	var saved []rune
+
+	for {
+		key, err := editor.readKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case enter, '\n':
+			fmt.Fprint(editor.out, "\r\n")
+			return string(editor.buffer), nil
+		case ctrlC:
+			fmt.Fprint(editor.out, "^C\r\n")
+			return "", ErrInterrupt
+		case ctrlD:
+			if len(editor.buffer) == 0 {
+				fmt.Fprint(editor.out, "\r\n")
+				return "", io.EOF
+			}
+			editor.deleteAt(editor.cursor)
+		case backspace, delete:
+			if editor.cursor > 0 {
+				editor.cursor--
+				editor.deleteAt(editor.cursor)
+			}
+		case keyDelete:
+			editor.deleteAt(editor.cursor)
+		case ctrlA, keyHome:
+			editor.cursor = 0
+		case ctrlE, keyEnd:
+			editor.cursor = len(editor.buffer)
+		case ctrlB, keyLeft:
+			if editor.cursor > 0 {
+				editor.cursor--
+			}
+		case ctrlF, keyRight:
+			if editor.cursor < len(editor.buffer) {
+				editor.cursor++
+			}
+		case ctrlK:
+			editor.buffer = editor.buffer[:editor.cursor]
+		case ctrlU:
+			editor.buffer = append(editor.buffer[:0], editor.buffer[editor.cursor:]...)
+			editor.cursor = 0
+		case ctrlW:
+			start := editor.wordStart(func(r rune) bool { return r != ' ' && r != '\t' })
+			editor.buffer = append(editor.buffer[:start], editor.buffer[editor.cursor:]...)
+			editor.cursor = start
+		case ctrlL:
+			fmt.Fprint(editor.out, "\x1b[H\x1b[2J")
+		case ctrlP, keyUp, ctrlN, keyDown:
+			next := index - 1
+			if key == ctrlN || key == keyDown {
+				next = index + 1
+			}
+			if next < 0 || next > len(editor.history) {
+				break
+			}
+			if index == len(editor.history) {
+				saved = append(saved[:0], editor.buffer...)
+			}
+			index = next
+			if index == len(editor.history) {
+				editor.setBuffer(string(saved))
+			} else {
+				editor.setBuffer(editor.history[index])
+			}
+		case ctrlR:
+			line, accepted, err := editor.reverseSearch()
+			if err != nil {
+				return "", err
+			}
+			editor.setBuffer(line)
+			if accepted {
+				editor.refresh()
+				fmt.Fprint(editor.out, "\r\n")
+				return line, nil
+			}
+		case tab:
+			editor.complete()
+		default:
+			if key >= ' ' {
+				editor.insert(key)
+			}
+		}
+		editor.refresh()
+	}
+}
+
+// readKey - Reads one key, decoding the escape sequences sent by the arrow, home, end and delete keys
+func (editor *Editor) readKey() (rune, error) {
+	key, _, err := editor.in.ReadRune()
+	if err != nil || key != escape {
+		return key, err
+	}
+
+	next, _, err := editor.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+
+	code, _, err := editor.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch code {
+	case 'A':
+		return keyUp, nil
+	case 'B':
+		return keyDown, nil
+	case 'C':
+		return keyRight, nil
+	case 'D':
+		return keyLeft, nil
+	case 'H':
+		return keyHome, nil
+	case 'F':
+		return keyEnd, nil
+	}
+
+	// Sequences of the form ESC [ <number> ~
+	number := ""
+	for '0' <= code && code <= '9' {
+		number += string(code)
+		if code, _, err = editor.in.ReadRune(); err != nil {
+			return 0, err
+		}
+	}
+	if code != '~' {
+		return keyUnknown, nil
+	}
+	switch number {
+	case "1", "7":
+		return keyHome, nil
+	case "4", "8":
+		return keyEnd, nil
+	case "3":
+		return keyDelete, nil
+	}
+	return keyUnknown, nil
+}
+
+// reverseSearch - Searches the history backwards for lines containing what is typed. Enter accepts the
+// match, Ctrl-R finds an older match, Ctrl-G or Ctrl-C restores the line and any other key stops
+// searching with the match in the buffer
+func (editor *Editor) reverseSearch() (line string, accepted bool, err error) {
+	original := string(editor.buffer)
+	query := ""
+	match, index := "", len(editor.history)
+
+	search := func(from int) {
+		if from >= len(editor.history) {
+			from = len(editor.history) - 1
+		}
+		for i := from; i >= 0; i-- {
+			if strings.Contains(editor.history[i], query) {
+				match, index = editor.history[i], i
+				return
+			}
+		}
+	}
+
+	for {
+		fmt.Fprintf(editor.out, "\r(reverse-i-search)`%s': %s\x1b[K", query, match)
+
+		key, err := editor.readKey()
+		if err != nil {
+			return "", false, err
+		}
+
+		switch key {
+		case enter, '\n':
+			return match, true, nil
+		case ctrlG, ctrlC:
+			return original, false, nil
+		case ctrlR:
+			search(index - 1)
+		case backspace, delete:
+			if query != "" {
+				_, size := utf8.DecodeLastRuneInString(query)
+				query = query[:len(query)-size]
+				search(len(editor.history) - 1)
+			}
+		default:
+			if key < ' ' || key == escape {
+				return match, false, nil
+			}
+			query += string(key)
+			search(index)
+		}
+	}
+}
+
+// complete - Completes the identifier before the cursor. A single candidate is inserted, otherwise the
+// common prefix of the candidates is inserted or, failing that, the candidates are listed
+func (editor *Editor) complete() {
+	if editor.Complete == nil {
+		return
+	}
+
+	start := editor.wordStart(isIdentifierRune)
+	word := string(editor.buffer[start:editor.cursor])
+	candidates := editor.Complete(word)
+	if len(candidates) == 0 {
+		return
+	}
+
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+
+	if len(prefix) > len(word) {
+		for _, r := range prefix[len(word):] {
+			editor.insert(r)
+		}
+		return
+	}
+	if len(candidates) > 1 {
+		fmt.Fprint(editor.out, "\r\n"+strings.Join(candidates, "  ")+"\r\n")
+	}
+}
+
+func (editor *Editor) wordStart(inWord func(rune) bool) int {
+	start := editor.cursor
+	for start > 0 && !inWord(editor.buffer[start-1]) {
+		start--
+	}
+	for start > 0 && inWord(editor.buffer[start-1]) {
+		start--
+	}
+	return start
+}
+
+func isIdentifierRune(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || r == '_' || '0' <= r && r <= '9'
+}
+
+func (editor *Editor) insert(r rune) {
+	editor.buffer = append(editor.buffer, 0)
+	copy(editor.buffer[editor.cursor+1:], editor.buffer[editor.cursor:])
+	editor.buffer[editor.cursor] = r
+	editor.cursor++
+}
+
+func (editor *Editor) deleteAt(position int) {
+	if position < len(editor.buffer) {
+		editor.buffer = append(editor.buffer[:position], editor.buffer[position+1:]...)
+	}
+}
+
+func (editor *Editor) setBuffer(line string) {
+	editor.buffer = []rune(line)
+	editor.cursor = len(editor.buffer)
+}
+
+// refresh - Redraws the prompt and buffer and moves the terminal cursor to the editing position
+func (editor *Editor) refresh() {
+	fmt.Fprintf(editor.out, "\r%s%s\x1b[K", editor.prompt, string(editor.buffer))
+	if back := len(editor.buffer) - editor.cursor; back > 0 {
+		fmt.Fprintf(editor.out, "\x1b[%dD", back)
+	}
+}
+
+// terminalReader - Edits lines with an Editor, keeping the terminal in raw mode only while a line is read
+// so that evaluation output is written normally
+type terminalReader struct {
+	editor *Editor
+	fd     uintptr
+}
+
+func (reader *terminalReader) ReadLine(prompt string) (string, error) {
+	restore, err := makeRaw(reader.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	line, err := reader.editor.ReadLine(prompt)
+	if err == nil {
+		reader.editor.AddHistory(line)
+	}
+	return line, err
+}
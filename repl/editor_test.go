@@ -0,0 +1,149 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"monkeylang/object"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditorReadLine(t *testing.T) {
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"let x = 5;\r", "let x = 5;"},
+		{"let x = 5\x7f6;\r", "let x = 6;"},
+		{"x + 1\x1b[D\x1b[D\x1b[D\x1b[D\x1b[Dy\r", "yx + 1"},
+		{"bc\x01a\x05d\r", "abcd"},
+		{"abc\x02\x02\x0b\r", "a"},
+		{"abc\x02\x15\r", "c"},
+		{"let foo bar\x17baz\r", "let foo baz"},
+		{"abc\x1b[H\x1b[3~\r", "bc"},
+		{"héllo\x7f\x7f\x7f\x7f\r", "h"},
+	}
+
+	for _, test := range tests {
+		editor := NewEditor(strings.NewReader(test.keys), ioutil.Discard)
+		line, err := editor.ReadLine(PROMPT)
+		if err != nil {
+			t.Errorf("ReadLine(%q) returned an error: %s", test.keys, err)
+			continue
+		}
+		if line != test.expected {
+			t.Errorf("ReadLine(%q) is incorrect. Expected: %q. Got: %q", test.keys, test.expected, line)
+		}
+	}
+}
+
+func TestEditorControlKeys(t *testing.T) {
+	editor := NewEditor(strings.NewReader("abc\x03\x04"), ioutil.Discard)
+	if _, err := editor.ReadLine(PROMPT); err != ErrInterrupt {
+		t.Errorf("Ctrl-C should interrupt the line. Got: %v", err)
+	}
+	if _, err := editor.ReadLine(PROMPT); err != io.EOF {
+		t.Errorf("Ctrl-D on an empty line should end the input. Got: %v", err)
+	}
+}
+
+func TestEditorHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	ioutil.WriteFile(path, []byte("let a = 1;\nlet b = 2;\n"), 0600)
+
+	keys := "\x1b[A\r" + // previous line
+		"\x1b[A\x1b[A\x1b[A\x1b[B\r" + // up past the oldest line and back down
+		"draft\x1b[A\x1b[B\r" + // the line being edited is restored
+		"\x12a = \r" + // reverse search
+		"\x12let\x12\x12\x07\r" // cancelled search keeps the original line
+	editor := NewEditor(strings.NewReader(keys), ioutil.Discard)
+	editor.HistoryFile = path
+	if err := editor.LoadHistory(); err != nil {
+		t.Fatalf("LoadHistory returned an error: %s", err)
+	}
+
+	expected := []string{"let b = 2;", "let b = 2;", "draft", "let a = 1;", ""}
+	for _, want := range expected {
+		line, err := editor.ReadLine(PROMPT)
+		if err != nil {
+			t.Fatalf("ReadLine returned an error: %s", err)
+		}
+		if line != want {
+			t.Errorf("ReadLine is incorrect. Expected: %q. Got: %q", want, line)
+		}
+		editor.AddHistory(line)
+	}
+
+	history := []string{"let a = 1;", "let b = 2;", "draft", "let a = 1;"}
+	if !reflect.DeepEqual(editor.History(), history) {
+		t.Errorf("History is incorrect. Expected: %q. Got: %q", history, editor.History())
+	}
+
+	saved, _ := ioutil.ReadFile(path)
+	if string(saved) != strings.Join(history, "\n")+"\n" {
+		t.Errorf("History file is incorrect. Got: %q", saved)
+	}
+}
+
+func TestEditorHistoryLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	lines := []string{}
+	for i := 0; i < MAX_HISTORY+5; i++ {
+		lines = append(lines, fmt.Sprintf("%d", i))
+	}
+	ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+
+	editor := NewEditor(strings.NewReader(""), ioutil.Discard)
+	editor.HistoryFile = path
+	if err := editor.LoadHistory(); err != nil {
+		t.Fatalf("LoadHistory returned an error: %s", err)
+	}
+	if err := editor.AddHistory("last"); err != nil {
+		t.Fatalf("AddHistory returned an error: %s", err)
+	}
+
+	expected := append(lines[6:], "last")
+	if !reflect.DeepEqual(editor.History(), expected) {
+		t.Errorf("History is incorrect. Expected %d lines from 6 to last. Got: %d lines from %s to %s", len(expected), len(editor.History()), editor.History()[0], editor.History()[len(editor.History())-1])
+	}
+	saved, _ := ioutil.ReadFile(path)
+	if string(saved) != strings.Join(expected, "\n")+"\n" {
+		t.Errorf("History file is incorrect. Expected %d lines. Got: %d", len(expected), strings.Count(string(saved), "\n"))
+	}
+}
+
+func TestEditorCompletion(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("counter", &object.Integer{Value: 1})
+	env.Set("count", &object.Integer{Value: 2})
+	complete := func(word string) []string {
+		return completions(env, word)
+	}
+
+	tests := []struct {
+		keys     string
+		expected string
+	}{
+		{"fa\t\r", "false"},
+		{"x + ret\t\r", "x + return"},
+		{"co\te\t\r", "counter"},
+		{"le\t\r", "le"},
+		{"zzz\t\r", "zzz"},
+	}
+
+	for _, test := range tests {
+		editor := NewEditor(strings.NewReader(test.keys), ioutil.Discard)
+		editor.Complete = complete
+		if line, _ := editor.ReadLine(PROMPT); line != test.expected {
+			t.Errorf("Completing %q is incorrect. Expected: %q. Got: %q", test.keys, test.expected, line)
+		}
+	}
+
+	expected := []string{"len", "let"}
+	if candidates := completions(env, "le"); !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("completions(le) is incorrect. Expected: %q. Got: %q", expected, candidates)
+	}
+}
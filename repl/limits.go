@@ -0,0 +1,71 @@
+package repl
+
+import (
+	"fmt"
+	"monkeylang/ast"
+	"monkeylang/object"
+	"time"
+)
+
+// DEFAULT_MAX_DEPTH - The nested function calls `monkey serve` allows per input unless told otherwise, so that
+// deep recursion is reported as an error rather than overflowing the stack of the whole process
+const DEFAULT_MAX_DEPTH = 10000
+
+// Limits - Bounds on the work a single input may do. Zero values mean no limit
+type Limits struct {
+	Timeout  time.Duration // wall clock time per input, checked as each node is evaluated
+	MaxSteps int           // statements run per input
+	MaxDepth int           // nested function calls (tail calls do not nest)
+}
+
+// limitExceeded is panicked from inside the evaluator to abandon an input and is recovered by the session
+type limitExceeded struct {
+	message string
+}
+
+// limiter - Enforces Limits as an object.Observer. It is set once on a session's root Environment, so
+// closures created by earlier inputs report to it too, and is reset before each input
+type limiter struct {
+	limits   Limits
+	deadline time.Time
+	steps    int
+	depth    int
+}
+
+func (limiter *limiter) reset() {
+	limiter.steps, limiter.depth = 0, 0
+	if limiter.limits.Timeout > 0 {
+		limiter.deadline = time.Now().Add(limiter.limits.Timeout)
+	}
+}
+
+// Statement - Counts the statement and checks the step limit. Implements object.Observer
+func (limiter *limiter) Statement(env *object.Environment, statement ast.Statement) {
+	limiter.steps++
+	if max := limiter.limits.MaxSteps; max > 0 && limiter.steps > max {
+		panic(limitExceeded{fmt.Sprintf("Execution limit exceeded: more than %d steps", max)})
+	}
+}
+
+// Enter - Checks the time limit, so that it also interrupts long expressions. Implements object.NodeObserver
+func (limiter *limiter) Enter(env *object.Environment, node ast.Node) {
+	if limiter.limits.Timeout > 0 && time.Now().After(limiter.deadline) {
+		panic(limitExceeded{fmt.Sprintf("Execution limit exceeded: ran for longer than %s", limiter.limits.Timeout)})
+	}
+}
+
+// Exit - Implements object.NodeObserver
+func (limiter *limiter) Exit(env *object.Environment, node ast.Node, result object.Object) {}
+
+// Call - Checks the call depth limit. Implements object.Observer
+func (limiter *limiter) Call(env *object.Environment, call *ast.CallExpression, function *object.Function, args []object.Object) {
+	limiter.depth++
+	if max := limiter.limits.MaxDepth; max > 0 && limiter.depth > max {
+		panic(limitExceeded{fmt.Sprintf("Execution limit exceeded: more than %d nested calls", max)})
+	}
+}
+
+// Return - Implements object.Observer
+func (limiter *limiter) Return(function *object.Function, result object.Object) {
+	limiter.depth--
+}
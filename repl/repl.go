@@ -6,44 +6,180 @@ import (
 	"io"
 	"monkeylang/evaluator"
 	"monkeylang/lexer"
+	"monkeylang/module"
 	"monkeylang/object"
-	"monkeylang/parser"
+	"monkeylang/stdlib"
+	"monkeylang/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 const PROMPT = ">> "
 
-// Start - Start the MonkeyLang REPL
+// CONTINUATION_PROMPT - Shown while the input read so far is an incomplete statement
+const CONTINUATION_PROMPT = ".. "
+
+// HISTORY_FILE - Where the REPL's history is kept, relative to the home directory. The MONKEY_HISTORY
+// environment variable overrides it (an empty value disables the history file)
+const HISTORY_FILE = ".monkey_history"
+
+// Start - Start the MonkeyLang REPL. When in is a terminal lines are read with an Editor. Modules are
+// looked up in the standard library and then in the MONKEY_PATH environment variable
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	library, _ := stdlib.New(stdlib.Names()...) // Every name is known
+	session := newSession(out, module.SearchPath(""), library)
+	reader := newLineReader(in, out, func(word string) []string {
+		return completions(session.env, word)
+	})
+
+	session.run(reader)
+}
+
+// readInput - Reads lines until they form a complete statement. Two blank lines in a row end the input
+// early so that a mistake (eg. an extra opening brace) can be escaped. ok is false once in is exhausted
+// Ctrl-C discards the input read so far. A line starting with ":" is a command and is never continued
+func readInput(reader lineReader, out io.Writer) (input string, ok bool) {
+	lines := []string{}
+	prompt := PROMPT
 
 	for {
-		fmt.Printf(PROMPT)
-		if !scanner.Scan() {
-			return
+		line, err := reader.ReadLine(prompt)
+		if err == ErrInterrupt {
+			lines, prompt = lines[:0], PROMPT
+			continue
+		}
+		if err != nil {
+			if _, isScanner := reader.(*scannerReader); isScanner && len(lines) != 0 {
+				io.WriteString(out, "\n")
+			}
+			if err != io.EOF {
+				fmt.Fprintf(out, "Could not read input: %s\n", err)
+			}
+			return strings.Join(lines, "\n"), false
+		}
+
+		if len(lines) == 0 && strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.TrimSpace(line) == "" && strings.TrimSpace(lines[len(lines)-1]) == "" {
+			return strings.Join(lines, "\n"), true
+		}
+
+		if len(lines) == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			return line, true
+		}
+
+		lines = append(lines, line)
+		input = strings.Join(lines, "\n")
+		if !Incomplete(input) {
+			return input, true
+		}
+		prompt = CONTINUATION_PROMPT
+	}
+}
+
+// newLineReader - An Editor when in is a terminal, otherwise a plain line reader
+func newLineReader(in io.Reader, out io.Writer, complete func(word string) []string) lineReader {
+	if file, ok := in.(*os.File); ok {
+		if restore, err := makeRaw(file.Fd()); err == nil {
+			restore()
+
+			editor := NewEditor(in, out)
+			editor.Complete = complete
+			editor.HistoryFile = historyFile()
+			if err := editor.LoadHistory(); err != nil {
+				fmt.Fprintf(out, "Could not load history: %s\n", err)
+			}
+			return &terminalReader{editor: editor, fd: file.Fd()}
 		}
+	}
+	return &scannerReader{scanner: bufio.NewScanner(in), out: out}
+}
+
+func historyFile() string {
+	if path, ok := os.LookupEnv("MONKEY_HISTORY"); ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, HISTORY_FILE)
+}
 
-		line := scanner.Text()
-		lexer := lexer.New(line)
-		parser := parser.New(lexer)
+// completions - The keywords, builtins and names bound in env that start with word
+func completions(env *object.Environment, word string) []string {
+	names := append(token.Keywords(), evaluator.BuiltinNames()...)
+	for scope := env; scope != nil; scope = scope.Outer {
+		names = append(names, scope.Names()...)
+	}
+	sort.Strings(names)
 
-		program := parser.ParseProgram()
-		if len(parser.Errors()) != 0 {
-			printParserErrors(out, parser.Errors())
+	candidates := []string{}
+	for i, name := range names {
+		if strings.HasPrefix(name, word) && (i == 0 || names[i-1] != name) {
+			candidates = append(candidates, name)
 		}
+	}
+	return candidates
+}
 
-		evaluated := evaluator.Eval(env, program)
-		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+// Incomplete - Reports whether input needs more lines to form a statement: it has unclosed parentheses,
+// brackets or braces, an unterminated string, a match whose arms have not started, or ends with an
+// operator, a comma, a colon, a dot, an arrow or one of else, catch, finally and throw
+func Incomplete(input string) bool {
+	depth := 0
+	matches := []int{} // the depths of the matches still missing the brace opening their arms
+	var last token.Token
+	lexer := lexer.New(input)
+	for tok := lexer.NextToken(); tok.Type != token.EOF; tok = lexer.NextToken() {
+		switch tok.Type {
+		case token.MATCH:
+			matches = append(matches, depth)
+		case token.LBRACE:
+			if len(matches) > 0 && matches[len(matches)-1] == depth {
+				matches = matches[:len(matches)-1]
+			}
+			depth++
+		case token.LPAREN, token.LBRACKET, token.QUESTION_BRACKET:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			depth--
 		}
+		last = tok
+	}
 
+	if depth > 0 || len(matches) > 0 || unterminated(input, last) {
+		return true
 	}
+	// Let the parser report unbalanced closing brackets
+	if depth < 0 {
+		return false
+	}
+
+	switch last.Type {
+	case token.ASSIGN, token.PLUS, token.MINUS, token.STAR, token.SLASH, token.BANG,
+		token.EQUAL, token.BANG_EQUAL, token.LESS, token.GREATER, token.COMMA, token.ELSE, token.DOT, token.COLON,
+		token.NULLISH, token.QUESTION_DOT, token.QUESTION_BRACKET, token.ARROW, token.RARROW,
+		token.CATCH, token.FINALLY, token.THROW:
+		return true
+	}
+	return false
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	for _, errorMsg := range errors {
-		io.WriteString(out, "Oops we got an unexpected Parser Error: \n")
-		io.WriteString(out, "\t"+errorMsg+"\n")
+// unterminated - Whether the last token of input is a string missing its closing quote. Such a string
+// runs to the end of the input, which then ends with its text rather than with a quote
+func unterminated(input string, last token.Token) bool {
+	if last.Type != token.STRING {
+		return false
+	}
+	lines := strings.SplitAfter(input, "\n")
+	offset := last.Column - 1
+	for _, line := range lines[:last.Line-1] {
+		offset += len(line)
 	}
+	// The text of the string starts after its opening quote
+	return offset+1+len(last.Literal) == len(input)
 }
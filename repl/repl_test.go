@@ -0,0 +1,120 @@
+package repl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"let x = 5;", false},
+		{"let add = fn(x, y) {", true},
+		{"let add = fn(x, y) {\n\tx + y\n};", false},
+		{"add(1,", true},
+		{"add(1,\n2)", false},
+		{"let x = 5 +", true},
+		{"let x =", true},
+		{"if (x) { 1 } else", true},
+		{`let s = "hello`, true},
+		{"let s = \"hello\nworld\"", false},
+		{"let s = \"hello\nworld", true},
+		{`let x = 1; // say "hi`, false},
+		{"// \"\nlet s = \"a", true},
+		{`let s = ""`, false},
+		{`let s = "`, true},
+		{`puts("a") `, false},
+		{`"{"`, false},
+		{"let g = fn(xs) {\n  xs?[0]", true},
+		{"let first = xs?[0", true},
+		{"xs?[0]", false},
+		{"let f = fn(x: int) ->", true},
+		{"let f = fn(x: int) -> int { x }", false},
+		{"match (x)", true},
+		{"match", true},
+		{"match (x) {\n  1 => \"one\",", true},
+		{"match (x) { 1 => \"one\", _ => \"many\" }", false},
+		{"match (match (x) { _ => 1 })", true},
+		{"let y = match (x) { _ => match (x) { _ => 1 } }", false},
+		{"}", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if incomplete := Incomplete(test.input); incomplete != test.expected {
+			t.Errorf("Incomplete(%q) is incorrect. Expected: %t. Got: %t", test.input, test.expected, incomplete)
+		}
+	}
+}
+
+func TestStartMultiLine(t *testing.T) {
+	input := "let add = fn(x, y) {\n\tx + y\n};\n\nadd(1,\n2)\nlet f = fn() {\n\n\n5 * 2\n"
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	// The two blank lines abandon the unfinished function
+	expected := ">> .. .. >> >> .. 3\n>> .. .. Oops we got an unexpected Parser Error: \n" +
+		"\tExpected token type }, got end of input instead\n>> 10\n>> "
+	if out.String() != expected {
+		t.Errorf("REPL output is incorrect. Expected: %q. Got: %q", expected, out.String())
+	}
+}
+
+func TestStartOutput(t *testing.T) {
+	var out bytes.Buffer
+	Start(strings.NewReader(`println("hello", 1)`+"\n"), &out)
+
+	expected := ">> hello 1\nnull\n>> "
+	if out.String() != expected {
+		t.Errorf("REPL output is incorrect. Expected: %q. Got: %q", expected, out.String())
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "lib.mk"), []byte("let double = fn(x) { x * 2 };"), 0644)
+	saved := filepath.Join(dir, "saved.mk")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{":tokens let x = 5;", "1:1\tLET\t\"let\"\n1:5\tIDENT\t\"x\"\n1:7\t=\t\"=\"\n1:9\tINT\t\"5\"\n1:10\t;\t\";\"\n"},
+		{":ast -x + 1", "Program (1:1)\n  ExpressionStatement (1:1)\n    InfixExpression + (1:4)\n" +
+			"      PrefixExpression - (1:1)\n        Identifier x (1:2)\n      IntegerLiteral 1 (1:6)\n"},
+		{"let a = 1;\nlet b = \"two\";\n:env", "a = 1\nb = two\n"},
+		{":type 5", "INTEGER\n"},
+		{":type fn(x) { x }", "FUNCTION_OBJ\n"},
+		{":load " + filepath.Join(dir, "lib.mk") + "\ndouble(21)", "42\n"},
+		{"let a = 1;\nlet b = a + true;\n:save " + saved, "Saved 1 definitions to " + saved + "\n"},
+		{"let a = 1;\n:reset\n:env\na", "ERROR: Unknown identifier: a\n"},
+		{":nope", "Unknown command :nope. Type :help for the list of commands\n"},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		Start(strings.NewReader(test.input), &out)
+
+		output := strings.Replace(out.String(), PROMPT, "", -1)
+		if !strings.HasSuffix(output, test.expected) {
+			t.Errorf("Output of %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, output)
+		}
+	}
+
+	if source, _ := ioutil.ReadFile(saved); string(source) != "let a = 1;\n" {
+		t.Errorf(":save wrote the wrong source. Got: %q", source)
+	}
+}
+
+func TestTimeCommand(t *testing.T) {
+	var out bytes.Buffer
+	Start(strings.NewReader(":time 1 + 2"), &out)
+	if !strings.Contains(out.String(), "3\nTime: ") || !strings.Contains(out.String(), "allocations: ") {
+		t.Errorf(":time output is incorrect. Got: %q", out.String())
+	}
+}
@@ -0,0 +1,133 @@
+package repl
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"monkeylang/object"
+	"monkeylang/stdlib"
+	"net"
+	"sync"
+	"time"
+)
+
+// AUTH_TIMEOUT - How long a client has to send the token after connecting
+const AUTH_TIMEOUT = 10 * time.Second
+
+// Server - Serves the REPL to clients connecting over a Unix socket or a localhost TCP port, so that a
+// running program embedding MonkeyLang can be inspected. Every connection gets its own session
+//
+// Clients must send Token as their first line. Inputs are then read and answered like the terminal
+// REPL, without line editing
+type Server struct {
+	Token       string   // required from every client. Empty disables authentication
+	Limits      Limits   // applied to every input of every session
+	ModulePaths []string // where sessions look up the modules they import
+
+	// Library is the standard library sessions can import modules from (see stdlib.Library for sandboxing
+	// it). nil gives sessions no standard library
+	Library *stdlib.Library
+
+	// Env, when set, is shared by every session instead of each getting its own. Sessions evaluate their
+	// inputs one at a time in an Environment enclosed by Env, so they see Env's bindings and each other's
+	// but leave Env's Observer and outputs to the program that owns it. That program must not change
+	// Env's bindings while sessions are connected
+	Env *object.Environment
+
+	once    sync.Once
+	mutex   sync.Mutex
+	env     *object.Environment // enclosed by Env and shared by every session
+	limiter *limiter
+}
+
+// Listen - Listens on a Unix socket ("unix") or a TCP address ("tcp"). TCP addresses must be on the
+// loopback interface since the REPL gives full control of the interpreter
+func Listen(network string, address string) (net.Listener, error) {
+	switch network {
+	case "unix":
+	case "tcp":
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, fmt.Errorf("refusing to listen on %s: only localhost addresses are allowed", address)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported network %q: expected unix or tcp", network)
+	}
+	return net.Listen(network, address)
+}
+
+// ListenAndServe - Listens on address (see Listen) and serves connections until an error occurs
+func (server *Server) ListenAndServe(network string, address string) error {
+	listener, err := Listen(network, address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	return server.Serve(listener)
+}
+
+// Serve - Accepts connections on listener and serves each on its own goroutine. It returns the error
+// that stopped it accepting, eg. once listener is closed
+func (server *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go server.serveConn(conn)
+	}
+}
+
+func (server *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	// A failing input must not bring down the program hosting the server
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			fmt.Fprintf(conn, "Internal error: %v\n", recovered)
+		}
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	if !server.authenticate(conn, scanner) {
+		fmt.Fprintln(conn, "Authentication failed")
+		return
+	}
+	fmt.Fprintln(conn, "Connected to MonkeyLang")
+
+	session := newSession(conn, server.ModulePaths, server.Library)
+	session.errorOutput = conn
+	session.env.SetErrorOutput(conn)
+	if server.Env != nil {
+		server.once.Do(func() {
+			server.env = object.NewEnclosedEnvrionment(server.Env)
+			server.limiter = &limiter{limits: server.Limits}
+			server.env.SetObserver(server.limiter)
+			if server.env.Importer() == nil {
+				session.loader.Attach(server.env, ".")
+			}
+		})
+		session.env, session.limiter, session.shared = server.env, server.limiter, &server.mutex
+	} else {
+		session.limit(server.Limits)
+	}
+
+	session.run(&scannerReader{scanner: scanner, out: conn})
+}
+
+func (server *Server) authenticate(conn net.Conn, scanner *bufio.Scanner) bool {
+	if server.Token == "" {
+		return true
+	}
+
+	fmt.Fprint(conn, "Token: ")
+	conn.SetReadDeadline(time.Now().Add(AUTH_TIMEOUT))
+	defer conn.SetReadDeadline(time.Time{})
+
+	if !scanner.Scan() {
+		return false
+	}
+	return subtle.ConstantTimeCompare(scanner.Bytes(), []byte(server.Token)) == 1
+}
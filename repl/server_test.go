@@ -0,0 +1,196 @@
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "secret"
+
+func startServer(t *testing.T, server *Server) string {
+	listener, err := Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned an error: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go server.Serve(listener)
+	return listener.Addr().String()
+}
+
+// connection - A client of the server that sends one input at a time and reads the reply up to the next
+// prompt
+type connection struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func connect(t *testing.T, address string, token string) (*connection, string) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("Dial returned an error: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	connection := &connection{t: t, conn: conn, reader: bufio.NewReader(conn)}
+	connection.readUntil("Token: ")
+	return connection, connection.send(token)
+}
+
+func (connection *connection) send(input string) string {
+	connection.conn.Write([]byte(input + "\n"))
+	return connection.readUntil(PROMPT)
+}
+
+// readUntil - Reads up to and including suffix, or to the end of the connection
+func (connection *connection) readUntil(suffix string) string {
+	var out strings.Builder
+	for !strings.HasSuffix(out.String(), suffix) {
+		b, err := connection.reader.ReadByte()
+		if err != nil {
+			break
+		}
+		out.WriteByte(b)
+	}
+	return strings.TrimSuffix(out.String(), suffix)
+}
+
+func TestServer(t *testing.T) {
+	address := startServer(t, &Server{Token: testToken})
+	first, greeting := connect(t, address, testToken)
+	if greeting != "Connected to MonkeyLang\n" {
+		t.Fatalf("Greeting is incorrect. Got: %q", greeting)
+	}
+
+	first.conn.Write([]byte("let add = fn(x, y) {\n"))
+	if reply := first.readUntil(CONTINUATION_PROMPT); reply != "" {
+		t.Errorf("An incomplete input should be continued. Got: %q", reply)
+	}
+	first.send("x + y };")
+	if reply := first.send("add(1, 2)"); reply != "3\n" {
+		t.Errorf("Reply is incorrect. Expected: %q. Got: %q", "3\n", reply)
+	}
+
+	// Each connection has its own session
+	second, _ := connect(t, address, testToken)
+	if reply := second.send("add"); reply != "ERROR: Unknown identifier: add\n" {
+		t.Errorf("Sessions should not share bindings. Got: %q", reply)
+	}
+}
+
+func TestServerAuthentication(t *testing.T) {
+	address := startServer(t, &Server{Token: testToken})
+	client, reply := connect(t, address, "wrong")
+	if reply != "Authentication failed\n" {
+		t.Errorf("A wrong token should be refused. Got: %q", reply)
+	}
+	if _, err := client.reader.ReadByte(); err == nil {
+		t.Errorf("The connection should be closed after a wrong token")
+	}
+}
+
+func TestServerSharedEnvironment(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("answer", &object.Integer{Value: 42})
+	address := startServer(t, &Server{Token: testToken, Env: env})
+
+	first, _ := connect(t, address, testToken)
+	second, _ := connect(t, address, testToken)
+	first.send("let double = answer * 2;")
+	if reply := second.send("double"); reply != "84\n" {
+		t.Errorf("Sessions should share the Environment. Got: %q", reply)
+	}
+	if reply := second.send(":reset"); !strings.HasPrefix(reply, "Cannot reset") {
+		t.Errorf("A shared Environment should not be reset. Got: %q", reply)
+	}
+
+	if _, ok := env.Get("double"); ok {
+		t.Errorf("Sessions should not bind names in the Environment they were given")
+	}
+}
+
+func TestServerLeavesSharedEnvironmentAlone(t *testing.T) {
+	env := object.NewEnvironment()
+	var output bytes.Buffer
+	env.SetOutput(&output)
+	address := startServer(t, &Server{Token: testToken, Env: env, Limits: Limits{MaxSteps: 10}})
+
+	client, _ := connect(t, address, testToken)
+	if reply := client.send("puts(1)"); reply != "1\nnull\n" {
+		t.Errorf("Sessions should print to their connection. Got: %q", reply)
+	}
+	if env.Observer() != nil || env.Output() != &output {
+		t.Errorf("The server should not change the Observer or output of the shared Environment")
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	address := startServer(t, &Server{Token: testToken, Limits: Limits{Timeout: time.Second, MaxSteps: 10000, MaxDepth: 50}})
+	client, _ := connect(t, address, testToken)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let loop = fn() { loop() }; loop()", "ERROR: Execution limit exceeded: more than 10000 steps\n"},
+		{"let deep = fn(n) { 1 + deep(n) }; deep(0)", "ERROR: Execution limit exceeded: more than 50 nested calls\n"},
+		// Limits apply to each input separately
+		{"let count = fn(n) { if (n == 0) { 0 } else { 1 + count(n - 1) } }; count(40)", "40\n"},
+		{"count(40)", "40\n"},
+	}
+
+	for _, test := range tests {
+		if reply := client.send(test.input); reply != test.expected {
+			t.Errorf("Reply to %q is incorrect. Expected: %q. Got: %q", test.input, test.expected, reply)
+		}
+	}
+}
+
+func TestServerUnlimitedDepth(t *testing.T) {
+	address := startServer(t, &Server{Token: testToken, Limits: Limits{Timeout: time.Minute}})
+	client, _ := connect(t, address, testToken)
+
+	input := fmt.Sprintf("let count = fn(n) { if (n == 0) { 0 } else { 1 + count(n - 1) } }; count(%d)", DEFAULT_MAX_DEPTH+1)
+	expected := fmt.Sprintf("%d\n", DEFAULT_MAX_DEPTH+1)
+	if reply := client.send(input); reply != expected {
+		t.Errorf("Reply to %q is incorrect. Expected: %q. Got: %q", input, expected, reply)
+	}
+}
+
+func TestTimeoutInterruptsExpressions(t *testing.T) {
+	limiter := &limiter{limits: Limits{Timeout: time.Nanosecond}}
+	env := object.NewEnvironment()
+	env.SetObserver(limiter)
+	limiter.reset()
+	time.Sleep(time.Millisecond)
+
+	// An expression has no statements, so only the evaluation of its nodes can notice the time is up
+	expression := parser.New(lexer.New("1 + 2")).ParseProgram().Statements[0].(*ast.ExpressionStatement).Expression
+	defer func() {
+		exceeded, ok := recover().(limitExceeded)
+		if !ok || exceeded.message != "Execution limit exceeded: ran for longer than 1ns" {
+			t.Errorf("The time limit was not enforced. Got: %+v", exceeded)
+		}
+	}()
+	evaluator.Eval(env, expression)
+}
+
+func TestListenRefusesPublicAddresses(t *testing.T) {
+	for _, address := range []string{"0.0.0.0:0", ":0", "example.com:80"} {
+		if listener, err := Listen("tcp", address); err == nil {
+			listener.Close()
+			t.Errorf("Listen(%q) should be refused", address)
+		}
+	}
+}
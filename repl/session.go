@@ -0,0 +1,252 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"monkeylang/ast"
+	"monkeylang/debugger"
+	"monkeylang/evaluator"
+	"monkeylang/format"
+	"monkeylang/lexer"
+	"monkeylang/module"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"monkeylang/stdlib"
+	"monkeylang/token"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const COMMANDS_HELP = `Commands:
+  :tokens <src>   print the tokens of src
+  :ast <src>      print the syntax tree of src
+  :env            list the bindings of the session
+  :type <expr>    print the type of the value of expr
+  :load <file>    evaluate a file into the session
+  :save <file>    write the session's let statements to a file
+  :reset          clear every binding of the session
+  :time <expr>    evaluate expr and report the time and memory it took
+  :help           show this message
+`
+
+// session - The state of one REPL: its Environment and the let statements that have been evaluated
+// successfully, so that they can be saved
+type session struct {
+	env         *object.Environment
+	out         io.Writer
+	errorOutput io.Writer // where scripts print errors. nil for the process's standard error
+	definitions []ast.Statement
+
+	loader  *module.Loader
+	limiter *limiter    // enforces the session's Limits. nil when there are none
+	shared  sync.Locker // held while evaluating when env is shared with other sessions. nil otherwise
+}
+
+// newSession - Creates a session whose imports are looked up in library (when not nil) and then in
+// modulePaths. Relative imports are resolved against the working directory. Scripts print to out
+func newSession(out io.Writer, modulePaths []string, library *stdlib.Library) *session {
+	session := &session{out: out, loader: module.NewLoader(modulePaths)}
+	if library != nil {
+		session.loader.Builtins = library
+	}
+	session.setEnv(object.NewEnvironment())
+	return session
+}
+
+// setEnv - Makes env the session's Environment, importing through the session's loader and printing to
+// its output
+func (session *session) setEnv(env *object.Environment) {
+	session.env = env
+	session.env.SetOutput(session.out)
+	if session.errorOutput != nil {
+		session.env.SetErrorOutput(session.errorOutput)
+	}
+	session.loader.Attach(session.env, ".")
+}
+
+// run - Reads and runs inputs until reader is exhausted
+func (session *session) run(reader lineReader) {
+	for {
+		input, ok := readInput(reader, session.out)
+		if strings.TrimSpace(input) != "" {
+			session.handle(input)
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// handle - Runs one command or evaluates one input
+func (session *session) handle(input string) {
+	if session.shared != nil {
+		session.shared.Lock()
+		defer session.shared.Unlock()
+		// The shared Environment prints to whichever session is evaluating
+		session.env.SetOutput(session.out)
+		session.env.SetErrorOutput(session.errorOutput)
+	}
+
+	if trimmed := strings.TrimSpace(input); strings.HasPrefix(trimmed, ":") {
+		session.command(trimmed)
+	} else {
+		session.evaluate(input)
+	}
+}
+
+// limit - Applies limits to every input the session evaluates
+func (session *session) limit(limits Limits) {
+	session.limiter = &limiter{limits: limits}
+	session.env.SetObserver(session.limiter)
+}
+
+// eval - Expands the macros of program and evaluates it in env within the session's limits. Exceeding them
+// abandons the program with an error
+func (session *session) eval(env *object.Environment, program *ast.Program) (result object.Object) {
+	if err := evaluator.ExpandMacros(env, program); err != nil {
+		return err
+	}
+	if session.limiter == nil {
+		return evaluator.Eval(env, program)
+	}
+
+	session.limiter.reset()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			exceeded, ok := recovered.(limitExceeded)
+			if !ok {
+				panic(recovered)
+			}
+			result = &object.Error{Message: exceeded.message}
+		}
+	}()
+	return evaluator.Eval(env, program)
+}
+
+// evaluate - Evaluates input in the session's Environment and prints the result
+func (session *session) evaluate(input string) object.Object {
+	program, ok := session.parse(input)
+	// Expanding the macros removes their definitions from the program
+	statements := program.Statements
+	evaluated := session.eval(session.env, program)
+	if evaluated != nil {
+		io.WriteString(session.out, evaluated.Inspect())
+		io.WriteString(session.out, "\n")
+	}
+
+	if ok && (evaluated == nil || evaluated.Type() != object.ERROR_OBJ) {
+		for _, statement := range statements {
+			if let, isLet := statement.(*ast.LetStatement); isLet {
+				session.definitions = append(session.definitions, let)
+			}
+		}
+	}
+	return evaluated
+}
+
+// parse - Parses input, printing any parser errors. ok is false if there were errors
+func (session *session) parse(input string) (program *ast.Program, ok bool) {
+	parser := parser.New(lexer.New(input))
+	program = parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		printParserErrors(session.out, parser.Errors())
+		return program, false
+	}
+	return program, true
+}
+
+// command - Runs a colon command (eg. ":env")
+func (session *session) command(input string) {
+	name, argument := splitCommand(strings.TrimPrefix(input, ":"))
+
+	switch name {
+	case "tokens":
+		lexer := lexer.New(argument)
+		for tok := lexer.NextToken(); tok.Type != token.EOF; tok = lexer.NextToken() {
+			fmt.Fprintf(session.out, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+		}
+	case "ast":
+		if program, ok := session.parse(argument); ok {
+			io.WriteString(session.out, ast.Dump(program))
+		}
+	case "env":
+		for _, name := range session.env.Names() {
+			value, _ := session.env.Get(name)
+			fmt.Fprintf(session.out, "%s = %s\n", name, debugger.Summarize(value))
+		}
+	case "type":
+		program, ok := session.parse(argument)
+		if !ok {
+			return
+		}
+		// Evaluated in an enclosed Environment so that lets in expr do not leak into the session
+		if evaluated := session.eval(object.NewEnclosedEnvrionment(session.env), program); evaluated != nil {
+			fmt.Fprintf(session.out, "%s\n", evaluated.Type())
+		}
+	case "load":
+		source, err := ioutil.ReadFile(argument)
+		if err != nil {
+			fmt.Fprintf(session.out, "Could not load %s: %s\n", argument, err)
+			return
+		}
+		session.evaluate(string(source))
+	case "save":
+		if argument == "" {
+			fmt.Fprintln(session.out, "Usage: :save <file>")
+			return
+		}
+		source := format.Source(&ast.Program{Statements: session.definitions})
+		if err := ioutil.WriteFile(argument, []byte(source), 0644); err != nil {
+			fmt.Fprintf(session.out, "Could not save %s: %s\n", argument, err)
+			return
+		}
+		fmt.Fprintf(session.out, "Saved %d definitions to %s\n", len(session.definitions), argument)
+	case "reset":
+		if session.shared != nil {
+			fmt.Fprintln(session.out, "Cannot reset an Environment shared with other sessions")
+			return
+		}
+		session.definitions = nil
+		builtins := session.loader.Builtins
+		session.loader = module.NewLoader(session.loader.Paths)
+		session.loader.Builtins = builtins
+		session.setEnv(object.NewEnvironment())
+		if session.limiter != nil {
+			session.env.SetObserver(session.limiter)
+		}
+	case "time":
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+
+		session.evaluate(argument)
+
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		fmt.Fprintf(session.out, "Time: %s, allocations: %d (%d bytes)\n",
+			elapsed, after.Mallocs-before.Mallocs, after.TotalAlloc-before.TotalAlloc)
+	case "help":
+		io.WriteString(session.out, COMMANDS_HELP)
+	default:
+		fmt.Fprintf(session.out, "Unknown command :%s. Type :help for the list of commands\n", name)
+	}
+}
+
+// splitCommand - Splits input into the command name and its argument
+func splitCommand(input string) (string, string) {
+	input = strings.TrimSpace(input)
+	if index := strings.IndexAny(input, " \t"); index >= 0 {
+		return input[:index], strings.TrimSpace(input[index+1:])
+	}
+	return input, ""
+}
+
+func printParserErrors(out io.Writer, errors []string) {
+	for _, errorMsg := range errors {
+		io.WriteString(out, "Oops we got an unexpected Parser Error: \n")
+		io.WriteString(out, "\t"+errorMsg+"\n")
+	}
+}
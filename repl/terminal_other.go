@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package repl
+
+import "errors"
+
+// makeRaw - Raw mode is only implemented for Linux. Elsewhere the REPL reads plain lines
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("raw mode is not supported on this platform")
+}
@@ -0,0 +1,358 @@
+package resolver
+
+import "monkeylang/ast"
+
+// Kind - What introduced a binding
+type Kind int
+
+const (
+	Let Kind = iota
+	Parameter
+	CatchParameter
+	PatternBinding
+	Builtin
+)
+
+func (kind Kind) String() string {
+	switch kind {
+	case Let:
+		return "let"
+	case Parameter:
+		return "parameter"
+	case CatchParameter:
+		return "catch parameter"
+	case PatternBinding:
+		return "pattern binding"
+	default:
+		return "builtin"
+	}
+}
+
+// Binding - A name introduced by a let statement, a function parameter, the parameter of a catch block, a
+// match pattern or a builtin
+type Binding struct {
+	Name        string
+	Kind        Kind
+	Declaration *ast.Identifier      // nil for builtins
+	Value       ast.Expression       // the value of a let binding
+	Function    *ast.FunctionLiteral // the function a parameter belongs to
+	Scope       *Scope               // nil for builtins
+	References  []*ast.Identifier    // every identifier that refers to this binding
+}
+
+// Scope - The bindings of the program or of one function body. Blocks of if expressions do not start a
+// new scope since the evaluator runs them in the enclosing Environment
+type Scope struct {
+	Parent   *Scope
+	Function *ast.FunctionLiteral // nil for the program scope
+	Bindings []*Binding           // in declaration order. A name is listed once per let that declares it
+	Children []*Scope
+
+	// parentCount is how many of Parent's bindings were declared when Function was defined
+	parentCount int
+	// pending are the functions defined in this scope. Their bodies are resolved once every binding of
+	// this scope is known, since they run later and may refer to names declared after them
+	pending []*Scope
+}
+
+// Resolution - The result of statically resolving every identifier of a program to its binding
+type Resolution struct {
+	Program      *ast.Program
+	Global       *Scope
+	Bindings     []*Binding                   // every let and parameter binding in the order resolved
+	Builtins     map[string]*Binding          // the builtins that are referenced
+	References   map[*ast.Identifier]*Binding // identifier uses to the binding they refer to
+	Declarations map[*ast.Identifier]*Binding // declaring identifiers to the binding they introduce
+	Unresolved   []*ast.Identifier            // identifiers that refer to no binding
+}
+
+// Resolve - Resolves the identifiers of program. Names that are not bound by the program are looked up
+// in builtins
+//
+// Function bodies run when they are called, so a function can refer to a binding of an enclosing scope
+// that is declared after it (eg. mutually recursive functions). Such references resolve to the closest
+// preceding declaration of the name, or failing that the first one that follows
+func Resolve(program *ast.Program, builtins []string) *Resolution {
+	resolver := &resolver{
+		resolution: &Resolution{
+			Program:      program,
+			Global:       &Scope{},
+			Builtins:     make(map[string]*Binding),
+			References:   make(map[*ast.Identifier]*Binding),
+			Declarations: make(map[*ast.Identifier]*Binding),
+		},
+		builtins: make(map[string]bool),
+	}
+	for _, name := range builtins {
+		resolver.builtins[name] = true
+	}
+
+	resolver.statements(resolver.resolution.Global, program.Statements)
+	resolver.resolvePending(resolver.resolution.Global)
+	return resolver.resolution
+}
+
+type resolver struct {
+	resolution *Resolution
+	builtins   map[string]bool
+}
+
+func (resolver *resolver) declare(scope *Scope, binding *Binding) {
+	binding.Scope = scope
+	scope.Bindings = append(scope.Bindings, binding)
+	resolver.resolution.Bindings = append(resolver.resolution.Bindings, binding)
+	resolver.resolution.Declarations[binding.Declaration] = binding
+}
+
+func (resolver *resolver) resolvePending(scope *Scope) {
+	for _, child := range scope.pending {
+		for _, parameter := range child.Function.Parameters {
+			resolver.destructure(child, parameter, func(name *ast.Identifier) *Binding {
+				return &Binding{Name: name.Value, Kind: Parameter, Declaration: name, Function: child.Function}
+			})
+		}
+		if child.Function.Body != nil {
+			resolver.statements(child, child.Function.Body.Statements)
+		}
+		resolver.resolvePending(child)
+	}
+	scope.pending = nil
+}
+
+func (resolver *resolver) statements(scope *Scope, statements []ast.Statement) {
+	for _, statement := range statements {
+		resolver.statement(scope, statement)
+	}
+}
+
+func (resolver *resolver) statement(scope *Scope, statement ast.Statement) {
+	switch statement := statement.(type) {
+	case *ast.LetStatement:
+		// The value is evaluated before the name is bound, so it cannot refer to the new binding directly
+		resolver.expression(scope, statement.Value)
+		if statement.Name != nil {
+			resolver.declare(scope, &Binding{Name: statement.Name.Value, Kind: Let, Declaration: statement.Name, Value: statement.Value})
+		}
+		if statement.Pattern != nil {
+			resolver.destructure(scope, statement.Pattern, func(name *ast.Identifier) *Binding {
+				return &Binding{Name: name.Value, Kind: Let, Declaration: name}
+			})
+		}
+	case *ast.ReturnStatement:
+		resolver.expression(scope, statement.ReturnValue)
+	case *ast.ExpressionStatement:
+		resolver.expression(scope, statement.Expression)
+	case *ast.BlockStatement:
+		if statement != nil {
+			resolver.statements(scope, statement.Statements)
+		}
+	}
+}
+
+func (resolver *resolver) expression(scope *Scope, expression ast.Expression) {
+	switch expression := expression.(type) {
+	case *ast.Identifier:
+		resolver.reference(scope, expression)
+	case *ast.PrefixExpression:
+		resolver.expression(scope, expression.Right)
+	case *ast.InfixExpression:
+		resolver.expression(scope, expression.Left)
+		resolver.expression(scope, expression.Right)
+	case *ast.IfExpression:
+		resolver.expression(scope, expression.Condition)
+		resolver.statement(scope, expression.Consequence)
+		if expression.Alternative != nil {
+			resolver.statement(scope, expression.Alternative)
+		}
+	case *ast.TryExpression:
+		// Like if blocks, the blocks of a try run in the enclosing Environment, which also binds the caught error
+		resolver.statement(scope, expression.Block)
+		if expression.Catch != nil {
+			if expression.Parameter != nil {
+				resolver.declare(scope, &Binding{Name: expression.Parameter.Value, Kind: CatchParameter, Declaration: expression.Parameter})
+			}
+			resolver.statement(scope, expression.Catch)
+		}
+		if expression.Finally != nil {
+			resolver.statement(scope, expression.Finally)
+		}
+	case *ast.ThrowExpression:
+		resolver.expression(scope, expression.Value)
+	case *ast.MatchExpression:
+		// Like the catch parameter, the names a pattern binds are set in the enclosing Environment
+		resolver.expression(scope, expression.Value)
+		for _, arm := range expression.Arms {
+			resolver.pattern(scope, arm.Pattern)
+			resolver.expression(scope, arm.Guard)
+			resolver.expression(scope, arm.Body)
+		}
+	case *ast.FunctionLiteral:
+		child := &Scope{Parent: scope, Function: expression, parentCount: len(scope.Bindings)}
+		scope.Children = append(scope.Children, child)
+		scope.pending = append(scope.pending, child)
+	case *ast.CallExpression:
+		if isCallTo(expression, "quote") {
+			resolver.quoted(scope, expression)
+			return
+		}
+		resolver.expression(scope, expression.Function)
+		for _, argument := range expression.Arguments {
+			resolver.expression(scope, argument)
+		}
+	case *ast.MemberExpression:
+		// The property names an export of the module (or a key of the hash), not a binding
+		resolver.expression(scope, expression.Object)
+	case *ast.ArrayLiteral:
+		for _, element := range expression.Elements {
+			resolver.expression(scope, element)
+		}
+	case *ast.HashLiteral:
+		for i, key := range expression.Keys {
+			resolver.expression(scope, key)
+			resolver.expression(scope, expression.Values[i])
+		}
+	case *ast.IndexExpression:
+		resolver.expression(scope, expression.Left)
+		resolver.expression(scope, expression.Index)
+	}
+}
+
+// quoted - Resolves the arguments of the unquote calls in a quote call. The rest of the quoted code is not
+// evaluated where it appears, so its names are left alone
+func (resolver *resolver) quoted(scope *Scope, quote *ast.CallExpression) {
+	for _, argument := range quote.Arguments {
+		ast.Walk(argument, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpression)
+			if !ok || !isCallTo(call, "unquote") {
+				return true
+			}
+			for _, argument := range call.Arguments {
+				resolver.expression(scope, argument)
+			}
+			return false
+		})
+	}
+}
+
+// isCallTo - Whether call calls the function named name
+func isCallTo(call *ast.CallExpression, name string) bool {
+	identifier, ok := call.Function.(*ast.Identifier)
+	return ok && identifier.Value == name
+}
+
+// destructure - Declares the bindings newBinding makes for the names pattern binds. Defaults are resolved
+// where they appear, so they can refer to the names bound before them
+func (resolver *resolver) destructure(scope *Scope, pattern ast.Pattern, newBinding func(name *ast.Identifier) *Binding) {
+	var elements []*ast.PatternElement
+	var rest *ast.Identifier
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		resolver.declare(scope, newBinding(pattern))
+		return
+	case *ast.ArrayPattern:
+		elements, rest = pattern.Elements, pattern.Rest
+	case *ast.HashPattern:
+		elements, rest = pattern.Elements, pattern.Rest
+	}
+
+	for _, element := range elements {
+		resolver.expression(scope, element.Default)
+		resolver.destructure(scope, element.Pattern, newBinding)
+	}
+	if rest != nil {
+		resolver.declare(scope, newBinding(rest))
+	}
+}
+
+// pattern - Declares the names a match pattern binds. _ ignores the value it matches, so it binds nothing
+func (resolver *resolver) pattern(scope *Scope, pattern ast.Expression) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			resolver.declare(scope, &Binding{Name: pattern.Value, Kind: PatternBinding, Declaration: pattern})
+		}
+	case *ast.ArrayLiteral:
+		for _, element := range pattern.Elements {
+			resolver.pattern(scope, element)
+		}
+	case *ast.HashLiteral:
+		// Keys are literals, so only the values can bind names
+		for _, value := range pattern.Values {
+			resolver.pattern(scope, value)
+		}
+	}
+}
+
+func (resolver *resolver) reference(scope *Scope, identifier *ast.Identifier) {
+	binding := lookup(scope, identifier.Value, len(scope.Bindings))
+	if binding == nil && resolver.builtins[identifier.Value] {
+		binding = resolver.resolution.Builtins[identifier.Value]
+		if binding == nil {
+			binding = &Binding{Name: identifier.Value, Kind: Builtin}
+			resolver.resolution.Builtins[identifier.Value] = binding
+		}
+	}
+
+	if binding == nil {
+		resolver.resolution.Unresolved = append(resolver.resolution.Unresolved, identifier)
+		return
+	}
+	binding.References = append(binding.References, identifier)
+	resolver.resolution.References[identifier] = binding
+}
+
+// lookup - Finds the binding name refers to in scope, when count of the scope's bindings are declared
+func lookup(scope *Scope, name string, count int) *Binding {
+	for ; scope != nil; scope, count = scope.Parent, scope.parentCount {
+		if binding := lastBefore(scope, name, count); binding != nil {
+			return binding
+		}
+		for _, binding := range scope.Bindings[count:] {
+			if binding.Name == name {
+				return binding
+			}
+		}
+	}
+	return nil
+}
+
+// lastBefore - The last binding of name among the first count bindings of scope
+func lastBefore(scope *Scope, name string, count int) *Binding {
+	for i := count - 1; i >= 0; i-- {
+		if scope.Bindings[i].Name == name {
+			return scope.Bindings[i]
+		}
+	}
+	return nil
+}
+
+// Lookup - The binding name refers to at the end of scope, searching enclosing scopes and builtins
+func (resolution *Resolution) Lookup(scope *Scope, name string) *Binding {
+	if binding := lookup(scope, name, len(scope.Bindings)); binding != nil {
+		return binding
+	}
+	return resolution.Builtins[name]
+}
+
+// ScopeAt - The innermost scope whose function body contains the given 1-based line and column
+func (resolution *Resolution) ScopeAt(line int, column int) *Scope {
+	scope := resolution.Global
+	for {
+		var inner *Scope
+		for _, child := range scope.Children {
+			body := child.Function.Body
+			if body != nil && before(body.Token.Line, body.Token.Column, line, column) &&
+				before(line, column, body.EndToken.Line, body.EndToken.Column) {
+				inner = child
+			}
+		}
+		if inner == nil {
+			return scope
+		}
+		scope = inner
+	}
+}
+
+func before(line int, column int, otherLine int, otherColumn int) bool {
+	return line < otherLine || line == otherLine && column <= otherColumn
+}
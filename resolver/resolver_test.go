@@ -0,0 +1,95 @@
+package resolver
+
+import (
+	"fmt"
+	"monkeylang/ast"
+	"monkeylang/lexer"
+	"monkeylang/parser"
+	"testing"
+)
+
+func resolve(t *testing.T, input string) *Resolution {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		t.Fatalf("Parser errors for %q: %v", input, parser.Errors())
+	}
+	return Resolve(program, []string{"len"})
+}
+
+// identifiers - Every identifier use (not declaration) of the program, in source order
+func identifiers(resolution *Resolution) []*ast.Identifier {
+	uses := []*ast.Identifier{}
+	ast.Walk(resolution.Program, func(node ast.Node) bool {
+		if identifier, ok := node.(*ast.Identifier); ok && resolution.Declarations[identifier] == nil {
+			uses = append(uses, identifier)
+		}
+		return true
+	})
+	return uses
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string // for each identifier use: "<kind> <line>:<column>" of its binding, or "unresolved"
+	}{
+		{"let x = 1; x", []string{"let 1:5"}},
+		{"let x = 1; let x = x + 1; x", []string{"let 1:5", "let 1:16"}},
+		{"fn(x) { x }", []string{"parameter 1:4"}},
+		{"let x = 1; let f = fn(x) { x }; x", []string{"parameter 1:23", "let 1:5"}},
+		{"let f = fn() { f() }", []string{"let 1:5"}},
+		{"let f = fn() { g() }; let g = fn() { f() }", []string{"let 1:27", "let 1:5"}},
+		{"let x = x", []string{"unresolved"}},
+		{"y; let y = 1", []string{"unresolved"}},
+		{"len(\"abc\")", []string{"builtin 0:0"}},
+		{"if (true) { let z = 1 } z", []string{"let 1:17"}},
+		{"try { 1 } catch (e) { e }", []string{"catch parameter 1:18"}},
+		{"match (1) { [a, b] => a + b }", []string{"pattern binding 1:14", "pattern binding 1:17"}},
+		{"let [a, b = a] = [1]; b", []string{"let 1:6", "let 1:9"}},
+		{"fn({x, y: [z]}) { x + z }", []string{"parameter 1:5", "parameter 1:12"}},
+		{"let x = 1; quote(y + unquote(x))", []string{"unresolved", "unresolved", "unresolved", "let 1:5"}},
+	}
+
+	for _, test := range tests {
+		resolution := resolve(t, test.input)
+		uses := identifiers(resolution)
+		if len(uses) != len(test.expected) {
+			t.Fatalf("Wrong number of identifiers in %q. Expected: %d. Got: %d", test.input, len(test.expected), len(uses))
+		}
+
+		for i, use := range uses {
+			got := "unresolved"
+			if binding := resolution.References[use]; binding != nil {
+				line, column := 0, 0
+				if binding.Declaration != nil {
+					line, column = binding.Declaration.Line(), binding.Declaration.Column()
+				}
+				got = fmt.Sprintf("%s %d:%d", binding.Kind, line, column)
+			}
+			if got != test.expected[i] {
+				t.Errorf("Identifier %s (#%d) in %q resolved incorrectly. Expected: %s. Got: %s", use.Value, i, test.input, test.expected[i], got)
+			}
+		}
+	}
+}
+
+func TestScopeAt(t *testing.T) {
+	input := "let a = 1;\nlet f = fn(b) {\n  let c = 2;\n  b\n};\na"
+	resolution := resolve(t, input)
+
+	if scope := resolution.ScopeAt(6, 1); scope != resolution.Global {
+		t.Errorf("ScopeAt(6, 1) should be the global scope")
+	}
+
+	scope := resolution.ScopeAt(4, 3)
+	if scope.Function == nil {
+		t.Fatalf("ScopeAt(4, 3) should be the scope of f")
+	}
+	if binding := resolution.Lookup(scope, "a"); binding == nil || binding.Scope != resolution.Global {
+		t.Errorf("Lookup of a from f should find the global binding. Got: %+v", binding)
+	}
+	if binding := resolution.Lookup(scope, "c"); binding == nil || binding.Scope != scope {
+		t.Errorf("Lookup of c from f should find the local binding. Got: %+v", binding)
+	}
+}
@@ -0,0 +1,168 @@
+package stdlib
+
+import (
+	"monkeylang/evaluator"
+	"monkeylang/object"
+	"sort"
+)
+
+// arraysModule - Functions on arrays: first, last, rest, push, concat, slice, reverse, contains, range,
+// map, filter, reduce and sort. None of them modify the arrays they are given
+func arraysModule(library *Library) map[string]object.Object {
+	return map[string]object.Object{
+		"first": builtin("arrays.first", []object.ObjectType{object.ARRAY_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			elements := args[0].(*object.Array).Elements
+			if len(elements) == 0 {
+				return evaluator.NULL
+			}
+			return elements[0]
+		}),
+		"last": builtin("arrays.last", []object.ObjectType{object.ARRAY_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			elements := args[0].(*object.Array).Elements
+			if len(elements) == 0 {
+				return evaluator.NULL
+			}
+			return elements[len(elements)-1]
+		}),
+		"rest": builtin("arrays.rest", []object.ObjectType{object.ARRAY_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			elements := args[0].(*object.Array).Elements
+			if len(elements) == 0 {
+				return evaluator.NULL
+			}
+			return array(elements[1:])
+		}),
+		"push": builtin("arrays.push", []object.ObjectType{object.ARRAY_OBJ, ""}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return array(args[0].(*object.Array).Elements, args[1])
+		}),
+		"concat": builtin("arrays.concat", []object.ObjectType{object.ARRAY_OBJ, object.ARRAY_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return array(args[0].(*object.Array).Elements, args[1].(*object.Array).Elements...)
+		}),
+		"slice": builtin("arrays.slice", []object.ObjectType{object.ARRAY_OBJ, object.INTEGER_OBJ, object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			elements := args[0].(*object.Array).Elements
+			start, end := clampIndex(args[1], len(elements)), clampIndex(args[2], len(elements))
+			if start > end {
+				start = end
+			}
+			return array(elements[start:end])
+		}),
+		"reverse": builtin("arrays.reverse", []object.ObjectType{object.ARRAY_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			elements := args[0].(*object.Array).Elements
+			reversed := make([]object.Object, len(elements))
+			for i, element := range elements {
+				reversed[len(elements)-1-i] = element
+			}
+			return &object.Array{Elements: reversed}
+		}),
+		"contains": builtin("arrays.contains", []object.ObjectType{object.ARRAY_OBJ, ""}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			for _, element := range args[0].(*object.Array).Elements {
+				if equal(element, args[1]) {
+					return evaluator.TRUE
+				}
+			}
+			return evaluator.FALSE
+		}),
+		"range": optional("arrays.range", []object.ObjectType{object.INTEGER_OBJ}, object.INTEGER_OBJ, func(env *object.Environment, args ...object.Object) object.Object {
+			start, end := int64(0), args[0].(*object.Integer).Value
+			if len(args) == 2 {
+				start, end = end, args[1].(*object.Integer).Value
+			}
+			elements := []object.Object{}
+			for i := start; i < end; i++ {
+				elements = append(elements, &object.Integer{Value: i})
+			}
+			return &object.Array{Elements: elements}
+		}),
+		"map": builtin("arrays.map", []object.ObjectType{object.ARRAY_OBJ, ""}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			mapped := []object.Object{}
+			for _, element := range args[0].(*object.Array).Elements {
+				result := evaluator.Apply(env, args[1], element)
+				if result.Type() == object.ERROR_OBJ {
+					return result
+				}
+				mapped = append(mapped, result)
+			}
+			return &object.Array{Elements: mapped}
+		}),
+		"filter": builtin("arrays.filter", []object.ObjectType{object.ARRAY_OBJ, ""}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			filtered := []object.Object{}
+			for _, element := range args[0].(*object.Array).Elements {
+				result := evaluator.Apply(env, args[1], element)
+				if result.Type() == object.ERROR_OBJ {
+					return result
+				}
+				if evaluator.IsTruthy(result) {
+					filtered = append(filtered, element)
+				}
+			}
+			return &object.Array{Elements: filtered}
+		}),
+		"reduce": builtin("arrays.reduce", []object.ObjectType{object.ARRAY_OBJ, "", ""}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			accumulator := args[1]
+			for _, element := range args[0].(*object.Array).Elements {
+				accumulator = evaluator.Apply(env, args[2], accumulator, element)
+				if accumulator.Type() == object.ERROR_OBJ {
+					return accumulator
+				}
+			}
+			return accumulator
+		}),
+		"sort": builtin("arrays.sort", []object.ObjectType{object.ARRAY_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			sorted := array(args[0].(*object.Array).Elements)
+			if len(sorted.Elements) == 0 {
+				return sorted
+			}
+			kind := sorted.Elements[0].Type()
+			for _, element := range sorted.Elements {
+				if element.Type() != kind || (kind != object.INTEGER_OBJ && kind != object.STRING_OBJ) {
+					return newError("Invalid argument to `arrays.sort`: can only sort arrays of integers or of strings")
+				}
+			}
+			sort.SliceStable(sorted.Elements, func(i, j int) bool {
+				if kind == object.INTEGER_OBJ {
+					return sorted.Elements[i].(*object.Integer).Value < sorted.Elements[j].(*object.Integer).Value
+				}
+				return sorted.Elements[i].(*object.String).Value < sorted.Elements[j].(*object.String).Value
+			})
+			return sorted
+		}),
+	}
+}
+
+// array - A new array of elements followed by more, which does not share storage with elements
+func array(elements []object.Object, more ...object.Object) *object.Array {
+	copied := make([]object.Object, 0, len(elements)+len(more))
+	copied = append(append(copied, elements...), more...)
+	return &object.Array{Elements: copied}
+}
+
+// clampIndex - index as a slice bound of an array of length elements. Negative indexes count from the end
+func clampIndex(index object.Object, length int) int {
+	value := index.(*object.Integer).Value
+	if value < 0 {
+		value += int64(length)
+	}
+	if value < 0 {
+		return 0
+	}
+	if value > int64(length) {
+		return length
+	}
+	return int(value)
+}
+
+// equal - Whether two values are the same: hashable values are compared by value, others by identity
+func equal(left object.Object, right object.Object) bool {
+	leftHashable, ok := left.(object.Hashable)
+	if !ok {
+		return left == right
+	}
+	rightHashable, ok := right.(object.Hashable)
+	return ok && leftHashable.HashKey() == rightHashable.HashKey()
+}
+
+func boolean(value bool) *object.Boolean {
+	if value {
+		return evaluator.TRUE
+	}
+	return evaluator.FALSE
+}
@@ -0,0 +1,155 @@
+package stdlib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"monkeylang/evaluator"
+	"monkeylang/object"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fsModule - read(path) and write(path, text) read and replace the text of a file, exists(path) checks
+// for a file or directory, list(dir) gives the sorted names in a directory and remove(path) deletes a
+// file or empty directory. Paths are sandboxed by the Library's Root and ReadOnly
+func fsModule(library *Library) map[string]object.Object {
+	return map[string]object.Object{
+		"read": builtin("fs.read", []object.ObjectType{object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			file, err := library.path(args[0].(*object.String).Value)
+			if err != nil {
+				return fsError("fs.read", args[0], err)
+			}
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				return fsError("fs.read", args[0], err)
+			}
+			return &object.String{Value: string(content)}
+		}),
+		"write": builtin("fs.write", []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			file, err := library.writablePath(args[0].(*object.String).Value)
+			if err != nil {
+				return fsError("fs.write", args[0], err)
+			}
+			if err := ioutil.WriteFile(file, []byte(args[1].(*object.String).Value), 0644); err != nil {
+				return fsError("fs.write", args[0], err)
+			}
+			return evaluator.NULL
+		}),
+		"exists": builtin("fs.exists", []object.ObjectType{object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			file, err := library.path(args[0].(*object.String).Value)
+			if err != nil {
+				return fsError("fs.exists", args[0], err)
+			}
+			_, err = os.Stat(file)
+			return boolean(err == nil)
+		}),
+		"list": builtin("fs.list", []object.ObjectType{object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			dir, err := library.path(args[0].(*object.String).Value)
+			if err != nil {
+				return fsError("fs.list", args[0], err)
+			}
+			infos, err := ioutil.ReadDir(dir)
+			if err != nil {
+				return fsError("fs.list", args[0], err)
+			}
+			names := []string{}
+			for _, info := range infos {
+				names = append(names, info.Name())
+			}
+			return stringArray(names)
+		}),
+		"remove": builtin("fs.remove", []object.ObjectType{object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			file, err := library.writablePath(args[0].(*object.String).Value)
+			if err != nil {
+				return fsError("fs.remove", args[0], err)
+			}
+			if err := os.Remove(file); err != nil {
+				return fsError("fs.remove", args[0], err)
+			}
+			return evaluator.NULL
+		}),
+	}
+}
+
+// path - The file a script's path refers to. With a Root, path is relative to Root (even if it is
+// absolute) and neither ".." nor symbolic links can lead out of it
+func (library *Library) path(name string) (string, error) {
+	if library.Root == "" {
+		return name, nil
+	}
+
+	file := filepath.Join(library.Root, filepath.FromSlash(path.Clean("/"+filepath.ToSlash(name))))
+	root, err := filepath.EvalSymlinks(library.Root)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := resolve(file, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if relative, err := filepath.Rel(root, resolved); err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the sandbox", name)
+	}
+	return file, nil
+}
+
+// MAX_LINKS - The most symbolic links followed to resolve a path
+const MAX_LINKS = 255
+
+// resolve - The path file refers to once every symbolic link is followed, links being the number followed
+// so far. The part of the path that does not exist yet (eg. a file being written, or the target of a
+// dangling link that writing would create) is kept as it is after its existing directory is resolved
+func resolve(file string, links int) (string, error) {
+	resolved, err := filepath.EvalSymlinks(file)
+	if err == nil || !os.IsNotExist(err) {
+		return resolved, err
+	}
+
+	if info, err := os.Lstat(file); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if links == MAX_LINKS {
+			return "", fmt.Errorf("%s: too many links", file)
+		}
+		target, err := os.Readlink(file)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(file), target)
+		}
+		return resolve(target, links+1)
+	}
+
+	dir, err := resolve(filepath.Dir(file), links)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(file)), nil
+}
+
+// writablePath - Like path for operations that change the file system. Root itself cannot be changed, eg.
+// removed when it is empty
+func (library *Library) writablePath(name string) (string, error) {
+	if library.ReadOnly {
+		return "", fmt.Errorf("cannot change %s: the file system is read only", name)
+	}
+	file, err := library.path(name)
+	if err != nil {
+		return "", err
+	}
+	if library.Root != "" && file == filepath.Clean(library.Root) {
+		return "", fmt.Errorf("cannot change %s: it is the root of the sandbox", name)
+	}
+	return file, nil
+}
+
+// fsError - The error of the function name on file. It shows file as the script gave it rather than the
+// real path
+func fsError(name string, file object.Object, err error) *object.Error {
+	if pathError, ok := err.(*os.PathError); ok {
+		err = fmt.Errorf("%s: %s", file.Inspect(), pathError.Err)
+	}
+	return newError("`%s` failed: %s", name, err)
+}
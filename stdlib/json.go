@@ -0,0 +1,27 @@
+package stdlib
+
+import (
+	"monkeylang/evaluator"
+	"monkeylang/object"
+)
+
+// jsonModule - encode(value, indent?) converts a value to JSON text, indented by indent spaces (or the
+// indent string) per level when given. decode(text) converts JSON text back to values
+func jsonModule(library *Library) map[string]object.Object {
+	return map[string]object.Object{
+		"encode": optional("json.encode", []object.ObjectType{""}, "", func(env *object.Environment, args ...object.Object) object.Object {
+			var indent object.Object
+			if len(args) == 2 {
+				indent = args[1]
+			}
+			return evaluator.EncodeJSON("json.encode", args[0], indent)
+		}),
+		"decode": builtin("json.decode", []object.ObjectType{object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			value, err := object.DecodeJSON(args[0].(*object.String).Value, evaluator.NULL)
+			if err != nil {
+				return newError("%s", err)
+			}
+			return value
+		}),
+	}
+}
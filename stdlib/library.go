@@ -0,0 +1,174 @@
+package stdlib
+
+import (
+	"fmt"
+	"math/rand"
+	"monkeylang/object"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// definitions - The exports of each module of the standard library, made for a Library
+var definitions = map[string]func(library *Library) map[string]object.Object{
+	"arrays":  arraysModule,
+	"fs":      fsModule,
+	"json":    jsonModule,
+	"math":    mathModule,
+	"os":      osModule,
+	"rand":    randModule,
+	"strings": stringsModule,
+	"time":    timeModule,
+}
+
+// Library - The modules of the standard library, imported by name (eg. `import "math"`) before the
+// module search path is looked at. Embedders can disable modules to sandbox scripts: importing a
+// disabled module is an error
+//
+// A Library can be shared by interpreters running concurrently, but its settings must not be changed
+// once modules have been imported
+type Library struct {
+	Args     []string // the values of os.args
+	Root     string   // when set, fs paths are relative to Root and cannot leave it
+	ReadOnly bool     // when set, fs cannot write or remove files
+	Seed     int64    // the initial seed of rand
+
+	mutex   sync.Mutex
+	enabled map[string]bool
+	modules map[string]*object.Module
+	random  *rand.Rand
+}
+
+// Names - The sorted names of every module of the standard library
+func Names() []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New - Creates a Library with the modules names enabled (New(Names()...) enables all of them). rand is
+// seeded with the current time
+func New(names ...string) (*Library, error) {
+	library := &Library{
+		Seed:    time.Now().UnixNano(),
+		enabled: make(map[string]bool),
+		modules: make(map[string]*object.Module),
+	}
+	for _, name := range names {
+		if err := library.Enable(name); err != nil {
+			return nil, err
+		}
+	}
+	return library, nil
+}
+
+// Parse - Creates a Library with the comma separated modules of list enabled. "all" enables every module
+// and an empty list none
+func Parse(list string) (*Library, error) {
+	if list == "all" {
+		return New(Names()...)
+	}
+	names := []string{}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return New(names...)
+}
+
+// Enable - Allows scripts to import the module name
+func (library *Library) Enable(name string) error {
+	if _, ok := definitions[name]; !ok {
+		return fmt.Errorf("unknown standard library module %q", name)
+	}
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+	library.enabled[name] = true
+	return nil
+}
+
+// Disable - Forbids scripts to import the module name
+func (library *Library) Disable(name string) {
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+	delete(library.enabled, name)
+}
+
+// Enabled - The sorted names of the enabled modules
+func (library *Library) Enabled() []string {
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+
+	names := []string{}
+	for _, name := range Names() {
+		if library.enabled[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Module - The module name, made the first time it is asked for. It is nil (without an error) when name
+// is not part of the standard library, and an error when the module is disabled. Implements
+// module.Builtins
+func (library *Library) Module(name string) (*object.Module, error) {
+	definition, ok := definitions[name]
+	if !ok {
+		return nil, nil
+	}
+
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+
+	if !library.enabled[name] {
+		return nil, fmt.Errorf("the standard library module %s is disabled", name)
+	}
+	if module, ok := library.modules[name]; ok {
+		return module, nil
+	}
+
+	module := &object.Module{Name: name, Exports: definition(library)}
+	library.modules[name] = module
+	return module, nil
+}
+
+// builtin - Wraps fn as the function name of a module. fn is only called with the number of arguments
+// given by types (or at least len(types) if variadic), each of the type it lists ("" allows any type)
+func builtin(name string, types []object.ObjectType, variadic bool, fn object.BuiltinFunction) *object.Builtin {
+	return &object.Builtin{Fn: func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) < len(types) || (!variadic && len(args) > len(types)) {
+			expected := fmt.Sprintf("%d", len(types))
+			if variadic {
+				expected = "at least " + expected
+			}
+			return newError("Invalid number of arguments to `%s`. Expected: %s, Got: %d", name, expected, len(args))
+		}
+		for i, expected := range types {
+			if expected != "" && args[i].Type() != expected {
+				return newError("Invalid argument %d to `%s`. Expected: %s, Got: %s", i+1, name, expected, args[i].Type())
+			}
+		}
+		return fn(env, args...)
+	}}
+}
+
+// optional - Like builtin for functions whose last argument (of type last) can be left out
+func optional(name string, types []object.ObjectType, last object.ObjectType, fn object.BuiltinFunction) *object.Builtin {
+	required := builtin(name, types, false, fn)
+	all := builtin(name, append(append([]object.ObjectType{}, types...), last), false, fn)
+	return &object.Builtin{Fn: func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) == len(types) {
+			return required.Fn(env, args...)
+		}
+		return all.Fn(env, args...)
+	}}
+}
+
+func newError(message string, args ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(message, args...)}
+}
@@ -0,0 +1,215 @@
+package stdlib
+
+import (
+	"io/ioutil"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/module"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func run(t *testing.T, library *Library, input string) object.Object {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		t.Fatalf("Parser errors for %q: %v", input, parser.Errors())
+	}
+
+	env := object.NewEnvironment()
+	loader := module.NewLoader(nil)
+	loader.Builtins = library
+	loader.Attach(env, ".")
+	return evaluator.Eval(env, program)
+}
+
+func newLibrary(t *testing.T, names ...string) *Library {
+	library, err := New(names...)
+	if err != nil {
+		t.Fatalf("New(%q) failed: %s", names, err)
+	}
+	return library
+}
+
+func testResults(t *testing.T, library *Library, tests []struct {
+	input    string
+	expected string
+}) {
+	for _, test := range tests {
+		evaluated := run(t, library, test.input)
+		if evaluated == nil {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: nil", test.input, test.expected)
+		} else if evaluated.Inspect() != test.expected {
+			t.Errorf("Value of %q is incorrect. Expected: %s. Got: %s", test.input, test.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let math = import "math"; math.abs(-3) + math.pow(2, 10) + math.sqrt(17)`, "1031"},
+		{`let math = import "math"; [math.min(3, 1, 2), math.max(3, 1, 2), math.clamp(9, 0, 5), math.sign(-4)]`, "[1, 3, 5, -1]"},
+		{`import "math".sqrt(import "math".max_int)`, "3037000499"},
+		{`import "math".pow(2, -1)`, "ERROR: Invalid argument to `math.pow`: negative exponent -1"},
+		{`import "math".abs("1")`, "ERROR: Invalid argument 1 to `math.abs`. Expected: INTEGER, Got: STRING"},
+		{`import "math".min()`, "ERROR: Invalid number of arguments to `math.min`. Expected: at least 1, Got: 0"},
+
+		{`let s = import "strings"; s.join(s.split("a,b,c", ","), "-")`, `a-b-c`},
+		{`let s = import "strings"; [s.upper("ab"), s.trim("  x "), s.replace("aaa", "a", "b"), s.repeat("ab", 2)]`, `["AB", "x", "bbb", "abab"]`},
+		{`let s = import "strings"; [s.contains("abc", "b"), s.index("abc", "c"), s.starts_with("abc", "ab"), s.ends_with("abc", "b")]`, `[true, 2, true, false]`},
+		{`let s = import "strings"; [s.chars("hé"), s.from([1]), s.parse_int(" 42 ")]`, `[["h", "é"], "[1]", 42]`},
+		{`import "strings".parse_int("4x")`, `ERROR: Invalid argument to ` + "`strings.parse_int`" + `: "4x" is not an integer`},
+
+		{`let a = import "arrays"; [a.first([1, 2]), a.last([1, 2]), a.rest([1, 2]), a.first([])]`, "[1, 2, [2], null]"},
+		{`let a = import "arrays"; let x = [1]; let y = a.push(x, 2); [x, y, a.concat(x, y), a.reverse(y)]`, "[[1], [1, 2], [1, 1, 2], [2, 1]]"},
+		{`let a = import "arrays"; [a.slice([1, 2, 3, 4], 1, -1), a.range(3), a.range(2, 4), a.contains(["a"], "a")]`, "[[2, 3], [0, 1, 2], [2, 3], true]"},
+		{`let a = import "arrays"; a.map(a.filter(a.range(10), fn(x) { x > 6 }), fn(x) { x * 2 })`, "[14, 16, 18]"},
+		{`let a = import "arrays"; a.reduce([1, 2, 3], 0, fn(sum, x) { sum + x })`, "6"},
+		{`let a = import "arrays"; [a.sort([3, 1, 2]), a.sort(["b", "a"])]`, `[[1, 2, 3], ["a", "b"]]`},
+		{`import "arrays".sort([1, "a"])`, "ERROR: Invalid argument to `arrays.sort`: can only sort arrays of integers or of strings"},
+		{`import "arrays".map([1], fn(x) { x + true })`, "ERROR: Mismatch types: INTEGER + BOOLEAN"},
+		{`import "arrays".map([1], fn(x, y) { x })`, "ERROR: Wrong number of arguments. Expected: 2, Got: 1"},
+		{`let a = import "arrays"; [a.map([1], fn(x) {}), a.filter([1], fn(x) { let y = x }), a.reduce([1], 0, fn(sum, x) {})]`, "[[null], [], null]"},
+
+		{`let json = import "json"; json.encode({"a": [1, "x", true], "b": json.decode("null")})`, `{"a":[1,"x",true],"b":null}`},
+		{`import "json".encode([1, {}], 2)`, "[\n  1,\n  {}\n]"},
+		{`let json = import "json"; json.decode(json.encode({"b": 1, "a": [2]})).a[0]`, "2"},
+		{`import "json".encode([1], -2)`, "ERROR: Invalid indent to `json.encode` function: -2 is negative"},
+		{`import "json".decode("[1, 2.5]")`, "ERROR: Unsupported number 2.5 at offset 4: only integers are supported"},
+		{`import "json".decode("[1,")`, "ERROR: Invalid JSON at offset 3: unexpected end of JSON input"},
+		{`import "json".decode("[1] 2")`, "ERROR: Invalid JSON at offset 4: unexpected data after the value"},
+		{`import "json".encode(fn(x) { x })`, "ERROR: Cannot encode FUNCTION_OBJ as JSON"},
+
+		{`let time = import "time"; time.format(86400000)`, "1970-01-02T00:00:00Z"},
+		{`let time = import "time"; time.since(time.now()) < 1000`, "true"},
+	}
+
+	testResults(t, newLibrary(t, Names()...), tests)
+}
+
+func TestRand(t *testing.T) {
+	library := newLibrary(t, "rand")
+	library.Seed = 42
+
+	first := run(t, library, `let rand = import "rand"; [rand.int(1000), rand.between(5, 10), rand.shuffle([1, 2, 3])]`)
+	second := run(t, library, `let rand = import "rand"; rand.seed(42); [rand.int(1000), rand.between(5, 10), rand.shuffle([1, 2, 3])]`)
+	if first.Inspect() != second.Inspect() {
+		t.Errorf("Seeded sequences are different. Expected: %s. Got: %s", first.Inspect(), second.Inspect())
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "rand".choice([7])`, "7"},
+		{`import "rand".choice([])`, "null"},
+		{`import "rand".int(0)`, "ERROR: Invalid argument to `rand.int`: 0 is not positive"},
+		{`import "rand".between(-9223372036854775807, 9223372036854775807)`, "ERROR: Invalid arguments to `rand.between`: the range from -9223372036854775807 to 9223372036854775807 is too wide"},
+		{`let n = import "rand".between(-4611686018427387904, 4611686018427387903); n < 4611686018427387903`, "true"},
+	}
+	testResults(t, library, tests)
+}
+
+func TestOS(t *testing.T) {
+	os.Setenv("MONKEY_STDLIB_TEST", "set")
+	defer os.Unsetenv("MONKEY_STDLIB_TEST")
+
+	library := newLibrary(t, "os")
+	library.Args = []string{"script.mk", "x"}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "os".args`, `["script.mk", "x"]`},
+		{`import "os".env("MONKEY_STDLIB_TEST")`, "set"},
+		{`import "os".env("MONKEY_STDLIB_TEST_UNSET")`, "null"},
+	}
+	testResults(t, library, tests)
+}
+
+func TestFSSandbox(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	os.Mkdir(root, 0755)
+	ioutil.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0644)
+	os.Symlink(dir, filepath.Join(root, "escape"))
+	os.Symlink(filepath.Join(dir, "created.txt"), filepath.Join(root, "dangling"))
+	os.Symlink("escape/missing", filepath.Join(root, "chained"))
+
+	library := newLibrary(t, "fs")
+	library.Root = root
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let fs = import "fs"; fs.write("/a.txt", "hello"); [fs.read("a.txt"), fs.exists("a.txt"), fs.exists("b.txt")]`, `["hello", true, false]`},
+		{`import "fs".list("/")`, `["a.txt", "chained", "dangling", "escape"]`},
+		{`import "fs".read("../secret.txt")`, "ERROR: `fs.read` failed: ../secret.txt: no such file or directory"},
+		{`import "fs".read("escape/secret.txt")`, "ERROR: `fs.read` failed: escape/secret.txt is outside of the sandbox"},
+		{`import "fs".write("dangling", "x")`, "ERROR: `fs.write` failed: dangling is outside of the sandbox"},
+		{`import "fs".write("escape/missing/new.txt", "x")`, "ERROR: `fs.write` failed: escape/missing/new.txt is outside of the sandbox"},
+		{`import "fs".write("chained/new.txt", "x")`, "ERROR: `fs.write` failed: chained/new.txt is outside of the sandbox"},
+		{`import "fs".read("missing/new.txt")`, "ERROR: `fs.read` failed: missing/new.txt: no such file or directory"},
+		{`let fs = import "fs"; fs.remove("a.txt"); fs.exists("a.txt")`, "false"},
+	}
+	testResults(t, library, tests)
+	if _, err := os.Stat(filepath.Join(dir, "created.txt")); !os.IsNotExist(err) {
+		t.Errorf("Writing through a dangling link created a file outside of the sandbox")
+	}
+
+	// An empty root could otherwise be removed like any empty directory
+	library.Root = filepath.Join(dir, "empty")
+	os.Mkdir(library.Root, 0755)
+	testResults(t, library, []struct {
+		input    string
+		expected string
+	}{
+		{`import "fs".remove("/")`, "ERROR: `fs.remove` failed: cannot change /: it is the root of the sandbox"},
+		{`import "fs".remove("missing/..")`, "ERROR: `fs.remove` failed: cannot change missing/..: it is the root of the sandbox"},
+	})
+	if _, err := os.Stat(library.Root); err != nil {
+		t.Errorf("The root of the sandbox was removed: %s", err)
+	}
+
+	library.ReadOnly = true
+	testResults(t, library, []struct {
+		input    string
+		expected string
+	}{
+		{`import "fs".write("a.txt", "x")`, "ERROR: `fs.write` failed: cannot change a.txt: the file system is read only"},
+	})
+}
+
+func TestEnableDisable(t *testing.T) {
+	library := newLibrary(t, "math", "strings")
+	library.Disable("strings")
+
+	if enabled := library.Enabled(); len(enabled) != 1 || enabled[0] != "math" {
+		t.Errorf("Enabled modules are incorrect. Expected: [math]. Got: %q", enabled)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "math".abs(-1)`, "1"},
+		{`import "strings"`, `ERROR: Cannot import "strings": the standard library module strings is disabled`},
+		{`import "fs"`, `ERROR: Cannot import "fs": the standard library module fs is disabled`},
+	}
+	testResults(t, library, tests)
+
+	if _, err := New("sockets"); err == nil || err.Error() != `unknown standard library module "sockets"` {
+		t.Errorf("Error for an unknown module is incorrect. Got: %v", err)
+	}
+	if library, err := Parse("all"); err != nil || len(library.Enabled()) != len(Names()) {
+		t.Errorf("Parse(\"all\") is incorrect. Expected: every module. Got: %v (%v)", library.Enabled(), err)
+	}
+}
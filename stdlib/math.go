@@ -0,0 +1,95 @@
+package stdlib
+
+import (
+	"math"
+	"monkeylang/object"
+)
+
+// mathModule - Integer arithmetic: abs, sign, min, max, pow, sqrt and clamp, and the max_int and
+// min_int constants
+func mathModule(library *Library) map[string]object.Object {
+	return map[string]object.Object{
+		"max_int": &object.Integer{Value: math.MaxInt64},
+		"min_int": &object.Integer{Value: math.MinInt64},
+
+		"abs": builtin("math.abs", []object.ObjectType{object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			value := args[0].(*object.Integer).Value
+			if value < 0 {
+				value = -value
+			}
+			return &object.Integer{Value: value}
+		}),
+		"sign": builtin("math.sign", []object.ObjectType{object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			value := args[0].(*object.Integer).Value
+			switch {
+			case value > 0:
+				return &object.Integer{Value: 1}
+			case value < 0:
+				return &object.Integer{Value: -1}
+			}
+			return &object.Integer{Value: 0}
+		}),
+		"min": builtin("math.min", []object.ObjectType{object.INTEGER_OBJ}, true, func(env *object.Environment, args ...object.Object) object.Object {
+			return extreme("math.min", args, func(value, current int64) bool { return value < current })
+		}),
+		"max": builtin("math.max", []object.ObjectType{object.INTEGER_OBJ}, true, func(env *object.Environment, args ...object.Object) object.Object {
+			return extreme("math.max", args, func(value, current int64) bool { return value > current })
+		}),
+		"pow": builtin("math.pow", []object.ObjectType{object.INTEGER_OBJ, object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			base, exponent := args[0].(*object.Integer).Value, args[1].(*object.Integer).Value
+			if exponent < 0 {
+				return newError("Invalid argument to `math.pow`: negative exponent %d", exponent)
+			}
+			result := int64(1)
+			for ; exponent > 0; exponent >>= 1 {
+				if exponent&1 == 1 {
+					result *= base
+				}
+				base *= base
+			}
+			return &object.Integer{Value: result}
+		}),
+		"sqrt": builtin("math.sqrt", []object.ObjectType{object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			value := args[0].(*object.Integer).Value
+			if value < 0 {
+				return newError("Invalid argument to `math.sqrt`: negative number %d", value)
+			}
+			// Corrects the rounding of the float square root of large values (dividing to avoid overflows)
+			root := int64(math.Sqrt(float64(value)))
+			for root > 0 && root > value/root {
+				root--
+			}
+			for root+1 <= value/(root+1) {
+				root++
+			}
+			return &object.Integer{Value: root}
+		}),
+		"clamp": builtin("math.clamp", []object.ObjectType{object.INTEGER_OBJ, object.INTEGER_OBJ, object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			value, low, high := args[0].(*object.Integer).Value, args[1].(*object.Integer).Value, args[2].(*object.Integer).Value
+			if low > high {
+				return newError("Invalid arguments to `math.clamp`: %d is greater than %d", low, high)
+			}
+			if value < low {
+				value = low
+			} else if value > high {
+				value = high
+			}
+			return &object.Integer{Value: value}
+		}),
+	}
+}
+
+// extreme - The integer of args that is better than all the others
+func extreme(name string, args []object.Object, better func(value, current int64) bool) object.Object {
+	result := args[0].(*object.Integer)
+	for i, arg := range args[1:] {
+		value, ok := arg.(*object.Integer)
+		if !ok {
+			return newError("Invalid argument %d to `%s`. Expected: %s, Got: %s", i+2, name, object.INTEGER_OBJ, arg.Type())
+		}
+		if better(value.Value, result.Value) {
+			result = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,22 @@
+package stdlib
+
+import (
+	"monkeylang/evaluator"
+	"monkeylang/object"
+	"os"
+)
+
+// osModule - args is the array of the Library's Args and env(name) the value of an environment variable
+// (null when it is not set)
+func osModule(library *Library) map[string]object.Object {
+	return map[string]object.Object{
+		"args": stringArray(library.Args),
+		"env": builtin("os.env", []object.ObjectType{object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			value, ok := os.LookupEnv(args[0].(*object.String).Value)
+			if !ok {
+				return evaluator.NULL
+			}
+			return &object.String{Value: value}
+		}),
+	}
+}
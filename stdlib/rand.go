@@ -0,0 +1,64 @@
+package stdlib
+
+import (
+	"math/rand"
+	"monkeylang/evaluator"
+	"monkeylang/object"
+)
+
+// randModule - Pseudo-random numbers from the Library's Seed: int(n) is in [0, n), between(low, high) in
+// [low, high), choice(array) is one of the elements and shuffle(array) a shuffled copy. seed(n) restarts
+// the sequence, so runs can be reproduced
+func randModule(library *Library) map[string]object.Object {
+	library.random = rand.New(rand.NewSource(library.Seed))
+
+	return map[string]object.Object{
+		"int": builtin("rand.int", []object.ObjectType{object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			n := args[0].(*object.Integer).Value
+			if n <= 0 {
+				return newError("Invalid argument to `rand.int`: %d is not positive", n)
+			}
+			return &object.Integer{Value: library.int63n(n)}
+		}),
+		"between": builtin("rand.between", []object.ObjectType{object.INTEGER_OBJ, object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			low, high := args[0].(*object.Integer).Value, args[1].(*object.Integer).Value
+			if low >= high {
+				return newError("Invalid arguments to `rand.between`: %d is not less than %d", low, high)
+			}
+			span := high - low
+			if span <= 0 {
+				return newError("Invalid arguments to `rand.between`: the range from %d to %d is too wide", low, high)
+			}
+			return &object.Integer{Value: low + library.int63n(span)}
+		}),
+		"choice": builtin("rand.choice", []object.ObjectType{object.ARRAY_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			elements := args[0].(*object.Array).Elements
+			if len(elements) == 0 {
+				return evaluator.NULL
+			}
+			return elements[library.int63n(int64(len(elements)))]
+		}),
+		"shuffle": builtin("rand.shuffle", []object.ObjectType{object.ARRAY_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			shuffled := array(args[0].(*object.Array).Elements)
+			library.mutex.Lock()
+			defer library.mutex.Unlock()
+			library.random.Shuffle(len(shuffled.Elements), func(i, j int) {
+				shuffled.Elements[i], shuffled.Elements[j] = shuffled.Elements[j], shuffled.Elements[i]
+			})
+			return shuffled
+		}),
+		"seed": builtin("rand.seed", []object.ObjectType{object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			library.mutex.Lock()
+			defer library.mutex.Unlock()
+			library.random.Seed(args[0].(*object.Integer).Value)
+			return evaluator.NULL
+		}),
+	}
+}
+
+// int63n - A random number in [0, n). The generator is shared by every interpreter using the Library
+func (library *Library) int63n(n int64) int64 {
+	library.mutex.Lock()
+	defer library.mutex.Unlock()
+	return library.random.Int63n(n)
+}
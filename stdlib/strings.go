@@ -0,0 +1,85 @@
+package stdlib
+
+import (
+	"monkeylang/object"
+	"strconv"
+	"strings"
+)
+
+// stringsModule - Functions on strings: split, join, trim, upper, lower, contains, index, replace,
+// repeat, starts_with, ends_with, chars, from (the text of any value) and parse_int
+func stringsModule(library *Library) map[string]object.Object {
+	return map[string]object.Object{
+		"split": builtin("strings.split", []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return stringArray(strings.Split(args[0].(*object.String).Value, args[1].(*object.String).Value))
+		}),
+		"join": builtin("strings.join", []object.ObjectType{object.ARRAY_OBJ, object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			parts := []string{}
+			for i, element := range args[0].(*object.Array).Elements {
+				part, ok := element.(*object.String)
+				if !ok {
+					return newError("Invalid element %d to `strings.join`. Expected: %s, Got: %s", i, object.STRING_OBJ, element.Type())
+				}
+				parts = append(parts, part.Value)
+			}
+			return &object.String{Value: strings.Join(parts, args[1].(*object.String).Value)}
+		}),
+		"trim":  stringFunction("strings.trim", strings.TrimSpace),
+		"upper": stringFunction("strings.upper", strings.ToUpper),
+		"lower": stringFunction("strings.lower", strings.ToLower),
+		"contains": builtin("strings.contains", []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return boolean(strings.Contains(args[0].(*object.String).Value, args[1].(*object.String).Value))
+		}),
+		"index": builtin("strings.index", []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return &object.Integer{Value: int64(strings.Index(args[0].(*object.String).Value, args[1].(*object.String).Value))}
+		}),
+		"replace": builtin("strings.replace", []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ, object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return &object.String{Value: strings.ReplaceAll(args[0].(*object.String).Value, args[1].(*object.String).Value, args[2].(*object.String).Value)}
+		}),
+		"repeat": builtin("strings.repeat", []object.ObjectType{object.STRING_OBJ, object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			count := args[1].(*object.Integer).Value
+			if count < 0 {
+				return newError("Invalid argument to `strings.repeat`: negative count %d", count)
+			}
+			return &object.String{Value: strings.Repeat(args[0].(*object.String).Value, int(count))}
+		}),
+		"starts_with": builtin("strings.starts_with", []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return boolean(strings.HasPrefix(args[0].(*object.String).Value, args[1].(*object.String).Value))
+		}),
+		"ends_with": builtin("strings.ends_with", []object.ObjectType{object.STRING_OBJ, object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return boolean(strings.HasSuffix(args[0].(*object.String).Value, args[1].(*object.String).Value))
+		}),
+		"chars": builtin("strings.chars", []object.ObjectType{object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			chars := []string{}
+			for _, char := range args[0].(*object.String).Value {
+				chars = append(chars, string(char))
+			}
+			return stringArray(chars)
+		}),
+		"from": builtin("strings.from", []object.ObjectType{""}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return &object.String{Value: args[0].Inspect()}
+		}),
+		"parse_int": builtin("strings.parse_int", []object.ObjectType{object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			value, err := strconv.ParseInt(strings.TrimSpace(args[0].(*object.String).Value), 10, 64)
+			if err != nil {
+				return newError("Invalid argument to `strings.parse_int`: %q is not an integer", args[0].(*object.String).Value)
+			}
+			return &object.Integer{Value: value}
+		}),
+	}
+}
+
+// stringFunction - A module function taking and returning a single string
+func stringFunction(name string, fn func(string) string) *object.Builtin {
+	return builtin(name, []object.ObjectType{object.STRING_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+		return &object.String{Value: fn(args[0].(*object.String).Value)}
+	})
+}
+
+func stringArray(values []string) *object.Array {
+	elements := make([]object.Object, len(values))
+	for i, value := range values {
+		elements[i] = &object.String{Value: value}
+	}
+	return &object.Array{Elements: elements}
+}
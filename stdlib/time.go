@@ -0,0 +1,36 @@
+package stdlib
+
+import (
+	"monkeylang/evaluator"
+	"monkeylang/object"
+	"time"
+)
+
+// timeModule - Times are integers counting milliseconds since the Unix epoch. now() is the current time,
+// since(start) the milliseconds elapsed since start, sleep(ms) pauses and format(time, layout?) writes a
+// time in UTC with a Go layout (RFC 3339 by default)
+func timeModule(library *Library) map[string]object.Object {
+	return map[string]object.Object{
+		"now": builtin("time.now", []object.ObjectType{}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return &object.Integer{Value: milliseconds(time.Now())}
+		}),
+		"since": builtin("time.since", []object.ObjectType{object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			return &object.Integer{Value: milliseconds(time.Now()) - args[0].(*object.Integer).Value}
+		}),
+		"sleep": builtin("time.sleep", []object.ObjectType{object.INTEGER_OBJ}, false, func(env *object.Environment, args ...object.Object) object.Object {
+			time.Sleep(time.Duration(args[0].(*object.Integer).Value) * time.Millisecond)
+			return evaluator.NULL
+		}),
+		"format": optional("time.format", []object.ObjectType{object.INTEGER_OBJ}, object.STRING_OBJ, func(env *object.Environment, args ...object.Object) object.Object {
+			layout := time.RFC3339
+			if len(args) == 2 {
+				layout = args[1].(*object.String).Value
+			}
+			return &object.String{Value: time.Unix(0, args[0].(*object.Integer).Value*int64(time.Millisecond)).UTC().Format(layout)}
+		}),
+	}
+}
+
+func milliseconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
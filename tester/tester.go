@@ -0,0 +1,121 @@
+package tester
+
+import (
+	"monkeylang/ast"
+	"monkeylang/evaluator"
+	"monkeylang/object"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FILE_SUFFIX - The suffix of the names of the files holding tests
+const FILE_SUFFIX = "_test.mk"
+
+// TEST_PREFIX - The prefix of the names of test functions: top level lets binding a function without
+// parameters, eg. let test_sum = fn() { assert_eq(sum([1, 2]), 3) }
+const TEST_PREFIX = "test_"
+
+// Test - A test function declared by a program
+type Test struct {
+	Name string
+	Line int
+}
+
+// Result - The outcome of running a test. Error is nil when the test passed
+type Result struct {
+	Test
+	Error    *object.Error
+	Duration time.Duration
+}
+
+// Passed - Whether the test passed
+func (result Result) Passed() bool { return result.Error == nil }
+
+// Tests - The test functions program declares, in order. A name bound more than once is a single test,
+// at its last declaration
+func Tests(program *ast.Program) []Test {
+	tests := []Test{}
+	index := map[string]int{}
+	for _, statement := range program.Statements {
+		let, ok := statement.(*ast.LetStatement)
+		if !ok || let.Name == nil || !strings.HasPrefix(let.Name.Value, TEST_PREFIX) {
+			continue
+		}
+		function, ok := let.Value.(*ast.FunctionLiteral)
+		if !ok || function.IsMacro() || len(function.Parameters) != 0 {
+			continue
+		}
+
+		test := Test{Name: let.Name.Value, Line: let.Line()}
+		if i, ok := index[test.Name]; ok {
+			tests[i] = test
+			continue
+		}
+		index[test.Name] = len(tests)
+		tests = append(tests, test)
+	}
+	return tests
+}
+
+// Run - Evaluates program in env and then calls each of its tests whose name matches filter (every test
+// when filter is nil), in order. The tests share env, so one test sees the bindings set by the top level
+// code but not those set by other tests. The error is the one raised by the top level code, if any, in
+// which case no test is run
+func Run(env *object.Environment, program *ast.Program, filter *regexp.Regexp) ([]Result, *object.Error) {
+	if err := evaluator.ExpandMacros(env, program); err != nil {
+		return nil, err
+	}
+	if err, ok := evaluator.Eval(env, program).(*object.Error); ok {
+		return nil, err
+	}
+
+	results := []Result{}
+	for _, test := range Tests(program) {
+		if filter != nil && !filter.MatchString(test.Name) {
+			continue
+		}
+
+		function, _ := env.Get(test.Name)
+		start := time.Now()
+		result := Result{Test: test}
+		if err, ok := evaluator.Apply(env, function).(*object.Error); ok {
+			result.Error = err
+		}
+		result.Duration = time.Since(start)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Discover - The test files among paths: files are taken as they are and directories are searched
+// recursively for files named with FILE_SUFFIX, in lexical order
+func Discover(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && strings.HasSuffix(file, FILE_SUFFIX) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,119 @@
+package tester
+
+import (
+	"fmt"
+	"io/ioutil"
+	"monkeylang/ast"
+	"monkeylang/lexer"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		t.Fatalf("Parser errors for %q: %v", input, parser.Errors())
+	}
+	return program
+}
+
+func TestTests(t *testing.T) {
+	input := `
+let test_a = fn() { 1 };
+let helper = fn() { 2 };
+let test_b = fn(x) { x };
+let test_c = 3;
+let [test_d] = [fn() { 4 }];
+let test_e = fn() { 5 };
+let test_a = fn() { 6 };
+`
+	expected := []Test{{Name: "test_a", Line: 8}, {Name: "test_e", Line: 7}}
+	if tests := Tests(parse(t, input)); !reflect.DeepEqual(tests, expected) {
+		t.Errorf("Wrong tests. Expected: %v. Got: %v", expected, tests)
+	}
+}
+
+func TestRun(t *testing.T) {
+	input := `
+let total = 3;
+let test_passes = fn() { assert_eq(1 + 2, total) };
+let test_fails = fn() {
+  let values = [1, 2];
+  assert_eq(values, [1, 3], "values")
+};
+let test_errors = fn() { total + true };
+let test_skipped = fn() { assert(false) };
+`
+	tests := []struct {
+		filter   *regexp.Regexp
+		expected []string // "<name> <line> <error>" for each result
+	}{
+		{regexp.MustCompile("pass|fail|err"), []string{
+			"test_passes 3 <nil>",
+			"test_fails 4 values\nExpected: [1, 3]\n     Got: [1, 2]\n              ^ [assert_eq called at line 6]",
+			"test_errors 8 Mismatch types: INTEGER + BOOLEAN []",
+		}},
+		{regexp.MustCompile("^test_skipped$"), []string{"test_skipped 9 Expected a truthy value. Got: false [assert called at line 9]"}},
+		{regexp.MustCompile("none"), []string{}},
+	}
+
+	for _, test := range tests {
+		results, err := Run(object.NewEnvironment(), parse(t, input), test.filter)
+		if err != nil {
+			t.Fatalf("Top level error: %s", err.Message)
+		}
+		if len(results) != len(test.expected) {
+			t.Errorf("Wrong number of results for %s. Expected: %d. Got: %d", test.filter, len(test.expected), len(results))
+			continue
+		}
+		for i, result := range results {
+			got := fmt.Sprintf("%s %d <nil>", result.Name, result.Line)
+			if !result.Passed() {
+				got = fmt.Sprintf("%s %d %s %v", result.Name, result.Line, result.Error.Message, result.Error.Stack)
+			}
+			if got != test.expected[i] {
+				t.Errorf("Result %d for %s is incorrect. Expected: %q. Got: %q", i, test.filter, test.expected[i], got)
+			}
+		}
+	}
+}
+
+func TestRunTopLevelError(t *testing.T) {
+	results, err := Run(object.NewEnvironment(), parse(t, "let test_a = fn() { 1 }; missing"), nil)
+	if err == nil || err.Message != "Unknown identifier: missing" {
+		t.Errorf("Wrong error. Expected: Unknown identifier: missing. Got: %v", err)
+	}
+	if results != nil {
+		t.Errorf("Tests ran after a top level error: %v", results)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b_test.mk", "a.mk", "lib/c_test.mk", "lib/d.mk"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(""), 0644); err != nil {
+			t.Fatalf("Could not write %s: %s", path, err)
+		}
+	}
+
+	files, err := Discover([]string{dir, filepath.Join(dir, "a.mk")})
+	if err != nil {
+		t.Fatalf("Discover failed: %s", err)
+	}
+	expected := []string{filepath.Join(dir, "b_test.mk"), filepath.Join(dir, "lib/c_test.mk"), filepath.Join(dir, "a.mk")}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Wrong files. Expected: %v. Got: %v", expected, files)
+	}
+
+	if _, err := Discover([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Errorf("Expected an error for a missing path")
+	}
+}
@@ -1,5 +1,7 @@
 package token
 
+import "sort"
+
 // TokenType - token string identifier (change this to enum)
 type TokenType string
 
@@ -7,9 +9,11 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int // 1-based line the token starts on
+	Column  int // 1-based column (in bytes) the token starts on
 }
 
-//TODO: Refactor this to use enums instead of strings (see: iota)
+// TODO: Refactor this to use enums instead of strings (see: iota)
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = ""
@@ -32,15 +36,27 @@ const (
 	LESS       = "<"
 	GREATER    = ">"
 
+	// Null-safe operators
+	NULLISH          = "??"
+	QUESTION_DOT     = "?."
+	QUESTION_BRACKET = "?["
+
 	// Punctuation
 	COMMA     = ","
 	SEMICOLON = ";"
+	DOT       = "."
+	ELLIPSIS  = "..."
+	COLON     = ":"
+	ARROW     = "=>"
+	RARROW    = "->"
 
 	// Brackets
-	LPAREN = "("
-	RPAREN = ")"
-	LBRACE = "{"
-	RBRACE = "}"
+	LPAREN   = "("
+	RPAREN   = ")"
+	LBRACE   = "{"
+	RBRACE   = "}"
+	LBRACKET = "["
+	RBRACKET = "]"
 
 	// Keywords
 	LET      = "LET"
@@ -50,18 +66,48 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	IMPORT   = "IMPORT"
+	EXPORT   = "EXPORT"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	THROW    = "THROW"
+	NULL     = "NULL"
+	MATCH    = "MATCH"
+	MACRO    = "MACRO"
 
-	STRING = "STRING"
+	STRING  = "STRING"
+	COMMENT = "COMMENT" // "// text" to the end of the line. Comments are skipped by the lexer
 )
 
 var keywords = map[string]TokenType{
-	"let":    LET,
-	"fn":     FUNCTION,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"let":     LET,
+	"fn":      FUNCTION,
+	"true":    TRUE,
+	"false":   FALSE,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"import":  IMPORT,
+	"export":  EXPORT,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"throw":   THROW,
+	"raise":   THROW,
+	"null":    NULL,
+	"match":   MATCH,
+	"macro":   MACRO,
+}
+
+// Keywords - The sorted reserved words of the language
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
 }
 
 // NewToken - Create a new Token from a tokenType and char
@@ -0,0 +1,152 @@
+package tracer
+
+import (
+	"fmt"
+	"io"
+	"monkeylang/ast"
+	"monkeylang/format"
+	"monkeylang/object"
+	"strings"
+)
+
+// MAX_TEXT - The most characters of code or of a value written for an event. Longer ones are cut
+const MAX_TEXT = 60
+
+// Tracer - Writes the events of a running program to an io.Writer, one per line, indented by the depth of
+// the calls being run. It receives every event the evaluator sends, but only writes the evaluation of each
+// node when Nodes is set
+type Tracer struct {
+	Nodes bool
+
+	out   io.Writer
+	depth int
+}
+
+// New - Creates a tracer writing to out
+func New(out io.Writer) *Tracer {
+	return &Tracer{out: out}
+}
+
+func (tracer *Tracer) write(message string, args ...interface{}) {
+	fmt.Fprintf(tracer.out, "%s%s\n", strings.Repeat("  ", tracer.depth), fmt.Sprintf(message, args...))
+}
+
+// short - text on a single line, cut to MAX_TEXT characters
+func short(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) > MAX_TEXT {
+		return text[:MAX_TEXT-3] + "..."
+	}
+	return text
+}
+
+// values - The Inspect of values separated by commas, with strings quoted and functions reduced to their
+// parameters
+func values(values []object.Object) string {
+	texts := []string{}
+	for _, value := range values {
+		switch value := value.(type) {
+		case *object.String:
+			texts = append(texts, fmt.Sprintf("%q", value.Value))
+		case *object.Function:
+			parameters := []string{}
+			for _, parameter := range value.Parameters {
+				parameters = append(parameters, format.Node(parameter))
+			}
+			texts = append(texts, "fn("+strings.Join(parameters, ", ")+")")
+		default:
+			texts = append(texts, value.Inspect())
+		}
+	}
+	return short(strings.Join(texts, ", "))
+}
+
+// code - The code of node on a single line. The code of a whole program is not written
+func code(node ast.Node) string {
+	if _, ok := node.(*ast.Program); ok {
+		return "<program>"
+	}
+	return short(format.Node(node))
+}
+
+// callee - The code of the function call calls
+func callee(call *ast.CallExpression) string {
+	if call == nil {
+		return "<anonymous>"
+	}
+	return short(format.Node(call.Function))
+}
+
+// Statement - Implements object.Observer
+func (tracer *Tracer) Statement(env *object.Environment, statement ast.Statement) {
+	tracer.write("%d: %s", statement.Line(), code(statement))
+}
+
+// Call - Implements object.Observer
+func (tracer *Tracer) Call(env *object.Environment, call *ast.CallExpression, function *object.Function, args []object.Object) {
+	tracer.write("call %s(%s)", callee(call), values(args))
+	tracer.depth++
+}
+
+// Return - Implements object.Observer
+func (tracer *Tracer) Return(function *object.Function, result object.Object) {
+	tracer.depth--
+	if _, ok := result.(*object.TailCall); ok {
+		tracer.write("return by a tail call")
+		return
+	}
+	tracer.write("return %s", values([]object.Object{result}))
+}
+
+// CallBuiltin - Implements object.BuiltinObserver
+func (tracer *Tracer) CallBuiltin(env *object.Environment, call *ast.CallExpression, builtin *object.Builtin, args []object.Object) {
+	tracer.write("call builtin %s(%s)", callee(call), values(args))
+	tracer.depth++
+}
+
+// ReturnBuiltin - Implements object.BuiltinObserver
+func (tracer *Tracer) ReturnBuiltin(builtin *object.Builtin, result object.Object) {
+	tracer.depth--
+	tracer.write("return %s", values([]object.Object{result}))
+}
+
+// Branch - Implements object.BranchObserver
+func (tracer *Tracer) Branch(env *object.Environment, ifExpression *ast.IfExpression, consequence bool) {
+	branch := "alternative"
+	if consequence {
+		branch = "consequence"
+	}
+	tracer.write("if at %d:%d takes the %s", ifExpression.Line(), ifExpression.Column(), branch)
+}
+
+// Bind - Implements object.BindingObserver
+func (tracer *Tracer) Bind(env *object.Environment, name string, value object.Object) {
+	tracer.write("bind %s = %s", name, values([]object.Object{value}))
+}
+
+// Error - Implements object.ErrorObserver
+func (tracer *Tracer) Error(env *object.Environment, node ast.Node, err *object.Error) {
+	tracer.write("error at %d:%d: %s", node.Line(), node.Column(), short(err.Message))
+}
+
+// Enter - Implements object.NodeObserver
+func (tracer *Tracer) Enter(env *object.Environment, node ast.Node) {
+	if !tracer.Nodes {
+		return
+	}
+	tracer.write("enter %s", code(node))
+	tracer.depth++
+}
+
+// Exit - Implements object.NodeObserver
+func (tracer *Tracer) Exit(env *object.Environment, node ast.Node, result object.Object) {
+	if !tracer.Nodes {
+		return
+	}
+	tracer.depth--
+	if result == nil {
+		tracer.write("exit %s", code(node))
+		return
+	}
+	tracer.write("exit %s = %s", code(node), values([]object.Object{result}))
+}
@@ -0,0 +1,82 @@
+package tracer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"monkeylang/evaluator"
+	"monkeylang/lexer"
+	"monkeylang/object"
+	"monkeylang/parser"
+	"testing"
+)
+
+// trace - The trace of input, with the evaluation of each node when nodes is set
+func trace(t *testing.T, input string, nodes bool) string {
+	parser := parser.New(lexer.New(input))
+	program := parser.ParseProgram()
+	if len(parser.Errors()) != 0 {
+		t.Fatalf("Parser errors for %q: %v", input, parser.Errors())
+	}
+
+	var out bytes.Buffer
+	tracer := New(&out)
+	tracer.Nodes = nodes
+	env := object.NewEnvironment()
+	env.SetOutput(ioutil.Discard)
+	env.SetObserver(tracer)
+	evaluator.Eval(env, program)
+	return out.String()
+}
+
+func TestTracer(t *testing.T) {
+	input := `let half = fn(n) {
+  if (n / 2 * 2 != n) { throw "odd" }
+  n / 2
+};
+half(len("four"));
+try { half(3) } catch (e) { e.message };`
+
+	expected := `1: let half = fn(n) { if (n / 2 * 2 != n) { throw "odd"; } n...
+bind half = fn(n)
+5: half(len("four"));
+call builtin len("four")
+return 4
+bind n = 4
+call half(4)
+  2: if (n / 2 * 2 != n) { throw "odd"; }
+  if at 2:3 takes the alternative
+  3: n / 2;
+return 2
+6: try { half(3); } catch (e) { e.message; }
+6: half(3);
+bind n = 3
+call half(3)
+  2: if (n / 2 * 2 != n) { throw "odd"; }
+  if at 2:3 takes the consequence
+  2: throw "odd";
+  error at 2:25: odd
+return ERROR: odd
+bind e = Error: odd
+6: e.message;
+`
+	if got := trace(t, input, false); got != expected {
+		t.Errorf("Trace is incorrect. Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestTracerNodes(t *testing.T) {
+	expected := `enter <program>
+  1: let x = -1;
+  enter let x = -1;
+    enter -1
+      enter 1
+      exit 1 = 1
+    exit -1 = -1
+    bind x = -1
+  exit let x = -1;
+exit <program>
+`
+	if got := trace(t, "let x = -1;", true); got != expected {
+		t.Errorf("Trace is incorrect. Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}